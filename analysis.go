@@ -0,0 +1,324 @@
+package golisp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The rules of the diagnostics that Diagnose reports along with those of the linter.
+const (
+	RuleSyntax     = "syntax"     // a script that can't be parsed
+	RuleEvaluation = "evaluation" // a form that fails when it is evaluated, whatever the host binds
+)
+
+// diagnoseTimeout bounds the evaluation of each form that Diagnose checks.
+const diagnoseTimeout = 100 * time.Millisecond
+
+// diagnoseLimits bounds the work done evaluating the forms of a script, which may be half written.
+var diagnoseLimits = Limits{MaxSteps: 100000, MaxDepth: 100, MaxCollectionSize: 100000, MaxAllocatedBytes: 64 << 20}
+
+// Diagnose reports the problems that an editor shows in a script: where it fails to parse or, if it parses, the
+// diagnostics of LintSource and the errors of the forms that fail when they are evaluated.
+//
+// Only the forms that use nothing but the symbols bound in the context and the functions of its libraries are
+// evaluated, so that no go function or host object is called. Each is evaluated in a context of its own, with a short
+// timeout and tight limits; running out of either isn't reported, since the host may allow more.
+func (ctx *EvaluationContext) Diagnose(src string) []Diagnostic {
+	diagnostics, e := ctx.checkSource(src, ctx.diagnoseForms)
+	if e == nil && hasUnterminatedString(src) {
+		// the parser reads the rest of the line as an identifier
+		e = buildUnexpectedEndOfStringError()
+	}
+	if e != nil {
+		at := locateSyntaxError(src)
+		return []Diagnostic{{Rule: RuleSyntax, Severity: SeverityError, Position: at.start, End: at.end, Message: e.Error()}}
+	}
+	return diagnostics
+}
+
+func hasUnterminatedString(src string) bool {
+	tokenizer := newTokenizerContext(src)
+	for t := tokenizer.NextToken(); t != nil; t = tokenizer.NextToken() {
+		if t.tokenType == TOK_SYMBOL && isUnterminatedString(t.rawValue(tokenizer)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ctx *EvaluationContext) diagnoseForms(forms []SExpr) []Diagnostic {
+	diagnostics := ctx.lintForms(forms)
+	for _, form := range forms {
+		if !ctx.isEvaluable(form) || isReportedWithin(diagnostics, spanOf(form)) {
+			continue
+		}
+
+		if e, failures := ctx.evaluationError(form); e != nil {
+			at := spanOf(failures.locate(form, e))
+			diagnostics = append(diagnostics, Diagnostic{Rule: RuleEvaluation, Severity: SeverityError, Position: at.start, End: at.end, Message: e.Error()})
+		}
+	}
+
+	sortDiagnostics(diagnostics)
+	return diagnostics
+}
+
+// isReportedWithin reports whether the linter has found an error or warning in the span, which the error of evaluating
+// it would most likely repeat.
+func isReportedWithin(diagnostics []Diagnostic, at span) bool {
+	for _, d := range diagnostics {
+		if d.Severity != SeverityInfo && d.Position.IsValid() && d.Position.Offset >= at.start.Offset && d.Position.Offset < at.end.Offset {
+			return true
+		}
+	}
+	return false
+}
+
+// isEvaluable reports whether the expression uses nothing but the symbols bound in the context and the functions of
+// its libraries. Symbols bound to host objects and functions don't count, since using them may call the host.
+func (ctx *EvaluationContext) isEvaluable(expr SExpr) bool {
+	evaluable := true
+	walk(expr, func(node SExpr) {
+		a, ok := node.(*atom)
+		if !ok || a.typedValue.VariantType != VAR_IDENT {
+			return
+		}
+
+		name, _ := a.typedValue.GetIdentifierValue()
+		if i := strings.Index(name, propertySeparator); i > 0 && !ctx.isSymbol(name) && !ctx.isFunction(name) {
+			name = name[:i]
+		}
+
+		switch {
+		case ctx.isSymbol(name):
+			t := ctx.lookupIdentifier(name).VariantType
+			evaluable = evaluable && t != VAR_OBJECT && t != VAR_FUNCTION
+		case ctx.isFunction(name):
+			evaluable = evaluable && ctx.capabilityOf(name) != CapabilityHost
+		default:
+			evaluable = false
+		}
+	})
+	return evaluable
+}
+
+// evaluationError evaluates the expression in a context of its own, returning the error it fails with, if any, and the
+// calls that failed along the way.
+func (ctx *EvaluationContext) evaluationError(expr SExpr) (error, *failureLog) {
+	failures := &failureLog{}
+	child := NewEvaluationContext(ctx, WithLimits(diagnoseLimits))
+	child.failures = failures
+	v := EvalWithTimeout(expr, child, diagnoseTimeout)
+
+	e, ok := v.VariantValue.(error)
+	if v.VariantType != VAR_ERROR || !ok || errors.Is(e, context.DeadlineExceeded) || errors.Is(e, errLimitExceeded) {
+		return nil, nil
+	}
+	return e, failures
+}
+
+// failureLog records the calls that fail during an evaluation, other than those that only pass on the error of an
+// argument. Futures record their calls from other goroutines.
+type failureLog struct {
+	mutex    sync.Mutex
+	failures []failure
+}
+
+type failure struct {
+	expr SExpr
+	err  error
+}
+
+func (l *failureLog) record(expr SExpr, result Variant, args []Variant) {
+	if l == nil || result.VariantType != VAR_ERROR {
+		return
+	}
+	for _, arg := range args {
+		if arg.VariantType == VAR_ERROR {
+			return
+		}
+	}
+
+	e, _ := result.VariantValue.(error)
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.failures = append(l.failures, failure{expr: expr, err: e})
+}
+
+// locate returns the call that first failed with the error, which is where the mistake most likely is, or the
+// expression if the error didn't come from a call.
+func (l *failureLog) locate(expr SExpr, e error) SExpr {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for _, f := range l.failures {
+		if f.err != nil && f.err.Error() == e.Error() {
+			return f.expr
+		}
+	}
+	return expr
+}
+
+// locateSyntaxError finds where a script that can't be parsed goes wrong: at an unterminated string, an unmatched
+// bracket or, failing those, the innermost form that can't be parsed on its own.
+func locateSyntaxError(src string) span {
+	tokenizer := newTokenizerContext(src)
+	open := []*token{}
+
+	for t := tokenizer.NextToken(); t != nil; t = tokenizer.NextToken() {
+		switch t.tokenType {
+		case TOK_LPAREN, TOK_LBRACKET, TOK_LBRACE, TOK_LSET:
+			open = append(open, t)
+
+		case TOK_RPAREN, TOK_RBRACKET, TOK_RBRACE:
+			if len(open) == 0 || closingTokens[open[len(open)-1].tokenType] != t.tokenType {
+				return tokenizer.spanOf(t)
+			}
+			open = open[:len(open)-1]
+
+		case TOK_SYMBOL:
+			if isUnterminatedString(t.rawValue(tokenizer)) {
+				return tokenizer.spanOf(t)
+			}
+		}
+	}
+
+	if len(open) > 0 {
+		return tokenizer.spanOf(open[len(open)-1])
+	}
+
+	nodes, e := parseSyntax(src)
+	if e == nil {
+		if at, found := locateUnparsable(src, nodes); found {
+			return at
+		}
+	}
+	return span{start: Position{Line: 1, Column: 1}, end: Position{Line: 1, Column: 1}}
+}
+
+func locateUnparsable(src string, nodes []*syntaxNode) (span, bool) {
+	for _, n := range nodes {
+		if n.kind == syntaxComment {
+			continue
+		}
+		if _, e := ParseAll(src[n.start.Offset:n.end.Offset]); e == nil {
+			continue
+		}
+
+		if at, found := locateUnparsable(src, n.children); found {
+			return at, true
+		}
+		return n.span, true
+	}
+	return span{}, false
+}
+
+// FunctionInfo describes a function of a context, as an editor shows it.
+type FunctionInfo struct {
+	Name          string
+	Namespace     string // of the library that provides the function, or CapabilityHost for a go function
+	Documentation string // "" if the library doesn't document the function
+	Arity         string // such as "exactly 2 arguments", or "" if the library doesn't describe the function
+}
+
+// DescribeFunction describes the function that the name refers to in the context, from the documentation and signature
+// of its library.
+func (ctx *EvaluationContext) DescribeFunction(name string) (FunctionInfo, bool) {
+	if ctx.isSymbol(name) || !ctx.isFunction(name) {
+		return FunctionInfo{}, false
+	}
+
+	info := FunctionInfo{Name: name, Namespace: ctx.capabilityOf(name)}
+	if ctx.registry != nil {
+		info.Documentation, _ = ctx.registry.Documentation(name)
+		if signature, found := ctx.registry.Signature(name); found {
+			info.Arity = signature.DescribeArity()
+		}
+	}
+	return info, true
+}
+
+// TokenKind classifies the tokens of a script for highlighting.
+type TokenKind uint8
+
+const (
+	TokenComment     TokenKind = iota
+	TokenString                // including interpolated strings, as a whole
+	TokenNumber                // ints and floats
+	TokenDate                  // anything that dateparse reads as a date
+	TokenBool                  // true and false
+	TokenKeyword               // such as :name
+	TokenFunction              // a name that refers to a function
+	TokenSpecialForm           // future, whose argument isn't evaluated first
+	TokenSymbol                // any other name, whether or not it is bound
+)
+
+// Token is a comment, string or atom of a script. Brackets aren't tokens.
+type Token struct {
+	Kind  TokenKind
+	Text  string
+	Start Position
+	End   Position
+}
+
+// Tokens classifies the comments, strings and atoms of a script, using the context to tell functions from symbols.
+// Scripts that can't be parsed are classified as far as they can be, so that they can be highlighted as they are
+// written.
+func (ctx *EvaluationContext) Tokens(src string) []Token {
+	tokenizer := newTokenizerContext(src)
+	tokens := []Token{}
+
+	for t := tokenizer.NextToken(); t != nil; t = tokenizer.NextToken() {
+		raw := t.rawValue(tokenizer)
+		at := tokenizer.spanOf(t)
+
+		var kind TokenKind
+		switch t.tokenType {
+		case TOK_COMMENT:
+			kind = TokenComment
+		case TOK_QUOTEDSTRING, TOK_INTERPOLATEDSTRING:
+			kind = TokenString
+		case TOK_SYMBOL:
+			kind = ctx.symbolKind(raw)
+		default:
+			continue
+		}
+
+		tokens = append(tokens, Token{Kind: kind, Text: raw, Start: at.start, End: at.end})
+	}
+	return tokens
+}
+
+func (ctx *EvaluationContext) symbolKind(raw string) TokenKind {
+	if isUnterminatedString(raw) {
+		return TokenString
+	}
+
+	v, e := parseSymbol(raw)
+	if e != nil {
+		// a malformed number, such as 1__0
+		return TokenNumber
+	}
+
+	switch v.VariantType {
+	case VAR_KEYWORD:
+		return TokenKeyword
+	case VAR_INT, VAR_FLOAT:
+		return TokenNumber
+	case VAR_DATE:
+		return TokenDate
+	case VAR_BOOL:
+		return TokenBool
+	}
+
+	switch {
+	case ctx.isSymbol(raw) || !ctx.isFunction(raw):
+		return TokenSymbol
+	case ctx.asyncForm(&atom{typedValue: v}) != nil:
+		return TokenSpecialForm
+	default:
+		return TokenFunction
+	}
+}
@@ -0,0 +1,131 @@
+package golisp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagnose(t *testing.T) {
+	tests := [...]struct {
+		desc     string
+		input    string
+		expected []string
+	}{
+		{desc: "clean script", input: "(and (> amount 100) (= country \"NZ\"))", expected: []string{}},
+		{desc: "unclosed list", input: "(and\n  (> amount 100)", expected: []string{`1:1: error: parse error: unexpected end of string (syntax)`}},
+		{desc: "unmatched bracket", input: "(+ 1 2))", expected: []string{`1:8: error: parse error: unexpected close paren (syntax)`}},
+		{desc: "mismatched bracket", input: "[1 2)", expected: []string{`1:5: error: parse error: unexpected close paren (syntax)`}},
+		{desc: "unterminated string", input: "(concat 1\n  \"a b)", expected: []string{`2:3: error: parse error: unexpected end of string (syntax)`}},
+		{desc: "malformed form", input: "(+ 1)\n(get {:a} :a)", expected: []string{`2:6: error: parse error: map literal must have an even number of forms (syntax)`}},
+		{desc: "malformed atom", input: "(+ 1 1__0)", expected: []string{`1:6: error: parse error: invalid number literal "1__0" (syntax)`}},
+		{desc: "failing form", input: "(> amount 1)\n(and (> 1 2) (vec-ref [1] 5))", expected: []string{`2:14: error: index error: index 5 is out of range for length 1 in "vec-ref" (evaluation)`}},
+		{desc: "failing form with symbols", input: "(+ amount (/ amount 0))", expected: []string{`1:11: error: math error: attempt to divide by zero (evaluation)`}},
+		{desc: "unbound symbols", input: "(/ total 0)", expected: []string{}},
+		{desc: "host objects", input: "(/ order.quantity 0)", expected: []string{}},
+		{desc: "lint errors aren't repeated", input: "(abs 1 2)", expected: []string{`1:1: error: "abs" takes exactly 1 argument, not 2 (arity)`}},
+		{desc: "lint warnings", input: "(or 3.1415 true)", expected: []string{`1:5: warning: "or" doesn't accept VAR_FLOAT as argument 1, only VAR_BOOL or VAR_INT (type-mismatch)`}},
+		{desc: "suppressed", input: "(* lint:ignore evaluation *)\n(/ 1 0)", expected: []string{}},
+		{desc: "out of time", input: "(select [(chan)] 1_000)", expected: []string{}},
+		{desc: "over the limits", input: "(range 1000000)", expected: []string{}},
+		{desc: "failing callback", input: "(vec-length (map upper [\"a\" 1]))", expected: []string{`1:13: error: type error: argument of unacceptable type "VAR_INT" passed to "upper" (evaluation)`}},
+		{desc: "failing future", input: "(+ 1 (deref (future (vec-ref [] 0))))", expected: []string{`1:21: error: index error: index 0 is out of range for length 0 in "vec-ref" (evaluation)`}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := NewEvaluationContext(nil)
+			ctx.SymbolTable["amount"] = intVariant(5)
+			ctx.SymbolTable["order"] = Variant{VariantType: VAR_OBJECT, VariantValue: &testLineItem{Quantity: 1}}
+
+			actual := []string{}
+			for _, d := range ctx.Diagnose(test.input) {
+				if d.Rule != RuleUnusedSymbol {
+					actual = append(actual, d.String())
+				}
+			}
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestDiagnoseLargeValues(t *testing.T) {
+	// the limits are checked before the values are built, so these fail at once rather than allocating
+	tests := map[string][]string{
+		`(repeat "ab" 4_611_686_018_427_387_904)`: {`1:1: error: argument error: "repeat" cannot build a value of length 9223372036854775807 (evaluation)`},
+		"(range 300_000_000)":                     {},
+	}
+
+	for input, expected := range tests {
+		start := time.Now()
+		actual := []string{}
+		for _, d := range NewEvaluationContext(nil).Diagnose(input) {
+			actual = append(actual, d.String())
+		}
+		assert.Equal(t, expected, actual, input)
+		assert.Less(t, int64(time.Since(start)), int64(diagnoseTimeout), input)
+	}
+}
+
+func TestDiagnoseHostFunctions(t *testing.T) {
+	ctx := NewEvaluationContext(nil)
+	called := false
+	_ = ctx.RegisterGoFunc("notify", func(s string) int64 {
+		called = true
+		return 1
+	})
+
+	assert.Equal(t, []Diagnostic{}, ctx.Diagnose("(/ (notify \"x\") 0)"))
+	assert.False(t, called, "go functions are never called")
+}
+
+func TestDescribeFunction(t *testing.T) {
+	ctx := NewEvaluationContext(nil)
+	ctx.SymbolTable["abs"] = intVariant(1)
+	_ = ctx.RegisterGoFunc("discount", func(x float64) float64 { return x * 0.9 })
+
+	info, found := ctx.DescribeFunction("clamp")
+	assert.True(t, found)
+	assert.Equal(t, FunctionInfo{Name: "clamp", Namespace: "math", Documentation: "(clamp x low high) limits x to the range from low to high.", Arity: "exactly 3 arguments"}, info)
+
+	info, found = ctx.DescribeFunction("seq/range")
+	assert.True(t, found)
+	assert.Equal(t, FunctionInfo{Name: "seq/range", Namespace: "seq", Documentation: "(range [start] end [step]) is a vector of the ints from start up to, but not including, end.", Arity: "1 to 3 arguments"}, info)
+
+	info, found = ctx.DescribeFunction("discount")
+	assert.True(t, found)
+	assert.Equal(t, FunctionInfo{Name: "discount", Namespace: CapabilityHost}, info)
+
+	_, found = ctx.DescribeFunction("abs")
+	assert.False(t, found, "the symbol hides the function")
+	_, found = ctx.DescribeFunction("missing")
+	assert.False(t, found)
+}
+
+func TestTokens(t *testing.T) {
+	ctx := NewEvaluationContext(nil)
+	ctx.SymbolTable["abs"] = intVariant(1)
+
+	token := func(kind TokenKind, text string, start int, end int) Token {
+		return Token{Kind: kind, Text: text, Start: Position{Offset: start, Line: 1, Column: start + 1}, End: Position{Offset: end, Line: 1, Column: end + 1}}
+	}
+
+	assert.Equal(
+		t,
+		[]Token{
+			token(TokenComment, "(* c *)", 0, 7),
+			token(TokenSpecialForm, "future", 9, 15),
+			token(TokenFunction, "str/upper", 17, 26),
+			token(TokenString, `$"{x}"`, 27, 33),
+			token(TokenSymbol, "abs", 34, 37),
+			token(TokenNumber, "1_000", 39, 44),
+			token(TokenNumber, "2.50", 45, 49),
+			token(TokenDate, "2021-01-01", 50, 60),
+			token(TokenBool, "true", 61, 65),
+			token(TokenKeyword, ":k", 66, 68),
+			token(TokenSymbol, "order.total", 69, 80),
+			token(TokenString, `"unterminated`, 81, 94),
+		},
+		ctx.Tokens(`(* c *) (future (str/upper $"{x}" abs [1_000 2.50 2021-01-01 true :k order.total "unterminated`))
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/johnazariah/golisp"
+)
+
+type fmtOptions struct {
+	write  bool
+	list   bool
+	width  int
+	indent int
+	files  []string
+}
+
+func parseFmtOptions(args []string, stderr io.Writer) (*fmtOptions, error) {
+	options := &fmtOptions{}
+
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.BoolVar(&options.write, "w", false, "write the formatted script back to its file instead of to stdout")
+	flags.BoolVar(&options.list, "l", false, "list the files that aren't formatted instead of printing them")
+	flags.IntVar(&options.width, "width", 80, "fit forms into `columns` where they can")
+	flags.IntVar(&options.indent, "indent", 2, "indent bodies by `spaces`")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: golisp fmt [flags] [files]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Formats the scripts, or stdin if no files are given, and prints them.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+
+	files, e := parseFlags(flags, args)
+	if e != nil {
+		return nil, e
+	}
+
+	switch {
+	case (options.write || options.list) && len(files) == 0:
+		return nil, fmt.Errorf("-w and -l need files to work on")
+	case options.width < 1 || options.indent < 0:
+		return nil, fmt.Errorf("the width must be positive and the indent can't be negative")
+	}
+
+	options.files = files
+	return options, nil
+}
+
+// runFmt formats scripts. It exits with 1 if any of them couldn't be formatted, and with 2 for bad flags.
+func runFmt(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	options, e := parseFmtOptions(args, stderr)
+	if e == flag.ErrHelp {
+		return 0
+	}
+	if e != nil {
+		fmt.Fprintln(stderr, "golisp fmt:", e)
+		return 2
+	}
+
+	formatOptions := []golisp.FormatOption{golisp.WithLineWidth(options.width), golisp.WithIndentWidth(options.indent)}
+
+	if len(options.files) == 0 {
+		source, e := ioutil.ReadAll(stdin)
+		if e == nil {
+			var formatted string
+			if formatted, e = golisp.Format(string(source), formatOptions...); e == nil {
+				fmt.Fprint(stdout, formatted)
+				return 0
+			}
+		}
+		fmt.Fprintln(stderr, "golisp fmt:", e)
+		return 1
+	}
+
+	code := 0
+	for _, path := range options.files {
+		if e := options.formatFile(path, formatOptions, stdout); e != nil {
+			fmt.Fprintf(stderr, "golisp fmt: %s: %v\n", path, e)
+			code = 1
+		}
+	}
+	return code
+}
+
+func (options *fmtOptions) formatFile(path string, formatOptions []golisp.FormatOption, stdout io.Writer) error {
+	source, e := ioutil.ReadFile(path)
+	if e != nil {
+		return e
+	}
+
+	formatted, e := golisp.Format(string(source), formatOptions...)
+	if e != nil {
+		return e
+	}
+
+	changed := formatted != string(source)
+	if options.list && changed {
+		fmt.Fprintln(stdout, path)
+	}
+
+	switch {
+	case options.write && changed:
+		info, e := os.Stat(path)
+		if e != nil {
+			return e
+		}
+		return ioutil.WriteFile(path, []byte(formatted), info.Mode().Perm())
+	case !options.write && !options.list:
+		fmt.Fprint(stdout, formatted)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunFmt(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+	read := func(path string) string {
+		content, e := ioutil.ReadFile(path)
+		assert.Nil(t, e)
+		return string(content)
+	}
+
+	messy := "(* rule *)\n(and  (> amount 100)\n (= country \"NZ\"))"
+	tidy := "(* rule *)\n(and (> amount 100) (= country \"NZ\"))\n"
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	assert.Equal(t, 0, run([]string{"fmt"}, strings.NewReader(messy), stdout, stderr))
+	assert.Equal(t, tidy, stdout.String())
+
+	stdout.Reset()
+	assert.Equal(t, 0, run([]string{"fmt", "--width", "30", "--indent", "4"}, strings.NewReader("(future (and (> amount 100) (= country \"NZ\")))"), stdout, stderr))
+	assert.Equal(t, "(future\n    (and (> amount 100)\n         (= country \"NZ\")))\n", stdout.String())
+
+	rule, formatted := write("rule.lisp", messy), write("formatted.lisp", tidy)
+	stdout.Reset()
+	assert.Equal(t, 0, run([]string{"fmt", "-l", rule, formatted}, nil, stdout, stderr))
+	assert.Equal(t, rule+"\n", stdout.String())
+	assert.Equal(t, messy, read(rule), "-l leaves the files alone")
+
+	stdout.Reset()
+	assert.Equal(t, 0, run([]string{"fmt", "-w", rule}, nil, stdout, stderr))
+	assert.Equal(t, "", stdout.String())
+	assert.Equal(t, tidy, read(rule))
+
+	assert.Equal(t, 0, run([]string{"fmt", "-w", rule}, nil, stdout, stderr))
+	assert.Equal(t, tidy, read(rule), "formatting is idempotent")
+
+	broken := write("broken.lisp", "(+ 1")
+	stderr.Reset()
+	assert.Equal(t, 1, run([]string{"fmt", "-w", broken, rule}, nil, stdout, stderr))
+	assert.Equal(t, "golisp fmt: "+broken+": parse error: unexpected end of string\n", stderr.String())
+	assert.Equal(t, "(+ 1", read(broken))
+
+	stderr.Reset()
+	assert.Equal(t, 2, run([]string{"fmt", "-w"}, strings.NewReader(messy), stdout, stderr))
+	assert.Equal(t, "golisp fmt: -w and -l need files to work on\n", stderr.String())
+}
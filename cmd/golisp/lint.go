@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/johnazariah/golisp"
+)
+
+// lintOptions are the flags of the lint command.
+type lintOptions struct {
+	bindings runOptions
+	strict   bool
+	files    []string
+}
+
+func parseLintOptions(args []string, stderr io.Writer) (*lintOptions, error) {
+	options := &lintOptions{}
+
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Var(&options.bindings.vars, "var", "bind a symbol the scripts can use, as `name=value` (repeatable)")
+	flags.StringVar(&options.bindings.varsFile, "vars", "", "bind the keys of the JSON object in the `file` as symbols the scripts can use")
+	flags.BoolVar(&options.strict, "strict", false, "fail on warnings as well as errors")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: golisp lint [flags] [files]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Checks the scripts, or stdin if no files are given, for likely mistakes.")
+		fmt.Fprintln(stderr, "A comment (* lint:ignore rule ... *) suppresses the rules in the next form, or on its line if it")
+		fmt.Fprintln(stderr, "follows a form; (* lint:ignore-file rule ... *) suppresses them in the whole script.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+
+	files, e := parseFlags(flags, args)
+	if e != nil {
+		return nil, e
+	}
+
+	options.files = files
+	if len(files) == 0 {
+		options.files = []string{stdinPath}
+	}
+	return options, nil
+}
+
+// runLint checks scripts and prints their diagnostics. It exits with 1 if any script has errors, or warnings with
+// --strict, or can't be parsed, and with 2 for bad flags.
+func runLint(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	options, e := parseLintOptions(args, stderr)
+	if e == flag.ErrHelp {
+		return 0
+	}
+	if e != nil {
+		fmt.Fprintln(stderr, "golisp lint:", e)
+		return 2
+	}
+
+	code := 0
+	for _, path := range options.files {
+		failed, e := options.lintFile(path, stdin, stdout)
+		if e != nil {
+			fmt.Fprintf(stderr, "golisp lint: %s: %v\n", path, e)
+			return 2
+		}
+		if failed {
+			code = 1
+		}
+	}
+	return code
+}
+
+// lintFile prints the diagnostics of the script and reports whether any of them fail the run. A script that can't be
+// parsed is reported as an error diagnostic, since that is what the linter is there to find.
+func (options *lintOptions) lintFile(path string, stdin io.Reader, stdout io.Writer) (bool, error) {
+	ctx := golisp.NewEvaluationContext(nil)
+	if e := options.bindings.bind(ctx.SymbolTable, stdin); e != nil {
+		return false, e
+	}
+
+	source, e := readSource(path, stdin)
+	if e != nil {
+		return false, e
+	}
+
+	diagnostics, e := ctx.LintSource(source)
+	if e != nil {
+		fmt.Fprintf(stdout, "%s: %v\n", path, e)
+		return true, nil
+	}
+
+	failed := false
+	for _, d := range diagnostics {
+		if d.Position.IsValid() {
+			fmt.Fprintf(stdout, "%s:%s\n", path, d)
+		} else {
+			fmt.Fprintf(stdout, "%s: %s\n", path, d)
+		}
+		switch d.Severity {
+		case golisp.SeverityError:
+			failed = true
+		case golisp.SeverityWarning:
+			failed = failed || options.strict
+		}
+	}
+	return failed, nil
+}
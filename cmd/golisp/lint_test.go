@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunLint(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	clean := write("clean.lisp", "(and (> amount 100) (= country \"NZ\"))\n")
+	warned := write("warned.lisp", "(or 3.1415 vip)\n")
+	failed := write("failed.lisp", "(* lint:ignore arity *)\n(abs 1 2)\n(upper)\n")
+	broken := write("broken.lisp", "(+ 1\n")
+
+	tests := [...]struct {
+		desc     string
+		args     []string
+		stdin    string
+		code     int
+		expected string
+	}{
+		{desc: "clean script", args: []string{clean}, code: 0, expected: ""},
+		{desc: "warnings", args: []string{clean, warned}, code: 0, expected: warned + `:1:5: warning: "or" doesn't accept VAR_FLOAT as argument 1, only VAR_BOOL or VAR_INT (type-mismatch)` + "\n"},
+		{desc: "strict", args: []string{"--strict", warned}, code: 1, expected: warned + `:1:5: warning: "or" doesn't accept VAR_FLOAT as argument 1, only VAR_BOOL or VAR_INT (type-mismatch)` + "\n"},
+		{desc: "errors", args: []string{failed}, code: 1, expected: failed + `:3:1: error: "upper" takes exactly 1 argument, not 0 (arity)` + "\n"},
+		{desc: "parse errors", args: []string{broken, clean}, code: 1, expected: broken + ": parse error: unexpected end of string\n"},
+		{desc: "bound symbols", args: []string{"--var", "abs=1", "--var", "vip=true", clean}, code: 0, expected: clean + `: info: symbol "abs" is bound but never used (unused-symbol)` + "\n" + clean + `: info: symbol "vip" is bound but never used (unused-symbol)` + "\n"},
+		{desc: "stdin", args: []string{"--var", "abs=1"}, stdin: "(map abs [1])", code: 0, expected: `-:1:6: warning: "abs" refers to the symbol of that name, which hides the function (shadowed-builtin)` + "\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			assert.Equal(t, test.code, run(append([]string{"lint"}, test.args...), strings.NewReader(test.stdin), stdout, stderr))
+			assert.Equal(t, test.expected, stdout.String())
+			assert.Empty(t, stderr.String())
+		})
+	}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	assert.Equal(t, 2, run([]string{"lint", filepath.Join(dir, "missing.lisp")}, nil, stdout, stderr))
+	assert.Contains(t, stderr.String(), "golisp lint: "+filepath.Join(dir, "missing.lisp"))
+}
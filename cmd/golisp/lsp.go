@@ -0,0 +1,583 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/johnazariah/golisp"
+)
+
+// lspOptions are the flags of the lsp command.
+type lspOptions struct {
+	bindings runOptions
+	width    int
+}
+
+func parseLspOptions(args []string, stderr io.Writer) (*lspOptions, error) {
+	options := &lspOptions{}
+
+	flags := flag.NewFlagSet("lsp", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Var(&options.bindings.vars, "var", "bind a symbol the scripts can use, as `name=value` (repeatable)")
+	flags.StringVar(&options.bindings.varsFile, "vars", "", "bind the keys of the JSON object in the `file` as symbols, which go-to-definition jumps to")
+	flags.IntVar(&options.width, "width", 80, "fit forms into `columns` when formatting")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: golisp lsp [flags]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Serves the Language Server Protocol over stdin and stdout, for editors.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+
+	args, e := parseFlags(flags, args)
+	if e != nil {
+		return nil, e
+	}
+
+	switch {
+	case len(args) > 0:
+		return nil, fmt.Errorf("unexpected arguments %q", args)
+	case options.bindings.varsFile == stdinPath:
+		return nil, fmt.Errorf("the vars can't be read from stdin, which the client writes to")
+	case options.width < 1:
+		return nil, fmt.Errorf("the width must be positive")
+	}
+	return options, nil
+}
+
+// runLsp serves the Language Server Protocol until the client asks it to exit. It exits with 1 if the client exits
+// without shutting it down first, or the connection fails, and with 2 for bad flags.
+func runLsp(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	options, e := parseLspOptions(args, stderr)
+	if e == flag.ErrHelp {
+		return 0
+	}
+	if e != nil {
+		fmt.Fprintln(stderr, "golisp lsp:", e)
+		return 2
+	}
+
+	s, e := newLspServer(options, stdout, stderr)
+	if e != nil {
+		fmt.Fprintln(stderr, "golisp lsp:", e)
+		return 2
+	}
+	return s.serve(stdin)
+}
+
+// lspServer answers the requests of one client, one at a time, about the documents the client has open.
+type lspServer struct {
+	ctx         *golisp.EvaluationContext
+	definitions map[string]lspLocation
+	functions   map[string]golisp.FunctionInfo
+	width       int
+
+	out         io.Writer
+	log         io.Writer
+	documents   map[string]*document
+	initialized bool
+	shutdown    bool
+}
+
+func newLspServer(options *lspOptions, out io.Writer, log io.Writer) (*lspServer, error) {
+	s := &lspServer{
+		ctx:         golisp.NewEvaluationContext(nil),
+		definitions: map[string]lspLocation{},
+		functions:   map[string]golisp.FunctionInfo{},
+		width:       options.width,
+		out:         out,
+		log:         log,
+		documents:   map[string]*document{},
+	}
+
+	e := options.bindings.bind(s.ctx.SymbolTable, nil)
+	if e != nil {
+		return nil, e
+	}
+
+	if options.bindings.varsFile != "" {
+		if s.definitions, e = bindingLocations(options.bindings.varsFile); e != nil {
+			return nil, e
+		}
+	}
+	for _, binding := range options.bindings.vars {
+		// a symbol bound again by --var is defined on the command line rather than in the file
+		delete(s.definitions, strings.SplitN(binding, "=", 2)[0])
+	}
+	return s, nil
+}
+
+// lspHandler handles a request or notification, returning the result of a request.
+type lspHandler func(s *lspServer, params json.RawMessage) (interface{}, error)
+
+// lspHandlers handle the requests and notifications that the server understands, by method.
+var lspHandlers = map[string]lspHandler{
+	"initialize":                       (*lspServer).initialize,
+	"shutdown":                         (*lspServer).shutdownServer,
+	"textDocument/didOpen":             (*lspServer).didOpen,
+	"textDocument/didChange":           (*lspServer).didChange,
+	"textDocument/didClose":            (*lspServer).didClose,
+	"textDocument/hover":               (*lspServer).hover,
+	"textDocument/completion":          (*lspServer).completion,
+	"textDocument/definition":          (*lspServer).definition,
+	"textDocument/formatting":          (*lspServer).formatting,
+	"textDocument/semanticTokens/full": (*lspServer).semanticTokensFull,
+}
+
+// serve reads messages until the client asks the server to exit or the connection closes, returning the exit code.
+func (s *lspServer) serve(in io.Reader) int {
+	reader := bufio.NewReader(in)
+	for {
+		body, e := readMessage(reader)
+		if e == io.EOF {
+			return s.exitCode()
+		}
+		if e != nil {
+			fmt.Fprintln(s.log, "golisp lsp:", e)
+			return 1
+		}
+
+		var request rpcRequest
+		if e := json.Unmarshal(body, &request); e != nil {
+			s.respond(json.RawMessage("null"), nil, &rpcError{Code: rpcParseError, Message: e.Error()})
+			continue
+		}
+
+		if request.Method == "exit" {
+			return s.exitCode()
+		}
+		s.handle(&request)
+	}
+}
+
+func (s *lspServer) exitCode() int {
+	if s.shutdown {
+		return 0
+	}
+	return 1
+}
+
+func (s *lspServer) handle(request *rpcRequest) {
+	handler, found := lspHandlers[request.Method]
+
+	var result interface{}
+	var e error
+	switch {
+	case !found && request.isNotification():
+		// notifications that aren't understood, such as those starting $/, may be ignored
+		return
+	case !s.initialized && request.Method != "initialize":
+		e = &rpcError{Code: rpcServerNotInitialized, Message: "the server hasn't been initialized"}
+	case s.shutdown:
+		e = &rpcError{Code: rpcInvalidRequest, Message: "the server has been shut down"}
+	case !found:
+		e = &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("method %q isn't supported", request.Method)}
+	default:
+		result, e = s.call(handler, request.Params)
+	}
+
+	if request.isNotification() {
+		if e != nil {
+			fmt.Fprintf(s.log, "golisp lsp: %s: %v\n", request.Method, e)
+		}
+		return
+	}
+	s.respond(request.ID, result, e)
+}
+
+// call calls the handler, reporting a panic as an internal error so that one bad request doesn't end the session.
+func (s *lspServer) call(handler lspHandler, params json.RawMessage) (result interface{}, e error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, e = nil, &rpcError{Code: rpcInternalError, Message: fmt.Sprintf("internal error: %v", r)}
+		}
+	}()
+	return handler(s, params)
+}
+
+func (s *lspServer) respond(id json.RawMessage, result interface{}, e error) {
+	response := rpcResponse{JSONRPC: "2.0", ID: id}
+	if e != nil {
+		if rpcE, ok := e.(*rpcError); ok {
+			response.Error = rpcE
+		} else {
+			response.Error = &rpcError{Code: rpcInvalidParams, Message: e.Error()}
+		}
+	} else if response.Result, e = json.Marshal(result); e != nil {
+		response.Result, response.Error = nil, &rpcError{Code: rpcInvalidRequest, Message: e.Error()}
+	}
+	s.send(response)
+}
+
+func (s *lspServer) notify(method string, params interface{}) {
+	s.send(rpcNotification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (s *lspServer) send(message interface{}) {
+	if e := writeMessage(s.out, message); e != nil {
+		fmt.Fprintln(s.log, "golisp lsp:", e)
+	}
+}
+
+func (s *lspServer) initialize(params json.RawMessage) (interface{}, error) {
+	s.initialized = true
+	return map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"textDocumentSync":           1, // the whole document is sent on every change
+			"hoverProvider":              true,
+			"completionProvider":         map[string]interface{}{"triggerCharacters": []string{"(", "/"}},
+			"definitionProvider":         true,
+			"documentFormattingProvider": true,
+			"semanticTokensProvider": map[string]interface{}{
+				"legend": map[string]interface{}{"tokenTypes": semanticTokenTypes, "tokenModifiers": []string{}},
+				"full":   true,
+			},
+		},
+		"serverInfo": map[string]string{"name": "golisp"},
+	}, nil
+}
+
+func (s *lspServer) shutdownServer(params json.RawMessage) (interface{}, error) {
+	s.shutdown = true
+	return nil, nil
+}
+
+func (s *lspServer) didOpen(params json.RawMessage) (interface{}, error) {
+	var p didOpenParams
+	if e := json.Unmarshal(params, &p); e != nil {
+		return nil, e
+	}
+
+	s.documents[p.TextDocument.URI] = newDocument(p.TextDocument.Text)
+	s.publishDiagnostics(p.TextDocument.URI)
+	return nil, nil
+}
+
+func (s *lspServer) didChange(params json.RawMessage) (interface{}, error) {
+	var p didChangeParams
+	if e := json.Unmarshal(params, &p); e != nil {
+		return nil, e
+	}
+	if len(p.ContentChanges) == 0 {
+		return nil, nil
+	}
+
+	s.documents[p.TextDocument.URI] = newDocument(p.ContentChanges[len(p.ContentChanges)-1].Text)
+	s.publishDiagnostics(p.TextDocument.URI)
+	return nil, nil
+}
+
+func (s *lspServer) didClose(params json.RawMessage) (interface{}, error) {
+	var p didCloseParams
+	if e := json.Unmarshal(params, &p); e != nil {
+		return nil, e
+	}
+
+	delete(s.documents, p.TextDocument.URI)
+	s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: p.TextDocument.URI, Diagnostics: []lspDiagnostic{}})
+	return nil, nil
+}
+
+// publishDiagnostics sends the problems found in the document. Diagnostics without a position, such as symbols bound
+// but not used, are about the bindings rather than the document, so they aren't sent.
+func (s *lspServer) publishDiagnostics(uri string) {
+	doc := s.documents[uri]
+	diagnostics := []lspDiagnostic{}
+	for _, d := range s.ctx.Diagnose(doc.text) {
+		if !d.Position.IsValid() {
+			continue
+		}
+		diagnostics = append(diagnostics, lspDiagnostic{
+			Range:    doc.span(d.Position, d.End),
+			Severity: lspSeverities[d.Severity],
+			Code:     d.Rule,
+			Source:   "golisp",
+			Message:  d.Message,
+		})
+	}
+	s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: uri, Diagnostics: diagnostics})
+}
+
+// document returns the open document that the request is about.
+func (s *lspServer) document(uri string) (*document, error) {
+	doc, found := s.documents[uri]
+	if !found {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf("%s isn't open", uri)}
+	}
+	return doc, nil
+}
+
+// tokenAt returns the token under the cursor, including one that the cursor is just after.
+func (s *lspServer) tokenAt(params json.RawMessage) (*document, *golisp.Token, error) {
+	var p textDocumentPositionParams
+	if e := json.Unmarshal(params, &p); e != nil {
+		return nil, nil, e
+	}
+
+	doc, e := s.document(p.TextDocument.URI)
+	if e != nil {
+		return nil, nil, e
+	}
+
+	offset := doc.offset(p.Position)
+	var found *golisp.Token
+	for _, t := range s.ctx.Tokens(doc.text) {
+		t := t
+		if t.Start.Offset <= offset && offset < t.End.Offset {
+			return doc, &t, nil
+		}
+		if t.End.Offset == offset {
+			found = &t
+		}
+	}
+	return doc, found, nil
+}
+
+// describe describes a function, caching the description since learning the arity calls the function.
+func (s *lspServer) describe(name string) (golisp.FunctionInfo, bool) {
+	if info, found := s.functions[name]; found {
+		return info, true
+	}
+
+	info, found := s.ctx.DescribeFunction(name)
+	if found {
+		s.functions[name] = info
+	}
+	return info, found
+}
+
+func (s *lspServer) hover(params json.RawMessage) (interface{}, error) {
+	doc, t, e := s.tokenAt(params)
+	if e != nil || t == nil {
+		return nil, e
+	}
+
+	var text string
+	switch t.Kind {
+	case golisp.TokenFunction, golisp.TokenSpecialForm:
+		info, found := s.describe(t.Text)
+		if !found {
+			return nil, nil
+		}
+		text = describeFunction(info)
+
+	case golisp.TokenSymbol:
+		name := bindingName(t.Text)
+		v, found := s.ctx.SymbolTable[name]
+		if !found {
+			return nil, nil
+		}
+		text = fmt.Sprintf("`%s` is bound to `%s`", name, v.ToDebugString())
+
+	default:
+		return nil, nil
+	}
+
+	return hover{Contents: markupContent{Kind: "markdown", Value: text}, Range: doc.span(t.Start, t.End)}, nil
+}
+
+// describeFunction writes the description of a function in markdown.
+func describeFunction(info golisp.FunctionInfo) string {
+	var b strings.Builder
+	if info.Namespace == golisp.CapabilityHost {
+		fmt.Fprintf(&b, "`%s` is a go function bound by the host", info.Name)
+	} else {
+		fmt.Fprintf(&b, "`%s` from the %s library", info.Name, info.Namespace)
+	}
+	if info.Arity != "" {
+		fmt.Fprintf(&b, ", taking %s", info.Arity)
+	}
+	b.WriteString(".")
+
+	if info.Documentation != "" {
+		b.WriteString("\n\n")
+		b.WriteString(info.Documentation)
+	}
+	return b.String()
+}
+
+// bindingName is the symbol that a name refers to, which for a property path such as "order.total" is its first
+// segment.
+func bindingName(name string) string {
+	if i := strings.Index(name, "."); i > 0 {
+		return name[:i]
+	}
+	return name
+}
+
+func (s *lspServer) completion(params json.RawMessage) (interface{}, error) {
+	var p textDocumentPositionParams
+	if e := json.Unmarshal(params, &p); e != nil {
+		return nil, e
+	}
+
+	doc, e := s.document(p.TextDocument.URI)
+	if e != nil {
+		return nil, e
+	}
+
+	offset := doc.offset(p.Position)
+	line := doc.text[doc.lines[doc.position(offset).Line]:offset]
+	word := line[strings.LastIndexAny(line, wordDelimiters)+1:]
+
+	items := []completionItem{}
+	for name := range s.ctx.SymbolTable {
+		if strings.HasPrefix(name, word) {
+			items = append(items, completionItem{Label: name, Kind: completionVariable, Detail: "symbol"})
+		}
+	}
+	for name := range s.ctx.FunctionTable {
+		if _, shadowed := s.ctx.SymbolTable[name]; shadowed || !strings.HasPrefix(name, word) {
+			continue
+		}
+
+		item := completionItem{Label: name, Kind: completionFunction}
+		if info, found := s.describe(name); found {
+			item.Detail = info.Namespace
+			if info.Documentation != "" {
+				item.Documentation = &markupContent{Kind: "markdown", Value: info.Documentation}
+			}
+		}
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+	return completionList{Items: items}, nil
+}
+
+func (s *lspServer) definition(params json.RawMessage) (interface{}, error) {
+	_, t, e := s.tokenAt(params)
+	if e != nil || t == nil || t.Kind != golisp.TokenSymbol {
+		return nil, e
+	}
+
+	location, found := s.definitions[bindingName(t.Text)]
+	if !found {
+		return nil, nil
+	}
+	return location, nil
+}
+
+// formatting formats the whole document with the indent of the editor. A document that can't be parsed is left as it
+// is, since its diagnostics already say why.
+func (s *lspServer) formatting(params json.RawMessage) (interface{}, error) {
+	var p formattingParams
+	if e := json.Unmarshal(params, &p); e != nil {
+		return nil, e
+	}
+
+	doc, e := s.document(p.TextDocument.URI)
+	if e != nil {
+		return nil, e
+	}
+
+	options := []golisp.FormatOption{golisp.WithLineWidth(s.width)}
+	if p.Options.TabSize > 0 {
+		options = append(options, golisp.WithIndentWidth(p.Options.TabSize))
+	}
+
+	formatted, e := golisp.Format(doc.text, options...)
+	if e != nil || formatted == doc.text {
+		return []textEdit{}, nil
+	}
+	return []textEdit{{Range: lspRange{End: doc.position(len(doc.text))}, NewText: formatted}}, nil
+}
+
+// semanticTokensFull encodes the tokens of the document as the LSP asks: five numbers for each, being the line relative
+// to the previous token, the character relative to it if they are on the same line, the length and the type. Tokens
+// that span lines, such as multi-line strings, are split into one for each line.
+func (s *lspServer) semanticTokensFull(params json.RawMessage) (interface{}, error) {
+	var p semanticTokensParams
+	if e := json.Unmarshal(params, &p); e != nil {
+		return nil, e
+	}
+
+	doc, e := s.document(p.TextDocument.URI)
+	if e != nil {
+		return nil, e
+	}
+
+	data := []int{}
+	previous := lspPosition{}
+	for _, t := range s.ctx.Tokens(doc.text) {
+		for start := t.Start.Offset; start < t.End.Offset; {
+			end := doc.lineEnd(start)
+			if end > t.End.Offset {
+				end = t.End.Offset
+			}
+
+			if end > start {
+				at := doc.position(start)
+				character := at.Character
+				if at.Line == previous.Line {
+					character -= previous.Character
+				}
+				data = append(data, at.Line-previous.Line, character, utf16Length(doc.text[start:end]), tokenTypes[t.Kind], 0)
+				previous = at
+			}
+			start = end + 1
+		}
+	}
+	return semanticTokens{Data: data}, nil
+}
+
+// bindingLocations finds the keys of the JSON object in the file, so that go-to-definition can jump to where a symbol
+// is bound.
+func bindingLocations(path string) (map[string]lspLocation, error) {
+	text, e := ioutil.ReadFile(path)
+	if e != nil {
+		return nil, e
+	}
+
+	absolute, e := filepath.Abs(path)
+	if e != nil {
+		return nil, e
+	}
+	uri := (&url.URL{Scheme: "file", Path: filepath.ToSlash(absolute)}).String()
+
+	doc := newDocument(string(text))
+	locations := map[string]lspLocation{}
+	decoder := json.NewDecoder(bytes.NewReader(text))
+	depth, expectKey := 0, false
+
+	for {
+		before := int(decoder.InputOffset())
+		t, e := decoder.Token()
+		if e == io.EOF {
+			return locations, nil
+		}
+		if e != nil {
+			return nil, fmt.Errorf("%s: %v", path, e)
+		}
+
+		switch t {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+			expectKey = depth == 1
+			continue
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+			expectKey = depth == 1
+			continue
+		}
+
+		if depth != 1 {
+			continue
+		}
+		if key, ok := t.(string); ok && expectKey {
+			start := before + bytes.IndexByte(text[before:], '"')
+			end := int(decoder.InputOffset())
+			locations[key] = lspLocation{URI: uri, Range: lspRange{Start: doc.position(start), End: doc.position(end)}}
+		}
+		expectKey = !expectKey
+	}
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/johnazariah/golisp"
+)
+
+// The error codes of JSON-RPC and the LSP that the server uses.
+const (
+	rpcParseError           = -32700
+	rpcInvalidRequest       = -32600
+	rpcMethodNotFound       = -32601
+	rpcInvalidParams        = -32602
+	rpcInternalError        = -32603
+	rpcServerNotInitialized = -32002
+)
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// rpcRequest is a request or, without an ID, a notification from the client.
+type rpcRequest struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+func (r *rpcRequest) isNotification() bool {
+	return len(r.ID) == 0
+}
+
+// rpcResponse answers a request. The result is written as null when there is no error, as JSON-RPC requires.
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+// readMessage reads the body of the next message, which is framed by headers giving its length.
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	headers, e := textproto.NewReader(r).ReadMIMEHeader()
+	if e != nil {
+		if e == io.EOF && len(headers) == 0 {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("reading headers: %v", e)
+	}
+
+	length, e := strconv.Atoi(headers.Get("Content-Length"))
+	if e != nil || length < 0 {
+		return nil, fmt.Errorf("bad Content-Length %q", headers.Get("Content-Length"))
+	}
+
+	body := make([]byte, length)
+	if _, e := io.ReadFull(r, body); e != nil {
+		return nil, fmt.Errorf("reading a body of %d bytes: %v", length, e)
+	}
+	return body, nil
+}
+
+func writeMessage(w io.Writer, message interface{}) error {
+	body, e := json.Marshal(message)
+	if e != nil {
+		return e
+	}
+	_, e = fmt.Fprintf(w, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return e
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspLocation struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+type textDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type textDocumentPositionParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Position     lspPosition            `json:"position"`
+}
+
+type didOpenParams struct {
+	TextDocument struct {
+		URI  string `json:"uri"`
+		Text string `json:"text"`
+	} `json:"textDocument"`
+}
+
+// didChangeParams carries the whole text of the document, since the server asks for full synchronisation.
+type didChangeParams struct {
+	TextDocument   textDocumentIdentifier `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+type didCloseParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+type formattingParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Options      struct {
+		TabSize int `json:"tabSize"`
+	} `json:"options"`
+}
+
+type semanticTokensParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Code     string   `json:"code"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+type publishDiagnosticsParams struct {
+	URI         string          `json:"uri"`
+	Diagnostics []lspDiagnostic `json:"diagnostics"`
+}
+
+type markupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type hover struct {
+	Contents markupContent `json:"contents"`
+	Range    lspRange      `json:"range"`
+}
+
+// The kinds of completion items that the server offers.
+const (
+	completionFunction = 3
+	completionVariable = 6
+)
+
+type completionItem struct {
+	Label         string         `json:"label"`
+	Kind          int            `json:"kind"`
+	Detail        string         `json:"detail,omitempty"`
+	Documentation *markupContent `json:"documentation,omitempty"`
+}
+
+type completionList struct {
+	IsIncomplete bool             `json:"isIncomplete"`
+	Items        []completionItem `json:"items"`
+}
+
+type textEdit struct {
+	Range   lspRange `json:"range"`
+	NewText string   `json:"newText"`
+}
+
+type semanticTokens struct {
+	Data []int `json:"data"`
+}
+
+// semanticTokenTypes is the legend of the semantic tokens, and tokenTypes maps the kinds of golisp tokens into it.
+var semanticTokenTypes = []string{"comment", "string", "number", "keyword", "enumMember", "function", "macro", "variable"}
+
+var tokenTypes = map[golisp.TokenKind]int{
+	golisp.TokenComment:     0,
+	golisp.TokenString:      1,
+	golisp.TokenNumber:      2,
+	golisp.TokenDate:        2,
+	golisp.TokenBool:        3,
+	golisp.TokenKeyword:     4,
+	golisp.TokenFunction:    5,
+	golisp.TokenSpecialForm: 6,
+	golisp.TokenSymbol:      7,
+}
+
+// lspSeverities maps the severities of golisp diagnostics to the LSP's.
+var lspSeverities = map[golisp.Severity]int{
+	golisp.SeverityError:   1,
+	golisp.SeverityWarning: 2,
+	golisp.SeverityInfo:    3,
+}
+
+// document is the text of a file open in the editor. The LSP places characters by line and by UTF-16 code unit along
+// the line, whereas golisp counts bytes, so positions are converted through the text.
+type document struct {
+	text  string
+	lines []int
+}
+
+func newDocument(text string) *document {
+	lines := []int{0}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+	return &document{text: text, lines: lines}
+}
+
+// position converts a byte offset into the text to an LSP position.
+func (d *document) position(offset int) lspPosition {
+	if offset > len(d.text) {
+		offset = len(d.text)
+	}
+	line := sort.Search(len(d.lines), func(i int) bool { return d.lines[i] > offset }) - 1
+	return lspPosition{Line: line, Character: utf16Length(d.text[d.lines[line]:offset])}
+}
+
+// offset converts an LSP position to a byte offset into the text, clamping it to the line and the text.
+func (d *document) offset(p lspPosition) int {
+	if p.Line < 0 {
+		return 0
+	}
+	if p.Line >= len(d.lines) {
+		return len(d.text)
+	}
+
+	offset, units := d.lines[p.Line], 0
+	for offset < len(d.text) && d.text[offset] != '\n' && units < p.Character {
+		r, width := utf8.DecodeRuneInString(d.text[offset:])
+		offset, units = offset+width, units+utf16Units(r)
+	}
+	return offset
+}
+
+func (d *document) span(start golisp.Position, end golisp.Position) lspRange {
+	return lspRange{Start: d.position(start.Offset), End: d.position(end.Offset)}
+}
+
+// lineEnd returns the offset of the end of the line that the offset is on, before its line break.
+func (d *document) lineEnd(offset int) int {
+	if i := strings.IndexByte(d.text[offset:], '\n'); i >= 0 {
+		return offset + i
+	}
+	return len(d.text)
+}
+
+func utf16Length(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16Units(r)
+	}
+	return n
+}
+
+func utf16Units(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
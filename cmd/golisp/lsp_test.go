@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// lspClient scripts the messages of a client, and reads the messages that the server sends back.
+type lspClient struct {
+	t        *testing.T
+	messages bytes.Buffer
+	id       int
+}
+
+func (c *lspClient) request(method string, params interface{}) int {
+	c.id++
+	assert.Nil(c.t, writeMessage(&c.messages, map[string]interface{}{"jsonrpc": "2.0", "id": c.id, "method": method, "params": params}))
+	return c.id
+}
+
+func (c *lspClient) notify(method string, params interface{}) {
+	assert.Nil(c.t, writeMessage(&c.messages, map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params}))
+}
+
+type lspReply struct {
+	ID     int             `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+// run runs the server on the scripted messages, returning its exit code and the replies to the requests by ID along
+// with the notifications it sent, in order.
+func (c *lspClient) run(args ...string) (int, map[int]lspReply, []lspReply) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	code := run(append([]string{"lsp"}, args...), &c.messages, stdout, stderr)
+	assert.Empty(c.t, stderr.String())
+
+	replies, notifications := map[int]lspReply{}, []lspReply{}
+	reader := bufio.NewReader(stdout)
+	for {
+		body, e := readMessage(reader)
+		if e == io.EOF {
+			return code, replies, notifications
+		}
+		assert.Nil(c.t, e)
+
+		var reply lspReply
+		assert.Nil(c.t, json.Unmarshal(body, &reply))
+		if reply.Method != "" {
+			notifications = append(notifications, reply)
+		} else {
+			replies[reply.ID] = reply
+		}
+	}
+}
+
+func at(uri string, line int, character int) map[string]interface{} {
+	return map[string]interface{}{"textDocument": map[string]string{"uri": uri}, "position": map[string]int{"line": line, "character": character}}
+}
+
+func TestRunLsp(t *testing.T) {
+	dir := t.TempDir()
+	vars := filepath.Join(dir, "vars.json")
+	assert.Nil(t, ioutil.WriteFile(vars, []byte("{\n  \"vip\": true,\n  \"amount\": 12\n}\n"), 0o600))
+
+	const uri = "file:///rule.lisp"
+	document := map[string]string{"uri": uri}
+
+	c := &lspClient{t: t}
+	early := c.request("textDocument/hover", at(uri, 0, 0))
+	initialize := c.request("initialize", map[string]interface{}{})
+	c.notify("initialized", map[string]interface{}{})
+	c.notify("textDocument/didOpen", map[string]interface{}{"textDocument": map[string]string{"uri": uri, "text": "(* discount *)\n(and vip (> amount 10)\n  (upper))\n(upper \"é\")"}})
+	hoverFunction := c.request("textDocument/hover", at(uri, 1, 2))
+	hoverSymbol := c.request("textDocument/hover", at(uri, 1, 6))
+	hoverNothing := c.request("textDocument/hover", at(uri, 1, 20))
+	completion := c.request("textDocument/completion", at(uri, 2, 5))
+	definition := c.request("textDocument/definition", at(uri, 1, 13))
+	formatting := c.request("textDocument/formatting", map[string]interface{}{"textDocument": document, "options": map[string]int{"tabSize": 2}})
+	tokens := c.request("textDocument/semanticTokens/full", map[string]interface{}{"textDocument": document})
+	c.notify("textDocument/didChange", map[string]interface{}{"textDocument": document, "contentChanges": []map[string]string{{"text": "(and vip\n  (> amount 1)"}}})
+	unknown := c.request("textDocument/rename", at(uri, 0, 0))
+	closed := c.request("textDocument/hover", at("file:///other.lisp", 0, 0))
+	c.notify("textDocument/didClose", map[string]interface{}{"textDocument": document})
+	shutdown := c.request("shutdown", nil)
+	late := c.request("textDocument/hover", at(uri, 0, 0))
+	c.notify("exit", nil)
+
+	code, replies, notifications := c.run("--vars", vars)
+	assert.Equal(t, 0, code)
+
+	assert.Equal(t, rpcServerNotInitialized, replies[early].Error.Code)
+	assert.Contains(t, string(replies[initialize].Result), `"hoverProvider":true`)
+	assert.Contains(t, string(replies[initialize].Result), `"tokenTypes":["comment","string","number","keyword","enumMember","function","macro","variable"]`)
+
+	assert.JSONEq(t, `{"contents": {"kind": "markdown", "value": "`+"`and`"+` from the logic library, taking at least 2 arguments.\n\n(and a b ...) is true if all the arguments are true."}, "range": {"start": {"line": 1, "character": 1}, "end": {"line": 1, "character": 4}}}`, string(replies[hoverFunction].Result))
+	assert.JSONEq(t, `{"contents": {"kind": "markdown", "value": "`+"`vip` is bound to `true`"+`"}, "range": {"start": {"line": 1, "character": 5}, "end": {"line": 1, "character": 8}}}`, string(replies[hoverSymbol].Result))
+	assert.Equal(t, "null", string(replies[hoverNothing].Result))
+
+	var list completionList
+	assert.Nil(t, json.Unmarshal(replies[completion].Result, &list))
+	labels := []string{}
+	for _, item := range list.Items {
+		labels = append(labels, item.Label)
+	}
+	assert.Equal(t, []string{"update", "upper"}, labels)
+	assert.Equal(t, "str", list.Items[1].Detail)
+	assert.Equal(t, "(upper s) upper-cases the string.", list.Items[1].Documentation.Value)
+
+	var location lspLocation
+	assert.Nil(t, json.Unmarshal(replies[definition].Result, &location))
+	assert.Equal(t, lspLocation{URI: "file://" + filepath.ToSlash(vars), Range: lspRange{Start: lspPosition{Line: 2, Character: 2}, End: lspPosition{Line: 2, Character: 10}}}, location)
+
+	var edits []textEdit
+	assert.Nil(t, json.Unmarshal(replies[formatting].Result, &edits))
+	assert.Equal(t, []textEdit{{Range: lspRange{End: lspPosition{Line: 3, Character: 11}}, NewText: "(* discount *)\n(and vip (> amount 10) (upper))\n(upper \"é\")\n"}}, edits)
+
+	// the string "é" is a byte longer in UTF-8 than in UTF-16
+	assert.JSONEq(t, `{"data": [0,0,14,0,0, 1,1,3,5,0, 0,4,3,7,0, 0,5,1,5,0, 0,2,6,7,0, 0,7,2,2,0, 1,3,5,5,0, 1,1,5,5,0, 0,6,3,1,0]}`, string(replies[tokens].Result))
+
+	assert.Equal(t, rpcMethodNotFound, replies[unknown].Error.Code)
+	assert.Equal(t, rpcInvalidParams, replies[closed].Error.Code)
+	assert.Equal(t, "null", string(replies[shutdown].Result))
+	assert.Equal(t, rpcInvalidRequest, replies[late].Error.Code)
+
+	diagnostics := []publishDiagnosticsParams{}
+	for _, n := range notifications {
+		assert.Equal(t, "textDocument/publishDiagnostics", n.Method)
+		var p publishDiagnosticsParams
+		assert.Nil(t, json.Unmarshal(n.Params, &p))
+		diagnostics = append(diagnostics, p)
+	}
+	assert.Equal(t, []publishDiagnosticsParams{
+		{URI: uri, Diagnostics: []lspDiagnostic{{Range: lspRange{Start: lspPosition{Line: 2, Character: 2}, End: lspPosition{Line: 2, Character: 9}}, Severity: 1, Code: "arity", Source: "golisp", Message: `"upper" takes exactly 1 argument, not 0`}}},
+		{URI: uri, Diagnostics: []lspDiagnostic{{Range: lspRange{Start: lspPosition{Line: 0, Character: 0}, End: lspPosition{Line: 0, Character: 1}}, Severity: 1, Code: "syntax", Source: "golisp", Message: "parse error: unexpected end of string"}}},
+		{URI: uri, Diagnostics: []lspDiagnostic{}},
+	}, diagnostics)
+}
+
+func TestRunLspExit(t *testing.T) {
+	c := &lspClient{t: t}
+	c.request("initialize", map[string]interface{}{})
+	c.notify("exit", nil)
+	code, _, _ := c.run()
+	assert.Equal(t, 1, code, "exiting without shutting down")
+
+	c = &lspClient{t: t}
+	c.request("initialize", map[string]interface{}{})
+	code, _, _ = c.run()
+	assert.Equal(t, 1, code, "closing the connection without shutting down")
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	assert.Equal(t, 2, run([]string{"lsp", "--vars", "-"}, nil, stdout, stderr))
+	assert.Contains(t, stderr.String(), "golisp lsp: the vars can't be read from stdin")
+}
+
+func TestRunLspPanic(t *testing.T) {
+	lspHandlers["test/panic"] = func(s *lspServer, params json.RawMessage) (interface{}, error) { panic("boom") }
+	defer delete(lspHandlers, "test/panic")
+
+	c := &lspClient{t: t}
+	c.request("initialize", map[string]interface{}{})
+	failed := c.request("test/panic", nil)
+	shutdown := c.request("shutdown", nil)
+	c.notify("exit", nil)
+	code, replies, _ := c.run()
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, &rpcError{Code: rpcInternalError, Message: "internal error: boom"}, replies[failed].Error)
+	assert.Nil(t, replies[shutdown].Error, "the server keeps serving after a request panics")
+}
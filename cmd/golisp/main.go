@@ -0,0 +1,78 @@
+// Command golisp evaluates golisp scripts. Run without a command, it starts an interactive session.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// command is a subcommand of golisp. It returns the exit code of the process.
+type command struct {
+	summary string
+	run     func(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int
+}
+
+var commands = map[string]command{
+	"fmt":  {summary: "format scripts in the canonical style", run: runFmt},
+	"lint": {summary: "check scripts for likely mistakes", run: runLint},
+	"lsp":  {summary: "serve the language server protocol over stdio, for editors", run: runLsp},
+	"repl": {summary: "start an interactive session (the default)", run: runRepl},
+	"run":  {summary: "evaluate a script with symbols bound from flags and JSON", run: runScript},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		return runRepl(args, stdin, stdout, stderr)
+	}
+
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		usage(stdout)
+		return 0
+	}
+
+	c, found := commands[args[0]]
+	if !found {
+		fmt.Fprintf(stderr, "golisp: unknown command %q\n", args[0])
+		usage(stderr)
+		return 2
+	}
+	return c.run(args[1:], stdin, stdout, stderr)
+}
+
+func usage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "usage: golisp [command] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-8s %s\n", name, commands[name].summary)
+	}
+}
+
+// parseFlags parses the flags of a command and returns its other arguments. Unlike flags.Parse, it accepts flags after
+// the arguments, as in "golisp run rule.lisp --var x=1".
+func parseFlags(flags *flag.FlagSet, args []string) ([]string, error) {
+	arguments := []string{}
+	for {
+		if e := flags.Parse(args); e != nil {
+			return nil, e
+		}
+		if args = flags.Args(); len(args) == 0 {
+			return arguments, nil
+		}
+		arguments, args = append(arguments, args[0]), args[1:]
+	}
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/johnazariah/golisp"
+	"github.com/peterh/liner"
+)
+
+const (
+	prompt             = "golisp> "
+	continuationPrompt = "   ...> "
+	historyFile        = ".golisp_history"
+
+	// wordDelimiters end the names that are completed.
+	wordDelimiters = " \t()[]{}\""
+)
+
+// metaCommandHelp describes the commands of the session, which are written at the start of a line.
+var metaCommandHelp = [...][2]string{
+	{":help", "show this help"},
+	{":env", "list the symbols that are bound"},
+	{":functions [prefix]", "list the functions, or those whose names start with the prefix"},
+	{":bind name form", "evaluate the form and bind its value to the symbol"},
+	{":time form", "evaluate the form and report the time and work it took"},
+	{":load file", "evaluate every form in the file"},
+	{":reset", "discard the symbols and functions bound in this session"},
+	{":quit", "end the session"},
+}
+
+// lineReader reads the lines of a session, such as a terminal through liner.
+type lineReader interface {
+	Prompt(prompt string) (string, error)
+}
+
+// repl is an interactive session. The forms typed into it are evaluated in one context, so symbols bound by one input
+// are seen by the next, and a form may span several lines.
+type repl struct {
+	ctx     *golisp.EvaluationContext
+	out     io.Writer
+	pending []string
+}
+
+func newRepl(out io.Writer) *repl {
+	return &repl{ctx: golisp.NewEvaluationContext(nil), out: out}
+}
+
+func runRepl(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) != 0 {
+		fmt.Fprintln(stderr, "usage: golisp repl")
+		return 2
+	}
+
+	// liner reads the terminal itself, or stdin line by line when it isn't a terminal
+	r := newRepl(stdout)
+	terminal := liner.NewLiner()
+	defer terminal.Close()
+
+	terminal.SetCtrlCAborts(true)
+	terminal.SetTabCompletionStyle(liner.TabPrints)
+	terminal.SetWordCompleter(r.complete)
+
+	history := historyPath()
+	if f, e := os.Open(history); e == nil {
+		_, _ = terminal.ReadHistory(f)
+		f.Close()
+	}
+
+	fmt.Fprintln(stdout, "golisp - type :help for help, :quit or Ctrl-D to leave")
+	r.loop(historyReader{terminal})
+
+	if f, e := os.Create(history); e == nil {
+		_, _ = terminal.WriteHistory(f)
+		f.Close()
+	}
+	return 0
+}
+
+// historyReader adds the lines read from the terminal to its history.
+type historyReader struct {
+	*liner.State
+}
+
+func (h historyReader) Prompt(prompt string) (string, error) {
+	line, e := h.State.Prompt(prompt)
+	if e == nil && strings.TrimSpace(line) != "" {
+		h.State.AppendHistory(line)
+	}
+	return line, e
+}
+
+func historyPath() string {
+	home, e := os.UserHomeDir()
+	if e != nil {
+		return historyFile
+	}
+	return filepath.Join(home, historyFile)
+}
+
+// loop reads lines until the input ends or the session is ended. Ctrl-C abandons the form being typed.
+func (r *repl) loop(reader lineReader) {
+	for {
+		line, e := reader.Prompt(r.prompt())
+		if errors.Is(e, liner.ErrPromptAborted) {
+			r.pending = nil
+			continue
+		}
+		if e != nil {
+			if !errors.Is(e, io.EOF) {
+				fmt.Fprintln(r.out, "error:", e)
+			}
+			return
+		}
+
+		if !r.handleLine(line) {
+			return
+		}
+	}
+}
+
+func (r *repl) prompt() string {
+	if len(r.pending) > 0 {
+		return continuationPrompt
+	}
+	return prompt
+}
+
+// handleLine evaluates the line once it completes the forms begun on earlier lines, or runs a command of the session.
+// It returns false when the session should end.
+func (r *repl) handleLine(line string) bool {
+	if len(r.pending) == 0 {
+		if handled, more := r.metaCommand(line); handled {
+			return more
+		}
+	}
+
+	r.pending = append(r.pending, line)
+	text := strings.Join(r.pending, "\n")
+	if golisp.IsIncomplete(text) {
+		return true
+	}
+
+	r.pending = nil
+	forms, e := golisp.ParseAll(text)
+	if e != nil {
+		fmt.Fprintln(r.out, e)
+		return true
+	}
+
+	for _, form := range forms {
+		r.print(evalForms(r.ctx, []golisp.SExpr{form}))
+	}
+	return true
+}
+
+// metaCommand runs the command of the session the line starts with, if any. Keywords start with a colon too, so a line
+// that doesn't name a command is evaluated.
+func (r *repl) metaCommand(line string) (handled bool, more bool) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false, true
+	}
+
+	name := fields[0]
+	arg := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), name))
+
+	switch name {
+	case ":help":
+		r.help()
+	case ":env":
+		r.env()
+	case ":functions":
+		r.functions(arg)
+	case ":bind":
+		r.bind(arg)
+	case ":time":
+		r.time(arg)
+	case ":load":
+		r.load(arg)
+	case ":reset":
+		r.ctx = golisp.NewEvaluationContext(nil)
+	case ":quit", ":q":
+		return true, false
+	default:
+		return false, true
+	}
+	return true, true
+}
+
+func (r *repl) help() {
+	fmt.Fprintln(r.out, "Type a form to evaluate it; a form may span several lines. Tab completes function names.")
+	fmt.Fprintln(r.out)
+	for _, c := range metaCommandHelp {
+		fmt.Fprintf(r.out, "  %-20s %s\n", c[0], c[1])
+	}
+}
+
+func (r *repl) env() {
+	names := make([]string, 0, len(r.ctx.SymbolTable))
+	for name := range r.ctx.SymbolTable {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if len(names) == 0 {
+		fmt.Fprintln(r.out, "no symbols are bound")
+	}
+	for _, name := range names {
+		v := r.ctx.SymbolTable[name]
+		fmt.Fprintf(r.out, "%s = %s\n", name, v.ToDebugString())
+	}
+}
+
+func (r *repl) functions(prefix string) {
+	for _, name := range r.functionNames() {
+		if strings.HasPrefix(name, prefix) {
+			fmt.Fprintln(r.out, name)
+		}
+	}
+}
+
+func (r *repl) bind(arg string) {
+	fields := strings.Fields(arg)
+	if len(fields) < 2 {
+		fmt.Fprintln(r.out, "usage: :bind name form")
+		return
+	}
+
+	name := fields[0]
+	value, ok := r.evalText(strings.TrimSpace(strings.TrimPrefix(arg, name)))
+	if !ok {
+		return
+	}
+	if value.VariantType == golisp.VAR_ERROR {
+		r.print(value)
+		return
+	}
+
+	r.ctx.SymbolTable[name] = value
+	fmt.Fprintf(r.out, "%s = %s\n", name, value.ToDebugString())
+}
+
+func (r *repl) time(arg string) {
+	if arg == "" {
+		fmt.Fprintln(r.out, "usage: :time form")
+		return
+	}
+
+	r.ctx.ResetUsage()
+	start := time.Now()
+	value, ok := r.evalText(arg)
+	if !ok {
+		return
+	}
+
+	elapsed := time.Since(start)
+	usage := r.ctx.Usage()
+	r.print(value)
+	fmt.Fprintf(r.out, "elapsed %v, %d steps, depth %d, %d bytes\n", elapsed, usage.Steps, usage.MaxDepth, usage.AllocatedBytes)
+}
+
+// load evaluates the forms in the file in order, stopping at the first that fails, and prints the value of the last.
+func (r *repl) load(path string) {
+	if path == "" {
+		fmt.Fprintln(r.out, "usage: :load file")
+		return
+	}
+
+	source, e := ioutil.ReadFile(path)
+	if e != nil {
+		fmt.Fprintln(r.out, "error:", e)
+		return
+	}
+
+	if value, ok := r.evalText(string(source)); ok {
+		r.print(value)
+	}
+}
+
+// evalText evaluates the forms in the text and returns the value of the last, or of the first that fails. It reports
+// false if the text doesn't parse.
+func (r *repl) evalText(text string) (golisp.Variant, bool) {
+	forms, e := golisp.ParseAll(text)
+	if e != nil {
+		fmt.Fprintln(r.out, e)
+		return golisp.Variant{}, false
+	}
+
+	return evalForms(r.ctx, forms), true
+}
+
+func (r *repl) print(v golisp.Variant) {
+	if v.VariantType == golisp.VAR_ERROR {
+		fmt.Fprintln(r.out, "error:", v.ToDebugString())
+		return
+	}
+	fmt.Fprintln(r.out, v.ToDebugString())
+}
+
+func (r *repl) functionNames() []string {
+	names := make([]string, 0, len(r.ctx.FunctionTable))
+	for name := range r.ctx.FunctionTable {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// complete completes the word before the cursor with the names of functions and symbols, or of the commands of the
+// session at the start of a line.
+func (r *repl) complete(line string, pos int) (head string, completions []string, tail string) {
+	start := strings.LastIndexAny(line[:pos], wordDelimiters) + 1
+	head, word, tail := line[:start], line[start:pos], line[pos:]
+	if word == "" {
+		return head, nil, tail
+	}
+
+	candidates := r.functionNames()
+	for name := range r.ctx.SymbolTable {
+		candidates = append(candidates, name)
+	}
+	if strings.TrimSpace(head) == "" && len(r.pending) == 0 {
+		for _, c := range metaCommandHelp {
+			candidates = append(candidates, strings.Fields(c[0])[0])
+		}
+	}
+
+	for _, name := range candidates {
+		if strings.HasPrefix(name, word) {
+			completions = append(completions, name)
+		}
+	}
+	sort.Strings(completions)
+	return head, completions, tail
+}
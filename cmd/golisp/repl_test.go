@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/johnazariah/golisp"
+	"github.com/stretchr/testify/assert"
+)
+
+// scriptedReader plays back the lines of a session, recording the prompts it is given.
+type scriptedReader struct {
+	lines   []string
+	prompts []string
+}
+
+func (s *scriptedReader) Prompt(prompt string) (string, error) {
+	s.prompts = append(s.prompts, prompt)
+	if len(s.lines) == 0 {
+		return "", io.EOF
+	}
+
+	line := s.lines[0]
+	s.lines = s.lines[1:]
+	return line, nil
+}
+
+func TestRepl(t *testing.T) {
+	tests := [...]struct {
+		desc     string
+		lines    []string
+		expected string
+	}{
+		{desc: "evaluates a form", lines: []string{"(+ 1 2)"}, expected: "3\n"},
+		{desc: "evaluates every form on a line", lines: []string{"(+ 1 2) (upper \"a\")"}, expected: "3\nA\n"},
+		{desc: "reads a form over several lines", lines: []string{"(+ 1", "   (* 2 3))"}, expected: "7\n"},
+		{desc: "reads a string over several lines", lines: []string{"(concat \"a", "b\")"}, expected: "a\nb\n"},
+		{desc: "keeps bindings between inputs", lines: []string{":bind x (* 6 7)", "(+ x 1)"}, expected: "x = 42\n43\n"},
+		{desc: "keywords are evaluated", lines: []string{":approved"}, expected: ":approved\n"},
+		{desc: "reports errors", lines: []string{"(/ 1 0)"}, expected: "error: math error: attempt to divide by zero\n"},
+		{desc: "reports parse errors", lines: []string{"(+ 1 2))"}, expected: "parse error: unexpected close paren\n"},
+		{desc: "lists the symbols", lines: []string{":bind y 2", ":bind x 1", ":env"}, expected: "y = 2\nx = 1\nx = 1\ny = 2\n"},
+		{desc: "lists no symbols", lines: []string{":env"}, expected: "no symbols are bound\n"},
+		{desc: "lists functions by prefix", lines: []string{":functions str/u"}, expected: "str/upper\n"},
+		{desc: "resets the session", lines: []string{":bind x 1", ":reset", ":env"}, expected: "x = 1\nno symbols are bound\n"},
+		{desc: "quits", lines: []string{":quit", "(+ 1 2)"}, expected: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			newRepl(out).loop(&scriptedReader{lines: test.lines})
+
+			assert.Equal(t, test.expected, out.String())
+		})
+	}
+}
+
+func TestReplPrompts(t *testing.T) {
+	reader := &scriptedReader{lines: []string{"(+ 1", "2", ")", "(+ 3"}}
+	newRepl(ioutil.Discard).loop(reader)
+
+	assert.Equal(t, []string{prompt, continuationPrompt, continuationPrompt, prompt, continuationPrompt}, reader.prompts)
+}
+
+func TestReplLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.lisp")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("(* a rule file *)\n(+ 1 2)\n(concat\n  \"a\"\n  \"b\")\n"), 0o600))
+
+	out := &bytes.Buffer{}
+	r := newRepl(out)
+	r.handleLine(":load " + path)
+	r.handleLine(":load " + filepath.Join(t.TempDir(), "missing.lisp"))
+
+	lines := strings.Split(out.String(), "\n")
+	assert.Equal(t, "ab", lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "error: "))
+}
+
+func TestReplTime(t *testing.T) {
+	out := &bytes.Buffer{}
+	newRepl(out).handleLine(":time (map abs [1 -2])")
+
+	lines := strings.Split(out.String(), "\n")
+	assert.Equal(t, "[1 2]", lines[0])
+	assert.Regexp(t, `^elapsed .+, 3 steps, depth 2, \d+ bytes$`, lines[1])
+}
+
+func TestReplComplete(t *testing.T) {
+	r := newRepl(ioutil.Discard)
+	r.ctx.SymbolTable["upper-limit"] = golisp.Variant{VariantType: golisp.VAR_NULL}
+
+	tests := [...]struct {
+		line        string
+		pos         int
+		head        string
+		completions []string
+		tail        string
+	}{
+		{line: "(str/upp", pos: 8, head: "(", completions: []string{"str/upper"}, tail: ""},
+		{line: "(map upp [1])", pos: 8, head: "(map ", completions: []string{"upper", "upper-limit"}, tail: " [1])"},
+		{line: ":lo", pos: 3, head: "", completions: []string{":load"}, tail: ""},
+		{line: "(f ", pos: 3, head: "(f ", completions: nil, tail: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.line, func(t *testing.T) {
+			head, completions, tail := r.complete(test.line, test.pos)
+			assert.Equal(t, test.head, head)
+			assert.Equal(t, test.completions, completions)
+			assert.Equal(t, test.tail, tail)
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	out, errors := &bytes.Buffer{}, &bytes.Buffer{}
+	assert.Equal(t, 0, run([]string{"help"}, nil, out, errors))
+	assert.Contains(t, out.String(), "repl")
+
+	assert.Equal(t, 2, run([]string{"nonsense"}, nil, out, errors))
+	assert.Contains(t, errors.String(), `unknown command "nonsense"`)
+}
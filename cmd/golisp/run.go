@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/johnazariah/golisp"
+)
+
+const (
+	// inputSymbol is the symbol the document given with --input is bound to.
+	inputSymbol = "input"
+
+	// stdinPath names the standard input in place of a file.
+	stdinPath = "-"
+)
+
+// variables collects the repeated --var flags, in order.
+type variables []string
+
+func (v *variables) String() string {
+	return strings.Join(*v, " ")
+}
+
+func (v *variables) Set(s string) error {
+	if !strings.Contains(s, "=") || strings.HasPrefix(s, "=") {
+		return fmt.Errorf("expected name=value, not %q", s)
+	}
+	*v = append(*v, s)
+	return nil
+}
+
+// runOptions are the flags of the run command.
+type runOptions struct {
+	vars     variables
+	varsFile string
+	input    string
+	output   string
+	script   string
+}
+
+func parseRunOptions(args []string, stderr io.Writer) (*runOptions, error) {
+	options := &runOptions{}
+
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Var(&options.vars, "var", "bind a symbol, as `name=value`; a value that isn't JSON is bound as a string (repeatable)")
+	flags.StringVar(&options.varsFile, "vars", "", "bind the keys of the JSON object in the `file` as symbols")
+	flags.StringVar(&options.input, "input", "", "bind the JSON document in the `file` to the symbol \"input\" (- for stdin)")
+	flags.StringVar(&options.output, "output", "text", "print the result as `text` or json")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: golisp run [flags] [script]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Evaluates the forms in the script, or in stdin if it is - or not given, and prints the value of the last.")
+		fmt.Fprintln(stderr, "--var takes precedence over --input, which takes precedence over --vars.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+
+	scripts, e := parseFlags(flags, args)
+	if e != nil {
+		return nil, e
+	}
+
+	switch {
+	case len(scripts) > 1:
+		return nil, fmt.Errorf("expected one script, not %d", len(scripts))
+	case options.output != "text" && options.output != "json":
+		return nil, fmt.Errorf("unknown output %q, expected text or json", options.output)
+	}
+
+	options.script = stdinPath
+	if len(scripts) == 1 {
+		options.script = scripts[0]
+	}
+	if e := options.ensureOneStdinSource(); e != nil {
+		return nil, e
+	}
+	return options, nil
+}
+
+// ensureOneStdinSource checks that no more than one of the script, the vars and the input is read from stdin, as the
+// first to read it would leave nothing for the others.
+func (options *runOptions) ensureOneStdinSource() error {
+	sources := []string{}
+	for _, source := range []struct{ name, path string }{{"the script", options.script}, {"--vars", options.varsFile}, {"--input", options.input}} {
+		if source.path == stdinPath {
+			sources = append(sources, source.name)
+		}
+	}
+
+	switch len(sources) {
+	case 2:
+		return fmt.Errorf("%s and %s can't both be read from stdin", sources[0], sources[1])
+	case 3:
+		return fmt.Errorf("%s, %s and %s can't all be read from stdin", sources[0], sources[1], sources[2])
+	}
+	return nil
+}
+
+// runScript evaluates a script with the symbols bound from the flags. It exits with 1 if the script evaluates to an
+// error, and with 2 if it can't be run at all.
+func runScript(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	options, e := parseRunOptions(args, stderr)
+	if e == flag.ErrHelp {
+		return 0
+	}
+	if e != nil {
+		fmt.Fprintln(stderr, "golisp run:", e)
+		return 2
+	}
+
+	ctx := golisp.NewEvaluationContext(nil)
+	if e := options.bind(ctx.SymbolTable, stdin); e != nil {
+		fmt.Fprintln(stderr, "golisp run:", e)
+		return 2
+	}
+
+	source, e := readSource(options.script, stdin)
+	if e != nil {
+		fmt.Fprintln(stderr, "golisp run:", e)
+		return 2
+	}
+
+	forms, e := golisp.ParseAll(source)
+	if e != nil {
+		fmt.Fprintf(stderr, "golisp run: %s: %v\n", options.script, e)
+		return 2
+	}
+
+	value := evalForms(ctx, forms)
+	if value.VariantType == golisp.VAR_ERROR {
+		fmt.Fprintln(stderr, "error:", value.ToDebugString())
+		return 1
+	}
+
+	if options.output == "json" {
+		text, e := value.MarshalJSON()
+		if e != nil {
+			fmt.Fprintln(stderr, "golisp run:", e)
+			return 1
+		}
+		fmt.Fprintln(stdout, string(text))
+		return 0
+	}
+
+	fmt.Fprintln(stdout, value.ToDebugString())
+	return 0
+}
+
+// bind binds the symbols given by the flags.
+func (options *runOptions) bind(symbols golisp.SymbolTable, stdin io.Reader) error {
+	if options.varsFile != "" {
+		v, e := readJSON(options.varsFile, stdin)
+		if e != nil {
+			return e
+		}
+
+		m, e := v.GetMapValue()
+		if e != nil {
+			return fmt.Errorf("%s: expected a JSON object", options.varsFile)
+		}
+		for _, key := range m.Keys() {
+			name, e := key.CoerceToString()
+			if e != nil {
+				return e
+			}
+			symbols[name], _ = m.Get(key)
+		}
+	}
+
+	if options.input != "" {
+		v, e := readJSON(options.input, stdin)
+		if e != nil {
+			return e
+		}
+		symbols[inputSymbol] = v
+	}
+
+	for _, binding := range options.vars {
+		parts := strings.SplitN(binding, "=", 2)
+		symbols[parts[0]] = parseVariable(parts[1])
+	}
+	return nil
+}
+
+// parseVariable reads the value of a --var flag as JSON, so that numbers, booleans, arrays and objects keep their
+// types, or as a string if it isn't JSON.
+func parseVariable(s string) golisp.Variant {
+	if v, e := golisp.ParseJSON(s); e == nil {
+		return v
+	}
+	return golisp.Variant{VariantType: golisp.VAR_STRING, VariantValue: s}
+}
+
+func readJSON(path string, stdin io.Reader) (golisp.Variant, error) {
+	text, e := readSource(path, stdin)
+	if e != nil {
+		return golisp.Variant{}, e
+	}
+
+	v, e := golisp.ParseJSON(text)
+	if e != nil {
+		return golisp.Variant{}, fmt.Errorf("%s: %v", path, e)
+	}
+	return v, nil
+}
+
+func readSource(path string, stdin io.Reader) (string, error) {
+	var text []byte
+	var e error
+	if path == stdinPath {
+		text, e = ioutil.ReadAll(stdin)
+	} else {
+		text, e = ioutil.ReadFile(path)
+	}
+	return string(text), e
+}
+
+// evalForms evaluates the forms in order and returns the value of the last, or of the first that fails. Ctrl-C cancels
+// the evaluation.
+func evalForms(ctx *golisp.EvaluationContext, forms []golisp.SExpr) golisp.Variant {
+	goContext, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	value := golisp.Variant{VariantType: golisp.VAR_NULL}
+	for _, form := range forms {
+		if value = golisp.EvalContext(goContext, form, ctx); value.VariantType == golisp.VAR_ERROR {
+			break
+		}
+	}
+	return value
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunScript(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	rule := write("rule.lisp", "(* approve large orders *)\n(> (* input.amount rate) x)\n")
+	vars := write("vars.json", `{"rate": 2, "x": 100}`)
+	event := write("event.json", `{"amount": 64}`)
+	broken := write("broken.lisp", "(+ 1")
+	failing := write("failing.lisp", "(+ 1 2)\n(/ 1 0)\n(+ 3 4)")
+
+	tests := [...]struct {
+		desc   string
+		args   []string
+		stdin  string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{desc: "vars and input", args: []string{"run", rule, "--vars", vars, "--input", event}, code: 0, stdout: "true\n"},
+		{desc: "var overrides vars", args: []string{"run", "--vars", vars, "--var", "x=200", "--input", event, rule}, code: 0, stdout: "false\n"},
+		{desc: "script from stdin", args: []string{"run", "--var", "x=22", "--var", "name=alice"}, stdin: `$"{name} {x}"`, code: 0, stdout: "alice 22\n"},
+		{desc: "input from stdin", args: []string{"run", "--vars", vars, "--input", "-", rule}, stdin: `{"amount": 10}`, code: 0, stdout: "false\n"},
+		{desc: "json output", args: []string{"run", "--output", "json", "--var", `xs=[1,2]`}, stdin: "{:a (map abs xs)}", code: 0, stdout: "{\"a\":[1,2]}\n"},
+		{desc: "text output of strings", args: []string{"run"}, stdin: `(upper "a")`, code: 0, stdout: "A\n"},
+		{desc: "empty script", args: []string{"run"}, stdin: "", code: 0, stdout: "NIL\n"},
+		{desc: "error result", args: []string{"run", failing}, code: 1, stderr: "error: math error: attempt to divide by zero\n"},
+		{desc: "parse error", args: []string{"run", broken}, code: 2, stderr: "golisp run: " + broken + ": parse error: unexpected end of string\n"},
+		{desc: "missing script", args: []string{"run", filepath.Join(dir, "missing.lisp")}, code: 2, stderr: "golisp run: open " + filepath.Join(dir, "missing.lisp") + ": no such file or directory\n"},
+		{desc: "vars must be an object", args: []string{"run", "--vars", write("list.json", "[1]"), rule}, code: 2, stderr: "golisp run: " + filepath.Join(dir, "list.json") + ": expected a JSON object\n"},
+		{desc: "malformed var", args: []string{"run", "--var", "x", rule}, code: 2},
+		{desc: "unknown output", args: []string{"run", "--output", "yaml", rule}, code: 2, stderr: "golisp run: unknown output \"yaml\", expected text or json\n"},
+		{desc: "two scripts", args: []string{"run", rule, rule}, code: 2, stderr: "golisp run: expected one script, not 2\n"},
+		{desc: "stdin twice", args: []string{"run", "--input", "-"}, code: 2, stderr: "golisp run: the script and --input can't both be read from stdin\n"},
+		{desc: "vars and input from stdin", args: []string{"run", "--vars", "-", "--input", "-", rule}, code: 2, stderr: "golisp run: --vars and --input can't both be read from stdin\n"},
+		{desc: "everything from stdin", args: []string{"run", "--vars", "-", "--input", "-", "-"}, code: 2, stderr: "golisp run: the script, --vars and --input can't all be read from stdin\n"},
+		{desc: "script and vars from stdin", args: []string{"run", "--vars", "-"}, code: 2, stderr: "golisp run: the script and --vars can't both be read from stdin\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			code := run(test.args, strings.NewReader(test.stdin), stdout, stderr)
+
+			assert.Equal(t, test.code, code, stderr.String())
+			assert.Equal(t, test.stdout, stdout.String())
+			if test.stderr != "" {
+				assert.Equal(t, test.stderr, stderr.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,520 @@
+package golisp
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+const fieldTagName = "golisp"
+
+var (
+	variantGoType  = reflect.TypeOf(Variant{})
+	timeGoType     = reflect.TypeOf(time.Time{})
+	durationGoType = reflect.TypeOf(time.Duration(0))
+	errorGoType    = reflect.TypeOf((*error)(nil)).Elem()
+	functionGoType = reflect.TypeOf(FunctionType(nil))
+)
+
+// FromGo converts a go value into a variant.
+// Numbers become VAR_INT or VAR_FLOAT, time.Time becomes VAR_DATE and time.Duration becomes a VAR_INT of nanoseconds.
+// Slices and arrays become VAR_VECTOR, while maps and structs become VAR_MAP.
+// Struct fields are keyed by name, which can be overridden with a tag like `golisp:"name"`, or skipped with `golisp:"-"`.
+// A value that refers back to itself, through a pointer, map or slice, is an error.
+func FromGo(value interface{}) (Variant, error) {
+	return variantFromValue(reflect.ValueOf(value))
+}
+
+// ToGo converts the variant into the value pointed to by target, following the same rules as FromGo.
+func (b *Variant) ToGo(target interface{}) error {
+	p := reflect.ValueOf(target)
+	if !p.IsValid() || p.Kind() != reflect.Ptr || p.IsNil() {
+		return buildInvalidTargetError(target)
+	}
+
+	v, e := variantToValue(*b, p.Elem().Type())
+	if e != nil {
+		return e
+	}
+
+	p.Elem().Set(v)
+	return nil
+}
+
+type structField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+func structFields(t reflect.Type) []structField {
+	fields := []structField{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name, omitEmpty := field.Name, false
+		if tag, found := field.Tag.Lookup(fieldTagName); found {
+			parts := strings.Split(tag, ",")
+			if parts[0] == "-" {
+				continue
+			}
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			for _, option := range parts[1:] {
+				omitEmpty = omitEmpty || option == "omitempty"
+			}
+		}
+
+		fields = append(fields, structField{name: name, index: field.Index, omitEmpty: omitEmpty})
+	}
+
+	return fields
+}
+
+func findStructField(t reflect.Type, name string) (structField, bool) {
+	for _, f := range structFields(t) {
+		if f.name == name {
+			return f, true
+		}
+	}
+	return structField{}, false
+}
+
+// goValueVisit identifies a pointer, map or slice that is being converted, so that a value that refers back to itself
+// is reported rather than followed forever. The type tells a struct apart from its first field, which shares its
+// address, and the length tells a slice apart from a shorter slice of the same array.
+type goValueVisit struct {
+	pointer uintptr
+	goType  reflect.Type
+	length  int
+}
+
+func variantFromValue(v reflect.Value) (Variant, error) {
+	return variantFromVisitedValue(v, map[goValueVisit]struct{}{})
+}
+
+func variantFromVisitedValue(v reflect.Value, visiting map[goValueVisit]struct{}) (Variant, error) {
+	if !v.IsValid() {
+		return Variant{VariantType: VAR_NULL}, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr, reflect.Slice, reflect.Map, reflect.Func:
+		if v.IsNil() {
+			return Variant{VariantType: VAR_NULL}, nil
+		}
+	}
+
+	switch v.Type() {
+	case variantGoType:
+		return v.Interface().(Variant), nil
+	case timeGoType:
+		return Variant{VariantType: VAR_DATE, VariantValue: v.Interface().(time.Time)}, nil
+	case durationGoType:
+		return Variant{VariantType: VAR_INT, VariantValue: v.Int()}, nil
+	}
+
+	if v.Type().Implements(errorGoType) {
+		return Variant{VariantType: VAR_ERROR, VariantValue: v.Interface().(error)}, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		visit := goValueVisit{pointer: v.Pointer(), goType: v.Type()}
+		if v.Kind() == reflect.Slice {
+			visit.length = v.Len()
+		}
+		if _, found := visiting[visit]; found {
+			return Variant{VariantType: VAR_ERROR}, buildCyclicGoValueError(v.Type().String())
+		}
+		visiting[visit] = struct{}{}
+		defer delete(visiting, visit)
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		return variantFromVisitedValue(v.Elem(), visiting)
+
+	case reflect.Bool:
+		return Variant{VariantType: VAR_BOOL, VariantValue: v.Bool()}, nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return Variant{VariantType: VAR_INT, VariantValue: v.Int()}, nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() > math.MaxInt64 {
+			return Variant{VariantType: VAR_ERROR}, buildIntegerOverflowError(v.Uint(), "int64")
+		}
+		return Variant{VariantType: VAR_INT, VariantValue: int64(v.Uint())}, nil
+
+	case reflect.Float32, reflect.Float64:
+		return Variant{VariantType: VAR_FLOAT, VariantValue: v.Float()}, nil
+
+	case reflect.String:
+		return Variant{VariantType: VAR_STRING, VariantValue: v.String()}, nil
+
+	case reflect.Slice, reflect.Array:
+		items := make([]Variant, v.Len())
+		for i := range items {
+			item, e := variantFromVisitedValue(v.Index(i), visiting)
+			if e != nil {
+				return Variant{VariantType: VAR_ERROR}, e
+			}
+			items[i] = item
+		}
+		return Variant{VariantType: VAR_VECTOR, VariantValue: items}, nil
+
+	case reflect.Map:
+		return variantFromMapValue(v, visiting)
+
+	case reflect.Struct:
+		return variantFromStructValue(v, visiting)
+
+	case reflect.Func:
+		if v.Type().ConvertibleTo(functionGoType) {
+			return Variant{VariantType: VAR_FUNCTION, VariantValue: v.Convert(functionGoType).Interface().(FunctionType)}, nil
+		}
+
+		f, e := WrapGoFunc(v.Type().String(), v.Interface())
+		if e != nil {
+			return Variant{VariantType: VAR_ERROR}, e
+		}
+		return Variant{VariantType: VAR_FUNCTION, VariantValue: f}, nil
+	}
+
+	return Variant{VariantType: VAR_ERROR}, buildUnsupportedGoTypeError(v.Type().String())
+}
+
+func variantFromMapValue(v reflect.Value, visiting map[goValueVisit]struct{}) (Variant, error) {
+	keys := make([]Variant, 0, v.Len())
+	values := make([]Variant, 0, v.Len())
+
+	iter := v.MapRange()
+	for iter.Next() {
+		key, e := variantFromVisitedValue(iter.Key(), visiting)
+		if e != nil {
+			return Variant{VariantType: VAR_ERROR}, e
+		}
+		value, e := variantFromVisitedValue(iter.Value(), visiting)
+		if e != nil {
+			return Variant{VariantType: VAR_ERROR}, e
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+
+	// go maps have no stable order, so sort the entries to keep the result deterministic
+	order := make([]int, len(keys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return keys[order[i]].toElementDebugString() < keys[order[j]].toElementDebugString()
+	})
+
+	m := NewVariantMap()
+	for _, i := range order {
+		if e := m.put(keys[i], values[i]); e != nil {
+			return Variant{VariantType: VAR_ERROR}, e
+		}
+	}
+	return Variant{VariantType: VAR_MAP, VariantValue: m}, nil
+}
+
+func variantFromStructValue(v reflect.Value, visiting map[goValueVisit]struct{}) (Variant, error) {
+	m := NewVariantMap()
+
+	for _, field := range structFields(v.Type()) {
+		fv := v.FieldByIndex(field.index)
+		if field.omitEmpty && fv.IsZero() {
+			continue
+		}
+
+		value, e := variantFromVisitedValue(fv, visiting)
+		if e != nil {
+			return Variant{VariantType: VAR_ERROR}, e
+		}
+
+		if e := m.put(Variant{VariantType: VAR_STRING, VariantValue: field.name}, value); e != nil {
+			return Variant{VariantType: VAR_ERROR}, e
+		}
+	}
+	return Variant{VariantType: VAR_MAP, VariantValue: m}, nil
+}
+
+func variantToValue(b Variant, t reflect.Type) (reflect.Value, error) {
+	switch t {
+	case variantGoType:
+		return reflect.ValueOf(b), nil
+
+	case errorGoType:
+		switch b.VariantType {
+		case VAR_NULL:
+			return reflect.Zero(t), nil
+		case VAR_ERROR:
+			v, e := b.GetErrorValue()
+			if e != nil {
+				return reflect.Value{}, e
+			}
+			return reflect.ValueOf(&v).Elem(), nil
+		}
+		return reflect.Value{}, buildGoTypeError(b.VariantType, t.String())
+	}
+
+	if e := ensureTypeIsNotInvalid(b); e != nil {
+		return reflect.Value{}, e
+	}
+
+	if b.VariantType == VAR_OBJECT {
+		hv := reflect.ValueOf(b.VariantValue)
+		if hv.IsValid() && hv.Type().AssignableTo(t) {
+			result := reflect.New(t).Elem()
+			result.Set(hv)
+			return result, nil
+		}
+
+		copied, e := variantFromValue(hv)
+		if e != nil {
+			return reflect.Value{}, e
+		}
+		return variantToValue(copied, t)
+	}
+
+	switch t {
+	case timeGoType:
+		d, e := b.GetDateValue()
+		if e != nil {
+			return reflect.Value{}, e
+		}
+		return reflect.ValueOf(d), nil
+
+	case durationGoType:
+		if b.VariantType == VAR_STRING {
+			s, e := b.CoerceToString()
+			if e != nil {
+				return reflect.Value{}, e
+			}
+			d, e := time.ParseDuration(s)
+			if e != nil {
+				return reflect.Value{}, e
+			}
+			return reflect.ValueOf(d), nil
+		}
+	}
+
+	result := reflect.New(t).Elem()
+
+	switch t.Kind() {
+	case reflect.Interface:
+		if t.NumMethod() != 0 {
+			break
+		}
+		if b.VariantType == VAR_NULL {
+			return result, nil
+		}
+		v, e := variantToInterface(b)
+		if e != nil {
+			return reflect.Value{}, e
+		}
+		result.Set(reflect.ValueOf(v))
+		return result, nil
+
+	case reflect.Ptr:
+		if b.VariantType == VAR_NULL {
+			return result, nil
+		}
+		v, e := variantToValue(b, t.Elem())
+		if e != nil {
+			return reflect.Value{}, e
+		}
+		p := reflect.New(t.Elem())
+		p.Elem().Set(v)
+		return p, nil
+
+	case reflect.Bool:
+		v, e := b.CoerceToBool()
+		if e != nil {
+			return reflect.Value{}, e
+		}
+		result.SetBool(v)
+		return result, nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, e := b.CoerceToInt()
+		if e != nil {
+			return reflect.Value{}, e
+		}
+		if result.OverflowInt(v) {
+			return reflect.Value{}, buildIntegerOverflowError(v, t.String())
+		}
+		result.SetInt(v)
+		return result, nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, e := b.CoerceToInt()
+		if e != nil {
+			return reflect.Value{}, e
+		}
+		if v < 0 || result.OverflowUint(uint64(v)) {
+			return reflect.Value{}, buildIntegerOverflowError(v, t.String())
+		}
+		result.SetUint(uint64(v))
+		return result, nil
+
+	case reflect.Float32, reflect.Float64:
+		v, e := b.CoerceToFloat()
+		if e != nil {
+			return reflect.Value{}, e
+		}
+		result.SetFloat(v)
+		return result, nil
+
+	case reflect.String:
+		if b.VariantType != VAR_STRING && b.VariantType != VAR_KEYWORD {
+			return reflect.Value{}, buildGoTypeError(b.VariantType, t.String())
+		}
+		v, e := keyName(b)
+		if e != nil {
+			return reflect.Value{}, e
+		}
+		result.SetString(v)
+		return result, nil
+
+	case reflect.Slice, reflect.Array:
+		items, e := b.GetSequenceValue()
+		if e != nil {
+			return reflect.Value{}, e
+		}
+		if t.Kind() == reflect.Slice {
+			result = reflect.MakeSlice(t, len(items), len(items))
+		} else if len(items) != t.Len() {
+			return reflect.Value{}, buildArrayLengthError(len(items), t.String())
+		}
+		for i, item := range items {
+			v, e := variantToValue(item, t.Elem())
+			if e != nil {
+				return reflect.Value{}, e
+			}
+			result.Index(i).Set(v)
+		}
+		return result, nil
+
+	case reflect.Map:
+		m, e := b.GetMapValue()
+		if e != nil {
+			return reflect.Value{}, e
+		}
+		result = reflect.MakeMapWithSize(t, m.Len())
+		for i, key := range m.keys {
+			k, e := variantToValue(key, t.Key())
+			if e != nil {
+				return reflect.Value{}, e
+			}
+			v, e := variantToValue(m.values[i], t.Elem())
+			if e != nil {
+				return reflect.Value{}, e
+			}
+			result.SetMapIndex(k, v)
+		}
+		return result, nil
+
+	case reflect.Struct:
+		m, e := b.GetMapValue()
+		if e != nil {
+			return reflect.Value{}, e
+		}
+		for i, key := range m.keys {
+			name, e := keyName(key)
+			if e != nil {
+				return reflect.Value{}, buildGoTypeError(key.VariantType, "string")
+			}
+			field, found := findStructField(t, name)
+			if !found {
+				return reflect.Value{}, buildUnknownFieldError(name, t.String())
+			}
+			fv := result.FieldByIndex(field.index)
+			v, e := variantToValue(m.values[i], fv.Type())
+			if e != nil {
+				return reflect.Value{}, e
+			}
+			fv.Set(v)
+		}
+		return result, nil
+
+	case reflect.Func:
+		if b.VariantType != VAR_FUNCTION || !functionGoType.ConvertibleTo(t) {
+			break
+		}
+		f, ok := b.VariantValue.(FunctionType)
+		if !ok {
+			return reflect.Value{}, buildInconsistentTypeError(b.VariantValue, b.VariantType)
+		}
+		return reflect.ValueOf(f).Convert(t), nil
+	}
+
+	return reflect.Value{}, buildGoTypeError(b.VariantType, t.String())
+}
+
+// variantToInterface converts a variant into the most natural go value for it.
+// Keywords become their names, and maps are converted to map[string]interface{}, with keys coerced to strings.
+func variantToInterface(b Variant) (interface{}, error) {
+	if e := ensureTypeIsNotInvalid(b); e != nil {
+		return nil, e
+	}
+
+	switch b.VariantType {
+	case VAR_NULL, VAR_UNKNOWN:
+		return nil, nil
+
+	case VAR_LIST, VAR_VECTOR, VAR_SET:
+		items, e := b.GetSequenceValue()
+		if e != nil {
+			return nil, e
+		}
+		result := make([]interface{}, len(items))
+		for i, item := range items {
+			if result[i], e = variantToInterface(item); e != nil {
+				return nil, e
+			}
+		}
+		return result, nil
+
+	case VAR_MAP:
+		m, e := b.GetMapValue()
+		if e != nil {
+			return nil, e
+		}
+		result := make(map[string]interface{}, m.Len())
+		for i, key := range m.keys {
+			k, e := keyName(key)
+			if e != nil {
+				if k, e = key.CoerceToString(); e != nil {
+					return nil, e
+				}
+			}
+			if result[k], e = variantToInterface(m.values[i]); e != nil {
+				return nil, e
+			}
+		}
+		return result, nil
+
+	case VAR_FUNCTION:
+		f, ok := b.VariantValue.(FunctionType)
+		if !ok {
+			return nil, buildInconsistentTypeError(b.VariantValue, b.VariantType)
+		}
+		return f, nil
+
+	case VAR_KEYWORD:
+		return b.GetKeywordName()
+	}
+
+	return b.GetTypeConsistentValue()
+}
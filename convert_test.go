@@ -0,0 +1,173 @@
+package golisp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCustomer struct {
+	Name    string `golisp:"name"`
+	Country string `golisp:"country,omitempty"`
+	secret  string
+	Ignored int `golisp:"-"`
+}
+
+type testOrder struct {
+	ID       uint32            `golisp:"id"`
+	Placed   time.Time         `golisp:"placed"`
+	Window   time.Duration     `golisp:"window"`
+	Customer *testCustomer     `golisp:"customer"`
+	Lines    []testLineItem    `golisp:"lines"`
+	Tags     map[string]string `golisp:"tags"`
+	Total    float32           `golisp:"total"`
+	Express  bool              `golisp:"express"`
+}
+
+type testNode struct {
+	Name string
+	Next *testNode
+}
+
+func stringVariant(s string) Variant {
+	return Variant{VariantType: VAR_STRING, VariantValue: s}
+}
+
+func TestFromGo(t *testing.T) {
+	placed := time.Date(2021, 5, 1, 10, 30, 0, 0, time.UTC)
+
+	customer := NewVariantMap()
+	customer, _ = customer.Set(stringVariant("name"), stringVariant("Ada"))
+
+	tags := NewVariantMap()
+	tags, _ = tags.Set(stringVariant("a"), stringVariant("1"))
+	tags, _ = tags.Set(stringVariant("b"), stringVariant("2"))
+
+	loop := &testNode{Name: "a"}
+	loop.Next = loop
+	loopMap := map[string]interface{}{}
+	loopMap["self"] = loopMap
+	loopSlice := []interface{}{nil}
+	loopSlice[0] = loopSlice
+	shared := &testNode{Name: "b"}
+
+	node := NewVariantMap()
+	node, _ = node.Set(stringVariant("Name"), stringVariant("b"))
+	node, _ = node.Set(stringVariant("Next"), Variant{VariantType: VAR_NULL})
+
+	tests := [...]struct {
+		desc     string
+		input    interface{}
+		expected Variant
+		err      error
+	}{
+		{desc: "nil", input: nil, expected: Variant{VariantType: VAR_NULL}},
+		{desc: "int8", input: int8(-3), expected: Variant{VariantType: VAR_INT, VariantValue: int64(-3)}},
+		{desc: "uint64", input: uint64(3), expected: Variant{VariantType: VAR_INT, VariantValue: int64(3)}},
+		{desc: "uint64 overflow", input: uint64(1 << 63), err: buildIntegerOverflowError(uint64(1<<63), "int64")},
+		{desc: "float32", input: float32(0.5), expected: Variant{VariantType: VAR_FLOAT, VariantValue: float64(0.5)}},
+		{desc: "string", input: "abc", expected: stringVariant("abc")},
+		{desc: "bool", input: true, expected: Variant{VariantType: VAR_BOOL, VariantValue: true}},
+		{desc: "time", input: placed, expected: Variant{VariantType: VAR_DATE, VariantValue: placed}},
+		{desc: "duration", input: time.Second, expected: Variant{VariantType: VAR_INT, VariantValue: int64(time.Second)}},
+		{desc: "error", input: errRandom, expected: Variant{VariantType: VAR_ERROR, VariantValue: errRandom}},
+		{desc: "pointer", input: &testCustomer{Name: "Ada", secret: "x", Ignored: 1}, expected: Variant{VariantType: VAR_MAP, VariantValue: customer}},
+		{desc: "map is sorted by key", input: map[string]string{"b": "2", "a": "1"}, expected: Variant{VariantType: VAR_MAP, VariantValue: tags}},
+		{desc: "array", input: [2]string{"a", "b"}, expected: Variant{VariantType: VAR_VECTOR, VariantValue: []Variant{stringVariant("a"), stringVariant("b")}}},
+		{desc: "unsupported", input: make(chan int), err: buildUnsupportedGoTypeError("chan int")},
+		{desc: "pointer cycle", input: loop, err: buildCyclicGoValueError("*golisp.testNode")},
+		{desc: "map cycle", input: loopMap, err: buildCyclicGoValueError("map[string]interface {}")},
+		{desc: "slice cycle", input: loopSlice, err: buildCyclicGoValueError("[]interface {}")},
+		{desc: "shared pointer is not a cycle", input: []*testNode{shared, shared}, expected: Variant{VariantType: VAR_VECTOR, VariantValue: []Variant{{VariantType: VAR_MAP, VariantValue: node}, {VariantType: VAR_MAP, VariantValue: node}}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			actual, e := FromGo(test.input)
+			if test.err != nil {
+				assert.Equal(t, test.err, e)
+			} else {
+				assert.Nil(t, e)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestToGo_RoundTrip(t *testing.T) {
+	expected := testOrder{
+		ID:       7,
+		Placed:   time.Date(2021, 5, 1, 10, 30, 0, 0, time.UTC),
+		Window:   90 * time.Minute,
+		Customer: &testCustomer{Name: "Ada", Country: "UK"},
+		Lines:    []testLineItem{{Sku: "A-1", Quantity: 2, Price: 1.25}},
+		Tags:     map[string]string{"channel": "web"},
+		Total:    2.5,
+		Express:  true,
+	}
+
+	v, e := FromGo(expected)
+	assert.Nil(t, e)
+
+	actual := testOrder{}
+	assert.Nil(t, v.ToGo(&actual))
+	assert.Equal(t, expected, actual)
+}
+
+func TestToGo(t *testing.T) {
+	var i int16
+	var f float64
+	var s string
+	var d time.Duration
+	var any interface{}
+	var xs []int
+
+	tests := [...]struct {
+		desc     string
+		input    Variant
+		target   interface{}
+		expected interface{}
+		err      error
+	}{
+		{desc: "int16", input: Variant{VariantType: VAR_INT, VariantValue: int64(12)}, target: &i, expected: int16(12)},
+		{desc: "int16 overflow", input: Variant{VariantType: VAR_INT, VariantValue: int64(1 << 20)}, target: &i, err: buildIntegerOverflowError(int64(1<<20), "int16")},
+		{desc: "float from int", input: Variant{VariantType: VAR_INT, VariantValue: int64(2)}, target: &f, expected: float64(2)},
+		{desc: "string", input: stringVariant("x"), target: &s, expected: "x"},
+		{desc: "string from int", input: Variant{VariantType: VAR_INT, VariantValue: int64(1)}, target: &s, err: buildGoTypeError(VAR_INT, "string")},
+		{desc: "duration from string", input: stringVariant("1m30s"), target: &d, expected: 90 * time.Second},
+		{desc: "duration from int", input: Variant{VariantType: VAR_INT, VariantValue: int64(5)}, target: &d, expected: time.Duration(5)},
+		{desc: "interface", input: Variant{VariantType: VAR_LIST, VariantValue: []Variant{stringVariant("a")}}, target: &any, expected: []interface{}{"a"}},
+		{desc: "slice from non-sequence", input: stringVariant("a"), target: &xs, err: buildTypeError(VAR_STRING, VAR_VECTOR)},
+		{desc: "unknown field", input: Variant{VariantType: VAR_MAP, VariantValue: func() *VariantMap { m, _ := NewVariantMap().Set(stringVariant("nope"), stringVariant("x")); return m }()}, target: &testCustomer{}, err: buildUnknownFieldError("nope", "golisp.testCustomer")},
+		{desc: "not a pointer", input: stringVariant("a"), target: s, err: buildInvalidTargetError("")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			e := test.input.ToGo(test.target)
+			if test.err != nil {
+				assert.Equal(t, test.err, e)
+			} else {
+				assert.Nil(t, e)
+				assert.Equal(t, test.expected, reflectDeref(test.target))
+			}
+		})
+	}
+}
+
+func reflectDeref(p interface{}) interface{} {
+	switch v := p.(type) {
+	case *int16:
+		return *v
+	case *float64:
+		return *v
+	case *string:
+		return *v
+	case *time.Duration:
+		return *v
+	case *interface{}:
+		return *v
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package golisp
+
+import "context"
+
+// Environment holds the functions and symbols that evaluations share. It can't be changed once it is built, so any
+// number of goroutines may evaluate expressions in it at the same time, and an expression parsed once may be evaluated
+// by all of them. Each evaluation gets a scope of its own, which is where its result and usage are kept.
+//
+// Bind and BindGoFunc return a new environment, copying only the scope they change, and Scope layers new symbols over
+// the environment without copying it at all.
+type Environment struct {
+	root *EvaluationContext
+}
+
+// Result is the value of an evaluation and the work it took.
+type Result struct {
+	Value Variant
+	Usage Usage
+}
+
+// NewEnvironment builds an environment from the default libraries and the options, which apply as they do to
+// NewEvaluationContext. Limits set with WithLimits apply to each evaluation separately.
+func NewEnvironment(options ...ContextOption) *Environment {
+	return &Environment{root: NewEvaluationContext(nil, options...)}
+}
+
+// Eval evaluates the expression and returns its value.
+func (env *Environment) Eval(expr SExpr) Variant {
+	return env.Evaluate(context.Background(), expr).Value
+}
+
+// Evaluate evaluates the expression until it finishes or the go context is done. See EvalContext.
+func (env *Environment) Evaluate(goContext context.Context, expr SExpr) Result {
+	ctx := NewEvaluationContext(env.root)
+	ctx.meter = newResourceMeter(env.root.meter.totals.limits)
+
+	value := EvalContext(goContext, expr, ctx)
+	return Result{Value: value, Usage: ctx.Usage()}
+}
+
+// Parse parses a script and checks it against the sandbox of the environment.
+func (env *Environment) Parse(s string) (SExpr, error) {
+	return env.root.Parse(s)
+}
+
+// Requirements reports the functions the script refers to and the capabilities it needs.
+func (env *Environment) Requirements(expr SExpr) Requirements {
+	return env.root.Requirements(expr)
+}
+
+// Scope returns an environment in which the symbols are bound over those of this one, such as the fields of the event
+// being processed. The symbols are copied, so the caller may reuse the table.
+func (env *Environment) Scope(symbols SymbolTable) *Environment {
+	scope := NewEvaluationContext(env.root)
+	for name, value := range symbols {
+		scope.SymbolTable[name] = value
+	}
+	return &Environment{root: scope}
+}
+
+// Bind returns an environment with the symbol bound, leaving this one unchanged.
+func (env *Environment) Bind(name string, value Variant) *Environment {
+	scope := env.copyScope()
+	scope.SymbolTable[name] = value
+	return &Environment{root: scope}
+}
+
+// BindGoFunc returns an environment with the go function bound, leaving this one unchanged. See RegisterGoFunc.
+func (env *Environment) BindGoFunc(functionName string, fn interface{}) (*Environment, error) {
+	scope := env.copyScope()
+	if e := scope.RegisterGoFunc(functionName, fn); e != nil {
+		return nil, e
+	}
+	return &Environment{root: scope}, nil
+}
+
+// copyScope copies the innermost scope of the environment so that it can be changed, sharing its parents.
+func (env *Environment) copyScope() *EvaluationContext {
+	scope := *env.root
+	scope.SymbolTable = SymbolTable{}
+	for name, value := range env.root.SymbolTable {
+		scope.SymbolTable[name] = value
+	}
+
+	scope.FunctionTable = FunctionTable{}
+	for name, f := range env.root.FunctionTable {
+		scope.FunctionTable[name] = f
+	}
+	return &scope
+}
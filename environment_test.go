@@ -0,0 +1,77 @@
+package golisp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvironmentConcurrentEvaluation(t *testing.T) {
+	env, e := NewEnvironment(WithLimits(Limits{MaxSteps: 20})).BindGoFunc("double", func(x int64) int64 { return x * 2 })
+	assert.Nil(t, e)
+	env = env.Bind("base", intVariant(1000))
+
+	sexpr, e := env.Parse(`(++ "event-" id ": " (+ base (reduce + 0 (map double (range 0 id)))))`)
+	assert.Nil(t, e)
+
+	const workers = 64
+	results := make([]Result, workers)
+	wg := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = env.Scope(SymbolTable{"id": intVariant(int64(i % 8))}).Evaluate(context.Background(), sexpr)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, r := range results {
+		id := int64(i % 8)
+		assert.Equal(t, stringVariant(fmt.Sprintf("event-%d: %d", id, 1000+id*(id-1))), r.Value)
+		assert.Equal(t, int64(5+2*id), r.Usage.Steps, "usage is counted per evaluation")
+	}
+}
+
+func TestEnvironmentCopyOnWrite(t *testing.T) {
+	env := NewEnvironment().Bind("x", intVariant(1))
+	rebound := env.Bind("x", intVariant(2))
+	scoped := env.Scope(SymbolTable{"y": intVariant(3)})
+
+	sexpr, _ := Parse("(+ x 10)")
+	assert.Equal(t, intVariant(11), env.Eval(sexpr))
+	assert.Equal(t, intVariant(12), rebound.Eval(sexpr))
+
+	sexpr, _ = Parse("(+ x y)")
+	assert.Equal(t, intVariant(4), scoped.Eval(sexpr))
+	assert.Equal(t, VAR_ERROR, env.Eval(sexpr).VariantType)
+
+	withFunc, e := env.BindGoFunc("answer", func() int64 { return 42 })
+	assert.Nil(t, e)
+
+	sexpr, _ = Parse("(answer)")
+	assert.Equal(t, intVariant(42), withFunc.Eval(sexpr))
+	assert.Equal(t, VAR_ERROR, env.Eval(sexpr).VariantType)
+}
+
+func TestEnvironmentLimitsApplyPerEvaluation(t *testing.T) {
+	env := NewEnvironment(WithLimits(Limits{MaxSteps: 2}))
+	sexpr, _ := Parse("(+ 1 (+ 2 3))")
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, intVariant(6), env.Eval(sexpr), "a previous evaluation doesn't use up the budget")
+	}
+}
+
+func TestEnvironmentSandbox(t *testing.T) {
+	env := NewEnvironment(WithSandbox(PureSandbox()))
+
+	_, e := env.Parse(`(json-parse "{}")`)
+	assert.Equal(t, buildFunctionNotPermittedError("json-parse", "pure"), e)
+
+	_, e = env.BindGoFunc("answer", func() int64 { return 42 })
+	assert.Equal(t, buildFunctionNotPermittedError("answer", "pure"), e)
+}
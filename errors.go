@@ -10,6 +10,26 @@ func buildUnexpectedCloseParenError() error {
 	return fmt.Errorf("parse error: unexpected close paren")
 }
 
+func buildUnexpectedCloseBraceError() error {
+	return fmt.Errorf("parse error: unexpected close brace")
+}
+
+func buildUnexpectedCloseBracketError() error {
+	return fmt.Errorf("parse error: unexpected close bracket")
+}
+
+func buildOddMapLiteralError() error {
+	return fmt.Errorf("parse error: map literal must have an even number of forms")
+}
+
+func buildInvalidNumberLiteralError(text string) error {
+	return fmt.Errorf("parse error: invalid number literal %q", text)
+}
+
+func buildInvalidKeywordLiteralError(text string) error {
+	return fmt.Errorf("parse error: invalid keyword literal %q", text)
+}
+
 func buildUnexpectedTrailingTextError() error {
 	return fmt.Errorf("parse error: unexpected trailing text")
 }
@@ -69,3 +89,175 @@ func buildDivideByZeroError() error {
 func buildGetPromotedNumberTypeReturnedInvalidType() error {
 	return fmt.Errorf("panic: getPromotedNumberType returned something other than VAR_INT and VAR_FLOAT")
 }
+
+func buildDuplicateNamespaceError(namespace string) error {
+	return fmt.Errorf("library error: namespace %q is already registered", namespace)
+}
+
+func buildFunctionNameCollisionError(functionName string, namespace string, existingNamespace string) error {
+	return fmt.Errorf("library error: function %q in namespace %q collides with namespace %q", functionName, namespace, existingNamespace)
+}
+
+func buildUnhashableTypeError(variantType EnumVariantType) error {
+	return fmt.Errorf("type error: variant of type %q cannot be used as a key", variantType)
+}
+
+func buildNotAFunctionError(functionName string, kind string) error {
+	return fmt.Errorf("host error: %q must be bound to a function, not %s", functionName, kind)
+}
+
+func buildUnsupportedReturnSignatureError(functionName string) error {
+	return fmt.Errorf("host error: %q must return nothing, a value, an error, or a value and an error", functionName)
+}
+
+func buildUnsupportedGoTypeError(goType string) error {
+	return fmt.Errorf("type error: go type %q has no variant representation", goType)
+}
+
+func buildGoFunctionPanicError(functionName string, recovered interface{}) error {
+	return fmt.Errorf("host error: %q panicked: %v", functionName, recovered)
+}
+
+func buildCyclicGoValueError(goType string) error {
+	return fmt.Errorf("type error: go value of type %q refers to itself", goType)
+}
+
+func buildIntegerOverflowError(value interface{}, goType string) error {
+	return fmt.Errorf("type error: value [%v] overflows go type %q", value, goType)
+}
+
+func buildArgumentError(index int, functionName string, err error) error {
+	return fmt.Errorf("argument %d of %q: %w", index, functionName, err)
+}
+
+func buildGoTypeError(variantType EnumVariantType, goType string) error {
+	return fmt.Errorf("type error: cannot represent variant of type %q as go type %q", variantType, goType)
+}
+
+func buildUnknownFieldError(fieldName string, goType string) error {
+	return fmt.Errorf("type error: go type %q has no field %q", goType, fieldName)
+}
+
+func buildArrayLengthError(length int, goType string) error {
+	return fmt.Errorf("type error: cannot represent %d elements as go type %q", length, goType)
+}
+
+func buildInvalidTargetError(target interface{}) error {
+	return fmt.Errorf("type error: target must be a non-nil pointer, not %T", target)
+}
+
+func buildUnresolvedPropertyError(path string, property string) error {
+	return fmt.Errorf("scope error: unresolved property %q in %q", property, path)
+}
+
+func buildJSONTrailingDataError() error {
+	return fmt.Errorf("json error: unexpected data after the top-level value")
+}
+
+func buildJSONFunctionError() error {
+	return fmt.Errorf("json error: functions cannot be decoded")
+}
+
+func buildInvalidJSONPathError(path string) error {
+	return fmt.Errorf("json error: invalid path %q", path)
+}
+
+func buildEvenArityError(functionName string) error {
+	return fmt.Errorf("arity error: expected an even number of key/value arguments for %q", functionName)
+}
+
+func buildInvalidKeywordError(name string) error {
+	return fmt.Errorf("type error: %q is not a valid keyword name", name)
+}
+
+func buildIndexOutOfRangeError(index int64, length int, functionName string) error {
+	return fmt.Errorf("index error: index %d is out of range for length %d in %q", index, length, functionName)
+}
+
+func buildIncomparableTypesError(left EnumVariantType, right EnumVariantType) error {
+	return fmt.Errorf("type error: cannot compare variants of type %q and %q", left, right)
+}
+
+func buildNonPositiveArgumentError(value int64, functionName string) error {
+	return fmt.Errorf("argument error: expected a positive integer for %q, got %d", functionName, value)
+}
+
+func buildZeroStepError(functionName string) error {
+	return fmt.Errorf("argument error: step must not be zero for %q", functionName)
+}
+
+func buildNegativeArgumentError(value int64, functionName string) error {
+	return fmt.Errorf("argument error: expected a non-negative integer for %q, got %d", functionName, value)
+}
+
+func buildEmptyStringArgumentError(functionName string) error {
+	return fmt.Errorf("argument error: expected a non-empty string for %q", functionName)
+}
+
+func buildEmptyCollectionArgumentError(functionName string) error {
+	return fmt.Errorf("argument error: expected a non-empty collection for %q", functionName)
+}
+
+func buildInvalidRegexError(pattern string, err error) error {
+	return fmt.Errorf("regex error: invalid pattern %q: %v", pattern, err)
+}
+
+func buildUnknownLocaleError(locale string) error {
+	return fmt.Errorf("format error: unknown locale %q", locale)
+}
+
+func buildInvalidFormatError(format string) error {
+	return fmt.Errorf("format error: invalid format %q", format)
+}
+
+func buildFormatArgumentCountError(verbs int, args int) error {
+	return fmt.Errorf("format error: the format has %d verbs for %d arguments", verbs, args)
+}
+
+func buildFormatVerbError(verb rune, index int, variantType EnumVariantType) error {
+	return fmt.Errorf("format error: %%%c cannot format argument %d of type %q", verb, index, variantType.String())
+}
+
+func buildInvalidInterpolationError(text string) error {
+	return fmt.Errorf("parse error: invalid interpolated string %q", text)
+}
+
+func buildUnknownRoundingModeError(mode string) error {
+	return fmt.Errorf("math error: unknown rounding mode %q", mode)
+}
+
+func buildInvalidRangeError(functionName string) error {
+	return fmt.Errorf("math error: lower bound is greater than upper bound in %q", functionName)
+}
+
+func buildBitPositionError(position int64, functionName string) error {
+	return fmt.Errorf("math error: bit position %d is out of range 0-63 in %q", position, functionName)
+}
+
+func buildEvaluationCancelledError(cause error) error {
+	return fmt.Errorf("evaluation error: evaluation cancelled: %w", cause)
+}
+
+func buildLimitExceededError(limit string, value int64, max int64) error {
+	return fmt.Errorf("%w: %s reached %d, over the limit of %d", errLimitExceeded, limit, value, max)
+}
+
+func buildValueTooLargeError(length int64, functionName string) error {
+	return fmt.Errorf("argument error: %q cannot build a value of length %d", functionName, length)
+}
+
+func buildFunctionNotPermittedError(functionName string, sandbox string) error {
+	return fmt.Errorf("sandbox error: function %q is not permitted by the %q sandbox", functionName, sandbox)
+}
+
+func buildFunctionPropertyNotPermittedError(property string, sandbox string) error {
+	return fmt.Errorf("sandbox error: function in property %q is not permitted by the %q sandbox", property, sandbox)
+}
+
+func buildFuturePanicError(recovered interface{}) error {
+	return fmt.Errorf("async error: function panicked: %v", recovered)
+}
+
+func buildClosedChannelError(functionName string) error {
+	return fmt.Errorf("async error: channel is closed in %q", functionName)
+}
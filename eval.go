@@ -1,18 +1,46 @@
 package golisp
 
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+const propertySeparator = "."
+
 type FunctionType func([]Variant) Variant
 type FunctionTable map[string]FunctionType
 
 type SymbolTable map[string]Variant
 
+// EvaluationContext holds the bindings of a scope and the state of the evaluation running in it. It isn't safe for
+// concurrent use: evaluating writes the usage counters, and the tables are plain maps. To evaluate in parallel, share an
+// Environment instead, which gives each evaluation a context of its own.
 type EvaluationContext struct {
+	// EvaluatedValue is the value of the last expression evaluated with EvalContext or EvalWithTimeout.
+	//
+	// Deprecated: use the value that they return. Expressions evaluated with SExpr.Eval don't set it.
 	EvaluatedValue Variant
 	Parent         *EvaluationContext
 	FunctionTable  FunctionTable
 	SymbolTable    SymbolTable
+
+	// goContext is checked for cancellation during EvalContext, and is shared with child contexts.
+	goContext context.Context
+
+	// meter counts the work done against the Limits, and is shared with child contexts.
+	meter *resourceMeter
+
+	// registry records which library each function came from, and sandbox restricts the functions that may be called.
+	registry *LibraryRegistry
+	sandbox  *Sandbox
+
+	// failures records where calls fail when the evaluation is diagnosed, and is shared with child contexts.
+	failures *failureLog
 }
 
-func (ctx *EvaluationContext) resolveIdentifier(identifierName string) Variant {
+func (ctx *EvaluationContext) lookupIdentifier(identifierName string) Variant {
 	if ctx == nil {
 		return Variant{VariantType: VAR_ERROR, VariantValue: buildUnresolvedIdentifierError(identifierName)}
 	}
@@ -22,74 +50,361 @@ func (ctx *EvaluationContext) resolveIdentifier(identifierName string) Variant {
 	if v, e := ctx.FunctionTable[identifierName]; e {
 		return Variant{VariantType: VAR_FUNCTION, VariantValue: v}
 	}
-	return ctx.Parent.resolveIdentifier(identifierName)
+	return ctx.Parent.lookupIdentifier(identifierName)
+}
+
+// resolveIdentifier looks the identifier up through the chain of contexts.
+// A dotted identifier like "order.customer.country" that isn't bound as-is walks the properties of its first segment,
+// and a function it finds there may only be called if the sandbox permits host functions.
+// A library function that needs the context evaluating it is bound to this one.
+func (ctx *EvaluationContext) resolveIdentifier(identifierName string) Variant {
+	v := ctx.lookupIdentifier(identifierName)
+	if v.VariantType == VAR_FUNCTION && ctx.sandbox != nil && !ctx.sandbox.permits(identifierName, ctx.capabilityOf(identifierName)) {
+		// a child context may have a stricter sandbox than the parent that provides the function
+		return Variant{VariantType: VAR_ERROR, VariantValue: buildFunctionNotPermittedError(identifierName, ctx.sandbox.Name)}
+	}
+	if v.VariantType == VAR_FUNCTION && ctx.registry != nil && !ctx.isSymbol(identifierName) {
+		if f, found := ctx.registry.contextFunction(identifierName); found {
+			return Variant{VariantType: VAR_FUNCTION, VariantValue: FunctionType(func(args []Variant) Variant { return f(ctx, args) })}
+		}
+	}
+	if v.VariantType != VAR_ERROR || !strings.Contains(identifierName, propertySeparator) {
+		return v
+	}
+
+	path := strings.Split(identifierName, propertySeparator)
+	if v = ctx.lookupIdentifier(path[0]); v.VariantType == VAR_ERROR {
+		return v
+	}
+
+	for _, segment := range path[1:] {
+		r, found, e := getPathSegment(v, segment)
+		if e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		if !found {
+			return Variant{VariantType: VAR_ERROR, VariantValue: buildUnresolvedPropertyError(identifierName, segment)}
+		}
+		v = r
+	}
+
+	if e := ctx.checkFunctionProperty(identifierName, v); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return v
 }
 
-func loadDefaultLibraries(functions FunctionTable) FunctionTable {
-	functions = (&ArithmeticLibrary{}).InjectFunctions(functions)
-	functions = (&LogicalLibrary{}).InjectFunctions(functions)
-	functions = (&StringLibrary{}).InjectFunctions(functions)
-	return functions
+func loadDefaultLibraries() *LibraryRegistry {
+	registry, e := NewLibraryRegistry(DefaultLibraries()...)
+	if e != nil {
+		panic(e)
+	}
+	return registry
 }
 
 func loadDefaultSymbols(symbols SymbolTable) SymbolTable {
 	return symbols
 }
 
-func NewEvaluationContext(parent *EvaluationContext) *EvaluationContext {
-	return &EvaluationContext{
+// ContextOption configures an EvaluationContext when it is created.
+type ContextOption func(*EvaluationContext)
+
+// WithLibraries replaces the default libraries with the given ones.
+// It panics if two libraries collide; use NewLibraryRegistry and WithLibraryRegistry to handle that as an error.
+func WithLibraries(libraries ...FunctionLibrary) ContextOption {
+	registry, e := NewLibraryRegistry(libraries...)
+	if e != nil {
+		panic(e)
+	}
+	return WithLibraryRegistry(registry)
+}
+
+func WithLibraryRegistry(registry *LibraryRegistry) ContextOption {
+	return func(ctx *EvaluationContext) {
+		ctx.FunctionTable = registry.InjectFunctions(FunctionTable{})
+		ctx.registry = registry
+	}
+}
+
+// NewEvaluationContext creates a context with the default libraries loaded.
+// A context with a parent resolves functions through the parent, so no libraries are loaded into it unless requested.
+func NewEvaluationContext(parent *EvaluationContext, options ...ContextOption) *EvaluationContext {
+	functions := FunctionTable{}
+	var registry *LibraryRegistry
+	if parent == nil {
+		registry = loadDefaultLibraries()
+		functions = registry.InjectFunctions(functions)
+	}
+
+	ctx := &EvaluationContext{
 		Parent:         parent,
-		FunctionTable:  loadDefaultLibraries(FunctionTable{}),
+		FunctionTable:  functions,
 		SymbolTable:    loadDefaultSymbols(SymbolTable{}),
 		EvaluatedValue: Variant{VariantType: VAR_UNKNOWN},
+		registry:       registry,
+	}
+
+	if parent != nil {
+		ctx.goContext = parent.goContext
+		ctx.meter = parent.meter
+		ctx.registry = parent.registry
+		ctx.sandbox = parent.sandbox
+		ctx.failures = parent.failures
+	} else {
+		ctx.meter = newResourceMeter(Limits{})
 	}
-}
 
-func (p *null) Eval(ctx *EvaluationContext) *EvaluationContext {
-	ctx.EvaluatedValue = Variant{VariantType: VAR_NULL, VariantValue: nil}
+	for _, option := range options {
+		option(ctx)
+	}
+
+	ctx.applySandbox()
 	return ctx
 }
 
-func (p *atom) Eval(ctx *EvaluationContext) *EvaluationContext {
+// EvalContext evaluates the expression, stopping with a cancellation error once the go context is cancelled or its
+// deadline passes. The go context is checked before every function call, including the calls that libraries make to
+// functions passed to them, such as the predicate of filter. A go function that is already running isn't interrupted.
+//
+// Futures started by the evaluation are cancelled when it returns.
+func EvalContext(goContext context.Context, expr SExpr, ctx *EvaluationContext) Variant {
+	goContext, cancel := context.WithCancel(goContext)
+	defer cancel()
+
+	previous := ctx.goContext
+	ctx.goContext = goContext
+	defer func() { ctx.goContext = previous }()
+
+	result := ctx.cancelledOr(func() Variant { return expr.Eval(ctx) })
+	ctx.EvaluatedValue = result
+	return result
+}
+
+// cancelledOr evaluates unless the evaluation has been cancelled already. As a library may have reported the failure
+// of a cancelled call as an error of its own, an error after cancellation is replaced by the cancellation.
+func (ctx *EvaluationContext) cancelledOr(evaluate func() Variant) Variant {
+	if e := ctx.cancellation(); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	result := evaluate()
+	if e := ctx.cancellation(); e != nil && result.VariantType == VAR_ERROR {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return result
+}
+
+// EvalWithTimeout evaluates the expression with EvalContext, giving it the timeout to finish.
+func EvalWithTimeout(expr SExpr, ctx *EvaluationContext, timeout time.Duration) Variant {
+	goContext, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return EvalContext(goContext, expr, ctx)
+}
+
+// IsCancellation reports whether the variant is the error of an evaluation that was cancelled or ran out of time.
+func IsCancellation(v Variant) bool {
+	if v.VariantType != VAR_ERROR {
+		return false
+	}
+
+	e, ok := v.VariantValue.(error)
+	return ok && (errors.Is(e, context.Canceled) || errors.Is(e, context.DeadlineExceeded))
+}
+
+func (ctx *EvaluationContext) cancellation() error {
+	if ctx == nil || ctx.goContext == nil {
+		return nil
+	}
+
+	if e := ctx.goContext.Err(); e != nil {
+		return buildEvaluationCancelledError(e)
+	}
+	return nil
+}
+
+// invoke calls a function, checking for cancellation and counting the call and its result against the limits.
+func (ctx *EvaluationContext) invoke(function FunctionType, args []Variant) Variant {
+	if e := ctx.cancellation(); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ctx.meter.step(); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	return ctx.accounted(function(args))
+}
+
+// accounted counts a value that the evaluation has built against the limits.
+func (ctx *EvaluationContext) accounted(v Variant) Variant {
+	if e := ctx.meter.account(v); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return v
+}
+
+// guardFunction makes a function go through invoke when it is called, so that libraries calling it in a loop stop
+// when the evaluation is cancelled or runs out of budget.
+func (ctx *EvaluationContext) guardFunction(f Variant) Variant {
+	function, ok := f.VariantValue.(FunctionType)
+	if !ok {
+		return f
+	}
+
+	// libraries such as pmap may call the function from several goroutines at once, so the depth of the call is
+	// checked without changing the depth of the context
+	guarded := func(args []Variant) Variant {
+		if e := ctx.meter.totals.checkDepth(ctx.meter.depth + 1); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		return ctx.invoke(function, args)
+	}
+	return Variant{VariantType: VAR_FUNCTION, VariantValue: FunctionType(guarded)}
+}
+
+func (p *null) Eval(ctx *EvaluationContext) Variant {
+	return Variant{VariantType: VAR_NULL, VariantValue: nil}
+}
+
+func (p *atom) Eval(ctx *EvaluationContext) Variant {
 	switch p.typedValue.VariantType {
 	case VAR_IDENT:
-		if v, e := p.typedValue.GetIdentifierValue(); e != nil {
-			ctx.EvaluatedValue = Variant{VariantType: VAR_ERROR, VariantValue: e}
-		} else {
-			ctx.EvaluatedValue = ctx.resolveIdentifier(v)
+		v, e := p.typedValue.GetIdentifierValue()
+		if e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
 		}
+		return ctx.resolveIdentifier(v)
 	default:
-		ctx.EvaluatedValue = p.typedValue.MakeConsistent()
+		return p.typedValue.MakeConsistent()
 	}
-	return ctx
 }
 
-func (p *list) Eval(ctx *EvaluationContext) *EvaluationContext {
+func (p *list) Eval(ctx *EvaluationContext) Variant {
 	if len(p.children) == 0 {
-		ctx.EvaluatedValue = Variant{VariantType: VAR_NULL}
-		return ctx
+		return Variant{VariantType: VAR_NULL}
+	}
+
+	if e := ctx.cancellation(); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	defer ctx.meter.ascend()
+	if e := ctx.meter.descend(); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
 	}
 
-	v := p.children[0].Eval(ctx).EvaluatedValue
+	v := p.children[0].Eval(ctx)
+
+	if form := ctx.asyncForm(p.children[0]); v.VariantType == VAR_FUNCTION && form != nil {
+		if e := ctx.meter.step(); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		return form(ctx, p.children[1:])
+	}
 
 	switch v.VariantType {
 	case VAR_FUNCTION:
 		functionArgs := []Variant{}
 
 		for _, v := range p.children[1:] {
-			switch v.(type) {
-			case *null, *atom:
-				functionArgs = append(functionArgs, v.Eval(ctx).EvaluatedValue)
-			case *list:
-				functionArgs = append(functionArgs, v.Eval(NewEvaluationContext(ctx)).EvaluatedValue)
+			arg := evalArgument(ctx, v)
+			if arg.VariantType == VAR_FUNCTION {
+				arg = ctx.guardFunction(arg)
 			}
+			functionArgs = append(functionArgs, arg)
 		}
 
 		function := v.VariantValue.(FunctionType)
-		ctx.EvaluatedValue = function(functionArgs)
+		result := ctx.invoke(function, functionArgs)
+		ctx.failures.record(p, result, functionArgs)
+		return result
 	default:
-		ctx.EvaluatedValue = Variant{VariantType: VAR_ERROR, VariantValue: buildFunctionNameNotFoundError(v.ToDebugString())}
+		result := Variant{VariantType: VAR_ERROR, VariantValue: buildFunctionNameNotFoundError(v.ToDebugString())}
+		ctx.failures.record(p, result, nil)
+		return result
 	}
+}
 
-	return ctx
+func evalArgument(ctx *EvaluationContext, arg SExpr) Variant {
+	switch arg.(type) {
+	case *list:
+		return arg.Eval(NewEvaluationContext(ctx))
+	default:
+		return arg.Eval(ctx)
+	}
+}
+
+func (p *mapLiteral) Eval(ctx *EvaluationContext) Variant {
+	m := NewVariantMap()
+
+	for i := 0; i+1 < len(p.children); i += 2 {
+		k := evalArgument(ctx, p.children[i])
+		if e := ensureTypeIsNotInvalid(k); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+
+		v := evalArgument(ctx, p.children[i+1])
+		if e := ensureTypeIsNotInvalid(v); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+
+		if e := m.put(k, v); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+	}
+
+	return ctx.accounted(Variant{VariantType: VAR_MAP, VariantValue: m})
+}
+
+func (p *vectorLiteral) Eval(ctx *EvaluationContext) Variant {
+	items := make([]Variant, len(p.children))
+
+	for i, c := range p.children {
+		items[i] = evalArgument(ctx, c)
+		if e := ensureTypeIsNotInvalid(items[i]); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+	}
+
+	return ctx.accounted(Variant{VariantType: VAR_VECTOR, VariantValue: items})
+}
+
+// Eval builds a set from the evaluated items, with duplicates collapsed.
+func (p *setLiteral) Eval(ctx *EvaluationContext) Variant {
+	s := NewVariantSet()
+
+	for _, c := range p.children {
+		item := evalArgument(ctx, c)
+		if e := ensureTypeIsNotInvalid(item); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+
+		if e := s.put(item); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+	}
+
+	return ctx.accounted(Variant{VariantType: VAR_SET, VariantValue: s})
+}
+
+// Eval evaluates each embedded expression and joins the results as display strings, once it has checked the length of
+// the joined string against the limits.
+func (p *interpolatedString) Eval(ctx *EvaluationContext) Variant {
+	pieces := make([]string, len(p.parts))
+	length := int64(0)
+
+	for i, part := range p.parts {
+		s, e := displayString(evalArgument(ctx, part))
+		if e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		pieces[i] = s
+		length += int64(len(s))
+	}
+
+	if e := ctx.ensureStringFits(length, "string interpolation"); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return ctx.accounted(Variant{VariantType: VAR_STRING, VariantValue: strings.Join(pieces, "")})
 }
@@ -1,7 +1,10 @@
 package golisp
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -57,8 +60,83 @@ func TestEval(t *testing.T) {
 			sexpr, e := Parse(test.input)
 			assert.Nil(t, e, "parse error")
 
-			ctx := sexpr.Eval(context)
-			assert.Equal(t, test.expected, ctx.EvaluatedValue)
+			actual := sexpr.Eval(context)
+			assert.Equal(t, test.expected, actual)
 		})
 	}
 }
+
+func TestEvalContext(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	t.Run("completes without cancellation", func(t *testing.T) {
+		sexpr, _ := Parse("(+ 1 (* 2 3))")
+		actual := EvalContext(context.Background(), sexpr, NewEvaluationContext(nil))
+		assert.Equal(t, intVariant(7), actual)
+	})
+
+	t.Run("cancelled before evaluation", func(t *testing.T) {
+		sexpr, _ := Parse("(+ 1 2)")
+		actual := EvalContext(cancelled, sexpr, NewEvaluationContext(nil))
+		assert.Equal(t, Variant{VariantType: VAR_ERROR, VariantValue: buildEvaluationCancelledError(context.Canceled)}, actual)
+		assert.True(t, IsCancellation(actual))
+	})
+
+	t.Run("cancelled during evaluation", func(t *testing.T) {
+		goContext, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		evaluationContext := NewEvaluationContext(nil)
+		calls := 0
+		_ = evaluationContext.RegisterGoFunc("tick", func(i int64) int64 {
+			calls++
+			if calls == 2 {
+				cancel()
+			}
+			return i
+		})
+
+		sexpr, _ := Parse("(map tick (range 1 100))")
+		actual := EvalContext(goContext, sexpr, evaluationContext)
+		assert.True(t, IsCancellation(actual))
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		evaluationContext := NewEvaluationContext(nil)
+		_ = evaluationContext.RegisterGoFunc("slow", func(i int64) int64 {
+			time.Sleep(5 * time.Millisecond)
+			return i
+		})
+
+		sexpr, _ := Parse("(map slow (range 1 100))")
+		actual := EvalWithTimeout(sexpr, evaluationContext, 20*time.Millisecond)
+		assert.True(t, IsCancellation(actual))
+
+		e, _ := actual.GetErrorValue()
+		assert.True(t, errors.Is(e, context.DeadlineExceeded))
+	})
+
+	t.Run("context is restored", func(t *testing.T) {
+		evaluationContext := NewEvaluationContext(nil)
+		sexpr, _ := Parse("(+ 1 2)")
+		EvalContext(cancelled, sexpr, evaluationContext)
+
+		actual := sexpr.Eval(evaluationContext)
+		assert.Equal(t, intVariant(3), actual)
+	})
+
+	t.Run("the deprecated EvaluatedValue is still set", func(t *testing.T) {
+		evaluationContext := NewEvaluationContext(nil)
+		sexpr, _ := Parse("(+ 1 2)")
+		EvalContext(context.Background(), sexpr, evaluationContext)
+		assert.Equal(t, intVariant(3), evaluationContext.EvaluatedValue)
+	})
+
+	t.Run("ordinary errors are not cancellations", func(t *testing.T) {
+		sexpr, _ := Parse("(/ 1 0)")
+		actual := EvalContext(context.Background(), sexpr, NewEvaluationContext(nil))
+		assert.False(t, IsCancellation(actual))
+	})
+}
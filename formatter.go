@@ -0,0 +1,239 @@
+package golisp
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	defaultLineWidth   = 80
+	defaultIndentWidth = 2
+)
+
+// defaultSpecialForms are the forms whose arguments are laid out as a body, mapped to the number of arguments that stay
+// on the line of the form's name.
+var defaultSpecialForms = map[string]int{
+	"future": 0,
+}
+
+// FormatOption configures Format.
+type FormatOption func(*formatter)
+
+// WithLineWidth sets the width that Format fits forms into where it can. Atoms and comments are never split, so a
+// line may still be longer.
+func WithLineWidth(width int) FormatOption {
+	return func(f *formatter) {
+		f.lineWidth = width
+	}
+}
+
+// WithIndentWidth sets the number of spaces that the body of a form is indented by.
+func WithIndentWidth(width int) FormatOption {
+	return func(f *formatter) {
+		f.indentWidth = width
+	}
+}
+
+// WithSpecialForm lays out the arguments of the named form as a body, indented under the name, keeping the first
+// distinguished arguments on the line of the name. Other lists that don't fit on a line are laid out as calls, with
+// their arguments aligned under the first.
+func WithSpecialForm(name string, distinguished int) FormatOption {
+	return func(f *formatter) {
+		f.specialForms[name] = distinguished
+	}
+}
+
+type formatter struct {
+	lineWidth    int
+	indentWidth  int
+	specialForms map[string]int
+}
+
+// layoutWriter builds the formatted text, tracking the column that the next text is written at.
+type layoutWriter struct {
+	strings.Builder
+	col int
+}
+
+func (w *layoutWriter) write(s string) {
+	w.WriteString(s)
+	if i := strings.LastIndex(s, "\n"); i >= 0 {
+		w.col = textWidth(s[i+1:])
+	} else {
+		w.col += textWidth(s)
+	}
+}
+
+// newline starts a line indented to the column, after a blank line if there was one in the source.
+func (w *layoutWriter) newline(indent int, blank bool) {
+	if blank {
+		w.WriteString("\n")
+	}
+	w.write("\n" + strings.Repeat(" ", indent))
+}
+
+func textWidth(s string) int {
+	return utf8.RuneCountInString(s)
+}
+
+// Format lays a script out in the canonical style: a form that fits on its line is written on it, and one that
+// doesn't is broken with its arguments one to a line, aligned under the first or indented as a body. Comments are kept
+// where they were, on their own lines or after the form they followed, as are blank lines between forms.
+// Formatting a script that is already formatted leaves it unchanged.
+func Format(src string, options ...FormatOption) (string, error) {
+	if _, e := ParseAll(src); e != nil {
+		return "", e
+	}
+
+	nodes, e := parseSyntax(src)
+	if e != nil {
+		return "", e
+	}
+
+	f := &formatter{lineWidth: defaultLineWidth, indentWidth: defaultIndentWidth, specialForms: map[string]int{}}
+	for name, distinguished := range defaultSpecialForms {
+		f.specialForms[name] = distinguished
+	}
+	for _, option := range options {
+		option(f)
+	}
+
+	w := &layoutWriter{}
+	for i, node := range nodes {
+		if i > 0 {
+			if node.kind == syntaxComment && node.linesBefore == 0 {
+				w.write(" ")
+			} else {
+				w.newline(0, node.linesBefore > 1)
+			}
+		}
+		f.layout(w, node)
+	}
+
+	if len(nodes) > 0 {
+		w.write("\n")
+	}
+	return w.String(), nil
+}
+
+func (f *formatter) layout(w *layoutWriter, node *syntaxNode) {
+	if !node.isCollection() {
+		w.write(node.text)
+		return
+	}
+
+	if s, ok := f.flat(node); ok && w.col+textWidth(s) <= f.lineWidth {
+		w.write(s)
+		return
+	}
+
+	col := w.col
+	open, close := node.brackets()
+	w.write(open)
+
+	items := node.children
+	switch {
+	case len(items) == 0:
+
+	case node.kind == syntaxMap:
+		f.layoutPairs(w, items, col+textWidth(open))
+
+	case node.kind == syntaxVector || node.kind == syntaxSet:
+		f.layout(w, items[0])
+		f.layoutFilled(w, items[1:], col+textWidth(open))
+
+	case node.kind == syntaxList && items[0].kind == syntaxAtom:
+		head, args := items[0], items[1:]
+		w.write(head.text)
+
+		if distinguished, special := f.specialForms[head.text]; special {
+			f.layoutItems(w, args, distinguished, col+f.indentWidth)
+		} else if len(args) > 0 && f.fits(args[0], w.col+1) {
+			f.layoutItems(w, args, 1, w.col+1)
+		} else {
+			f.layoutItems(w, args, 0, col+f.indentWidth)
+		}
+
+	default:
+		f.layout(w, items[0])
+		f.layoutItems(w, items[1:], 0, col+textWidth(open))
+	}
+
+	w.write(close)
+}
+
+// layoutItems writes the first inline items on the current line, and each of the others on a line of its own.
+func (f *formatter) layoutItems(w *layoutWriter, items []*syntaxNode, inline int, indent int) {
+	for i, item := range items {
+		if i < inline || f.isTrailingComment(item) {
+			w.write(" ")
+		} else {
+			w.newline(indent, item.linesBefore > 1)
+		}
+		f.layout(w, item)
+	}
+}
+
+// layoutFilled writes as many of the items on each line as fit, as suits the elements of vectors and sets.
+func (f *formatter) layoutFilled(w *layoutWriter, items []*syntaxNode, indent int) {
+	for _, item := range items {
+		if f.isTrailingComment(item) || (item.linesBefore <= 1 && f.fits(item, w.col+1)) {
+			w.write(" ")
+		} else {
+			w.newline(indent, item.linesBefore > 1)
+		}
+		f.layout(w, item)
+	}
+}
+
+// layoutPairs writes each key of a map on a line of its own, followed by its value.
+func (f *formatter) layoutPairs(w *layoutWriter, items []*syntaxNode, indent int) {
+	forms := 0
+	for i, item := range items {
+		switch {
+		case i == 0:
+		case f.isTrailingComment(item), item.kind != syntaxComment && forms%2 == 1:
+			w.write(" ")
+		default:
+			w.newline(indent, item.linesBefore > 1)
+		}
+		f.layout(w, item)
+
+		if item.kind != syntaxComment {
+			forms++
+		}
+	}
+}
+
+// isTrailingComment reports whether the item is a comment written on the same line as the form before it.
+func (f *formatter) isTrailingComment(item *syntaxNode) bool {
+	return item.kind == syntaxComment && item.linesBefore == 0
+}
+
+// fits reports whether the node can be written on one line starting at the column.
+func (f *formatter) fits(node *syntaxNode, col int) bool {
+	s, ok := f.flat(node)
+	return ok && col+textWidth(s) <= f.lineWidth
+}
+
+// flat writes the node on one line, which can't be done if it contains a comment or a string with a line break.
+func (f *formatter) flat(node *syntaxNode) (string, bool) {
+	switch {
+	case node.kind == syntaxComment:
+		return "", false
+	case !node.isCollection():
+		return node.text, !strings.Contains(node.text, "\n")
+	}
+
+	parts := make([]string, 0, len(node.children))
+	for _, child := range node.children {
+		s, ok := f.flat(child)
+		if !ok {
+			return "", false
+		}
+		parts = append(parts, s)
+	}
+
+	open, close := node.brackets()
+	return open + strings.Join(parts, " ") + close, true
+}
@@ -0,0 +1,116 @@
+package golisp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatter(t *testing.T) {
+	tests := [...]struct {
+		desc     string
+		input    string
+		options  []FormatOption
+		expected string
+	}{
+		{desc: "empty script", input: "  \n", expected: ""},
+		{desc: "normalizes spacing", input: "  (+   1\n 2 )", expected: "(+ 1 2)\n"},
+		{desc: "keeps literals as written", input: `[:a 0x1F  #{ 1 } { "s" 1_000 } $"{ (f x) }"]`, expected: "[:a 0x1F #{1} {\"s\" 1_000} $\"{ (f x) }\"]\n"},
+		{desc: "one form to a line", input: "(f 1) (g 2)", expected: "(f 1)\n(g 2)\n"},
+		{desc: "keeps blank lines between forms", input: "(f 1)\n\n\n\n(g 2)", expected: "(f 1)\n\n(g 2)\n"},
+		{desc: "keeps comments", input: "(* rules *)\n(f 1) (* why *)\n(* next *) (g 2)", expected: "(* rules *)\n(f 1) (* why *)\n(* next *)\n(g 2)\n"},
+		{
+			desc:     "aligns arguments under the first",
+			input:    "(filter (within-range x 1 10) (map normalize-amount [12.125 3.1415 100]))",
+			options:  []FormatOption{WithLineWidth(40)},
+			expected: "(filter (within-range x 1 10)\n        (map normalize-amount\n             [12.125 3.1415 100]))\n",
+		},
+		{
+			desc:     "indents arguments that don't fit after the name",
+			input:    "(concat \"a long string that can't be aligned\" \"b\")",
+			options:  []FormatOption{WithLineWidth(40)},
+			expected: "(concat\n  \"a long string that can't be aligned\"\n  \"b\")\n",
+		},
+		{
+			desc:     "indents the body of special forms",
+			input:    "(future (reduce + 0 (map abs values)))",
+			options:  []FormatOption{WithLineWidth(34)},
+			expected: "(future\n  (reduce + 0 (map abs values)))\n",
+		},
+		{
+			desc:     "configured special forms and indent",
+			input:    "(when-valid order (approve order) (notify order))",
+			options:  []FormatOption{WithLineWidth(30), WithIndentWidth(4), WithSpecialForm("when-valid", 1)},
+			expected: "(when-valid order\n    (approve order)\n    (notify order))\n",
+		},
+		{
+			desc:     "puts map entries on lines of their own",
+			input:    "{:name \"Ada\" :address {:city \"London\" :zip 12345}}",
+			options:  []FormatOption{WithLineWidth(30)},
+			expected: "{:name \"Ada\"\n :address {:city \"London\"\n           :zip 12345}}\n",
+		},
+		{
+			desc:     "fills vectors",
+			input:    "[1 2 3 4 5 6 7 8 9 10 11 12 13 14 15]",
+			options:  []FormatOption{WithLineWidth(20)},
+			expected: "[1 2 3 4 5 6 7 8 9\n 10 11 12 13 14 15]\n",
+		},
+		{
+			desc:     "comments break the form they are in",
+			input:    "(f a (* first *)\n b\n (* before c *)\n c)",
+			expected: "(f a (* first *)\n   b\n   (* before c *)\n   c)\n",
+		},
+		{
+			desc:     "comments between map entries",
+			input:    "{:a 1 (* a *)\n (* b *)\n :b 2}",
+			expected: "{:a 1 (* a *)\n (* b *)\n :b 2}\n",
+		},
+		{desc: "strings with line breaks are kept", input: "(concat \"a\nb\" \"c\")", expected: "(concat\n  \"a\nb\"\n  \"c\")\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			actual, e := Format(test.input, test.options...)
+			assert.Nil(t, e)
+			assert.Equal(t, test.expected, actual)
+
+			again, e := Format(actual, test.options...)
+			assert.Nil(t, e)
+			assert.Equal(t, actual, again, "formatting should be idempotent")
+		})
+	}
+}
+
+func TestFormatterErrors(t *testing.T) {
+	tests := [...]struct {
+		input    string
+		expected error
+	}{
+		{input: "(f 1", expected: buildUnexpectedEndOfStringError()},
+		{input: "(f 1))", expected: buildUnexpectedCloseParenError()},
+		{input: "(f [1)]", expected: buildUnexpectedCloseParenError()},
+		{input: "{:a}", expected: buildOddMapLiteralError()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			_, e := Format(test.input)
+			assert.Equal(t, test.expected, e)
+		})
+	}
+}
+
+func TestParseSyntax(t *testing.T) {
+	nodes, e := parseSyntax("  (f (* c *)\n\n [a]) b")
+	assert.Nil(t, e)
+	assert.Equal(t, 2, len(nodes))
+
+	f := nodes[0]
+	assert.Equal(t, syntaxList, f.kind)
+	assert.Equal(t, span{start: Position{Offset: 2, Line: 1, Column: 3}, end: Position{Offset: 19, Line: 3, Column: 6}}, f.span)
+	assert.Equal(t, 3, len(f.children))
+	assert.Equal(t, &syntaxNode{kind: syntaxComment, text: "(* c *)", span: span{start: Position{Offset: 5, Line: 1, Column: 6}, end: Position{Offset: 12, Line: 1, Column: 13}}}, f.children[1])
+	assert.Equal(t, syntaxVector, f.children[2].kind)
+	assert.Equal(t, 2, f.children[2].linesBefore)
+	assert.Equal(t, &syntaxNode{kind: syntaxAtom, text: "b", span: span{start: Position{Offset: 20, Line: 3, Column: 7}, end: Position{Offset: 21, Line: 3, Column: 8}}}, nodes[1])
+}
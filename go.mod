@@ -3,6 +3,7 @@ module github.com/johnazariah/golisp
 go 1.16
 
 require (
-	github.com/araddon/dateparse v0.0.0-20210429162001-6b43995a97de // indirect
+	github.com/araddon/dateparse v0.0.0-20210429162001-6b43995a97de
+	github.com/peterh/liner v1.2.2
 	github.com/stretchr/testify v1.7.0
 )
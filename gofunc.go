@@ -0,0 +1,128 @@
+package golisp
+
+import (
+	"reflect"
+)
+
+// WrapGoFunc adapts an arbitrary go function into a FunctionType.
+// Arguments are converted from variants to the go parameter types, and the results are converted back.
+// The function may return nothing, a value, an error, or a value and an error. If it panics, the call returns an error
+// instead of crashing the host.
+func WrapGoFunc(functionName string, fn interface{}) (FunctionType, error) {
+	v := reflect.ValueOf(fn)
+	if !v.IsValid() {
+		return nil, buildNotAFunctionError(functionName, "nil")
+	}
+	if v.Kind() != reflect.Func {
+		return nil, buildNotAFunctionError(functionName, v.Type().String())
+	}
+	if v.IsNil() {
+		return nil, buildNotAFunctionError(functionName, "nil")
+	}
+
+	if f, ok := fn.(FunctionType); ok {
+		return recoverGoFunc(functionName, f), nil
+	}
+	if f, ok := fn.(func([]Variant) Variant); ok {
+		return recoverGoFunc(functionName, f), nil
+	}
+
+	t := v.Type()
+	switch t.NumOut() {
+	case 0, 1:
+	case 2:
+		if t.Out(1) != errorGoType {
+			return nil, buildUnsupportedReturnSignatureError(functionName)
+		}
+	default:
+		return nil, buildUnsupportedReturnSignatureError(functionName)
+	}
+
+	fixedArity := t.NumIn()
+	if t.IsVariadic() {
+		fixedArity--
+	}
+
+	return recoverGoFunc(functionName, func(args []Variant) Variant {
+		if t.IsVariadic() {
+			if e := ensureMinimimArity(args, fixedArity, functionName); e != nil {
+				return Variant{VariantType: VAR_ERROR, VariantValue: e}
+			}
+		} else if e := ensureExactArity(args, fixedArity, functionName); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+
+		in := make([]reflect.Value, len(args))
+		for i, a := range args {
+			var parameterType reflect.Type
+			if i < fixedArity {
+				parameterType = t.In(i)
+			} else {
+				parameterType = t.In(fixedArity).Elem()
+			}
+
+			if parameterType != variantGoType && parameterType != errorGoType {
+				if e := ensureTypeIsNotInvalid(a); e != nil {
+					return Variant{VariantType: VAR_ERROR, VariantValue: e}
+				}
+			}
+
+			pv, e := variantToValue(a, parameterType)
+			if e != nil {
+				return Variant{VariantType: VAR_ERROR, VariantValue: buildArgumentError(i+1, functionName, e)}
+			}
+			in[i] = pv
+		}
+
+		return variantFromResults(v.Call(in))
+	}), nil
+}
+
+// recoverGoFunc turns a panic in a go function into the error that the call returns.
+func recoverGoFunc(functionName string, f FunctionType) FunctionType {
+	return func(args []Variant) (result Variant) {
+		defer func() {
+			if r := recover(); r != nil {
+				result = Variant{VariantType: VAR_ERROR, VariantValue: buildGoFunctionPanicError(functionName, r)}
+			}
+		}()
+		return f(args)
+	}
+}
+
+func variantFromResults(results []reflect.Value) Variant {
+	if len(results) == 0 {
+		return Variant{VariantType: VAR_NULL}
+	}
+
+	if last := results[len(results)-1]; last.Type() == errorGoType {
+		if !last.IsNil() {
+			return Variant{VariantType: VAR_ERROR, VariantValue: last.Interface().(error)}
+		}
+		if len(results) == 1 {
+			return Variant{VariantType: VAR_NULL}
+		}
+	}
+
+	result, e := variantFromValue(results[0])
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return result
+}
+
+// RegisterGoFunc binds an arbitrary go function into the context's FunctionTable. See WrapGoFunc.
+// A context with a sandbox only accepts the function if the sandbox permits it, by name or by CapabilityHost.
+func (ctx *EvaluationContext) RegisterGoFunc(functionName string, fn interface{}) error {
+	if ctx.sandbox != nil && !ctx.sandbox.permits(functionName, CapabilityHost) {
+		return buildFunctionNotPermittedError(functionName, ctx.sandbox.Name)
+	}
+
+	f, e := WrapGoFunc(functionName, fn)
+	if e != nil {
+		return e
+	}
+
+	ctx.FunctionTable[functionName] = f
+	return nil
+}
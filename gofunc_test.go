@@ -0,0 +1,233 @@
+package golisp
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testLineItem struct {
+	Sku      string
+	Quantity int
+	Price    float64
+}
+
+var errOutOfStock = fmt.Errorf("out of stock")
+
+func TestWrapGoFunc(t *testing.T) {
+	day := time.Date(1974, 11, 11, 0, 0, 0, 0, time.UTC)
+	items := Variant{VariantType: VAR_VECTOR, VariantValue: []Variant{
+		{VariantType: VAR_INT, VariantValue: int64(1)},
+		{VariantType: VAR_INT, VariantValue: int64(2)},
+	}}
+	order := NewVariantMap()
+	order, _ = order.Set(Variant{VariantType: VAR_STRING, VariantValue: "Sku"}, Variant{VariantType: VAR_STRING, VariantValue: "A-1"})
+	order, _ = order.Set(Variant{VariantType: VAR_STRING, VariantValue: "Quantity"}, Variant{VariantType: VAR_INT, VariantValue: int64(3)})
+	order, _ = order.Set(Variant{VariantType: VAR_STRING, VariantValue: "Price"}, Variant{VariantType: VAR_FLOAT, VariantValue: 2.5})
+
+	tests := [...]struct {
+		desc     string
+		fn       interface{}
+		input    []Variant
+		expected Variant
+	}{
+		{
+			desc:     "int parameters",
+			fn:       func(a int, b int8) int { return a + int(b) },
+			input:    []Variant{{VariantType: VAR_INT, VariantValue: int64(1)}, {VariantType: VAR_BOOL, VariantValue: true}},
+			expected: Variant{VariantType: VAR_INT, VariantValue: int64(2)},
+		},
+		{
+			desc:     "float parameter accepts int",
+			fn:       func(a float64) float32 { return float32(a / 2) },
+			input:    []Variant{{VariantType: VAR_INT, VariantValue: int64(3)}},
+			expected: Variant{VariantType: VAR_FLOAT, VariantValue: float64(1.5)},
+		},
+		{
+			desc: "string and bool",
+			fn: func(s string, upper bool) string {
+				if upper {
+					return strings.ToUpper(s)
+				}
+				return s
+			},
+			input:    []Variant{{VariantType: VAR_STRING, VariantValue: "abc"}, {VariantType: VAR_BOOL, VariantValue: true}},
+			expected: Variant{VariantType: VAR_STRING, VariantValue: "ABC"},
+		},
+		{
+			desc:     "time",
+			fn:       func(d time.Time) int { return d.Year() },
+			input:    []Variant{{VariantType: VAR_DATE, VariantValue: day}},
+			expected: Variant{VariantType: VAR_INT, VariantValue: int64(1974)},
+		},
+		{
+			desc:     "slice",
+			fn:       func(xs []int) int { return len(xs) },
+			input:    []Variant{items},
+			expected: Variant{VariantType: VAR_INT, VariantValue: int64(2)},
+		},
+		{
+			desc:     "slice result",
+			fn:       func() []uint8 { return []uint8{1, 2} },
+			input:    []Variant{},
+			expected: items,
+		},
+		{
+			desc:     "struct",
+			fn:       func(item testLineItem) float64 { return float64(item.Quantity) * item.Price },
+			input:    []Variant{{VariantType: VAR_MAP, VariantValue: order}},
+			expected: Variant{VariantType: VAR_FLOAT, VariantValue: float64(7.5)},
+		},
+		{
+			desc:     "struct result",
+			fn:       func() testLineItem { return testLineItem{Sku: "A-1", Quantity: 3, Price: 2.5} },
+			input:    []Variant{},
+			expected: Variant{VariantType: VAR_MAP, VariantValue: order},
+		},
+		{
+			desc:     "map",
+			fn:       func(m map[string]interface{}) int { return len(m) },
+			input:    []Variant{{VariantType: VAR_MAP, VariantValue: order}},
+			expected: Variant{VariantType: VAR_INT, VariantValue: int64(3)},
+		},
+		{
+			desc:     "variadic",
+			fn:       func(sep string, xs ...int) string { return fmt.Sprint(sep, xs) },
+			input:    []Variant{{VariantType: VAR_STRING, VariantValue: "-"}, {VariantType: VAR_INT, VariantValue: int64(1)}, {VariantType: VAR_INT, VariantValue: int64(2)}},
+			expected: Variant{VariantType: VAR_STRING, VariantValue: "-[1 2]"},
+		},
+		{
+			desc:     "variadic with no variadic arguments",
+			fn:       func(xs ...int) int { return len(xs) },
+			input:    []Variant{},
+			expected: Variant{VariantType: VAR_INT, VariantValue: int64(0)},
+		},
+		{
+			desc:     "no result",
+			fn:       func() {},
+			input:    []Variant{},
+			expected: Variant{VariantType: VAR_NULL},
+		},
+		{
+			desc:     "value and nil error",
+			fn:       func() (int, error) { return 1, nil },
+			input:    []Variant{},
+			expected: Variant{VariantType: VAR_INT, VariantValue: int64(1)},
+		},
+		{
+			desc:     "value and error",
+			fn:       func() (int, error) { return 0, errOutOfStock },
+			input:    []Variant{},
+			expected: Variant{VariantType: VAR_ERROR, VariantValue: errOutOfStock},
+		},
+		{
+			desc:     "variant passthrough",
+			fn:       func(v Variant) Variant { return v },
+			input:    []Variant{{VariantType: VAR_ERROR, VariantValue: errRandom}},
+			expected: Variant{VariantType: VAR_ERROR, VariantValue: errRandom},
+		},
+		{
+			desc:     "arity error",
+			fn:       func(a int) int { return a },
+			input:    []Variant{},
+			expected: Variant{VariantType: VAR_ERROR, VariantValue: buildExactArityError(1, "test")},
+		},
+		{
+			desc:     "variadic arity error",
+			fn:       func(a int, xs ...int) int { return a },
+			input:    []Variant{},
+			expected: Variant{VariantType: VAR_ERROR, VariantValue: buildMinimumArityError(1, "test")},
+		},
+		{
+			desc:     "type error",
+			fn:       func(a int, s string) int { return a },
+			input:    []Variant{{VariantType: VAR_INT, VariantValue: int64(1)}, {VariantType: VAR_INT, VariantValue: int64(2)}},
+			expected: Variant{VariantType: VAR_ERROR, VariantValue: buildArgumentError(2, "test", buildGoTypeError(VAR_INT, "string"))},
+		},
+		{
+			desc:     "overflow error",
+			fn:       func(a int8) int8 { return a },
+			input:    []Variant{{VariantType: VAR_INT, VariantValue: int64(300)}},
+			expected: Variant{VariantType: VAR_ERROR, VariantValue: buildArgumentError(1, "test", buildIntegerOverflowError(int64(300), "int8"))},
+		},
+		{
+			desc:     "error argument is passed back",
+			fn:       func(a int) int { return a },
+			input:    []Variant{{VariantType: VAR_ERROR, VariantValue: errRandom}},
+			expected: Variant{VariantType: VAR_ERROR, VariantValue: errRandom},
+		},
+		{
+			desc:     "panic",
+			fn:       func(key string) { var m map[string]int; m[key] = 1 },
+			input:    []Variant{{VariantType: VAR_STRING, VariantValue: "a"}},
+			expected: Variant{VariantType: VAR_ERROR, VariantValue: buildGoFunctionPanicError("test", "assignment to entry in nil map")},
+		},
+		{
+			desc:     "panic in a variant function",
+			fn:       func(args []Variant) Variant { panic("boom") },
+			input:    []Variant{},
+			expected: Variant{VariantType: VAR_ERROR, VariantValue: buildGoFunctionPanicError("test", "boom")},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			f, e := WrapGoFunc("test", test.fn)
+			assert.Nil(t, e)
+
+			actual := f(test.input)
+			assert.Equal(t, test.expected, actual, "computation error")
+		})
+	}
+}
+
+func TestWrapGoFunc_InvalidSignatures(t *testing.T) {
+	tests := [...]struct {
+		desc     string
+		fn       interface{}
+		expected error
+	}{
+		{desc: "nil", fn: nil, expected: buildNotAFunctionError("test", "nil")},
+		{desc: "not a function", fn: 42, expected: buildNotAFunctionError("test", "int")},
+		{desc: "too many results", fn: func() (int, int, error) { return 0, 0, nil }, expected: buildUnsupportedReturnSignatureError("test")},
+		{desc: "second result is not an error", fn: func() (int, int) { return 0, 0 }, expected: buildUnsupportedReturnSignatureError("test")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			_, e := WrapGoFunc("test", test.fn)
+			assert.Equal(t, test.expected, e)
+		})
+	}
+}
+
+func TestRegisterGoFunc(t *testing.T) {
+	context := NewEvaluationContext(nil)
+	assert.Nil(t, context.RegisterGoFunc("discount", func(total float64, percent int) float64 {
+		return total * float64(100-percent) / 100
+	}))
+	assert.Nil(t, context.RegisterGoFunc("weekday", func(d time.Time) string { return d.Weekday().String() }))
+
+	tests := [...]struct {
+		desc     string
+		input    string
+		expected Variant
+	}{
+		{desc: "float result", input: "(discount (* 2 100) 10)", expected: Variant{VariantType: VAR_FLOAT, VariantValue: float64(180)}},
+		{desc: "date argument", input: "(weekday 11/11/1974)", expected: Variant{VariantType: VAR_STRING, VariantValue: "Monday"}},
+		{desc: "arity error", input: "(discount 1)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildExactArityError(2, "discount")}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			sexpr, e := Parse(test.input)
+			assert.Nil(t, e, "parse error")
+
+			actual := sexpr.Eval(context)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
@@ -2,11 +2,16 @@ package golisp
 
 import (
 	"math"
+	"math/bits"
 )
 
 type ArithmeticLibrary struct {
 }
 
+func (l *ArithmeticLibrary) Namespace() string {
+	return "math"
+}
+
 func (l *ArithmeticLibrary) add(args []Variant) Variant {
 	return foldNumbers(
 		args,
@@ -75,6 +80,514 @@ func (l *ArithmeticLibrary) power(args []Variant) Variant {
 		"pow")
 }
 
+func ensureIntArgs(args []Variant, arity int, functionName string) ([]int64, error) {
+	if e := ensureExactArity(args, arity, functionName); e != nil {
+		return nil, e
+	}
+
+	if e := ensureArgumentTypesMatch(args, []EnumVariantType{VAR_INT}, []EnumVariantType{}, functionName); e != nil {
+		return nil, e
+	}
+
+	values := make([]int64, len(args))
+	for i, a := range args {
+		v, e := a.CoerceToInt()
+		if e != nil {
+			return nil, e
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// integerDivision applies an operation to two ints, checking for division by zero first.
+func integerDivision(args []Variant, op func(int64, int64) (int64, error), functionName string) Variant {
+	values, e := ensureIntArgs(args, 2, functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if values[1] == 0 {
+		return Variant{VariantType: VAR_ERROR, VariantValue: buildDivideByZeroError()}
+	}
+
+	result, e := op(values[0], values[1])
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return Variant{VariantType: VAR_INT, VariantValue: result}
+}
+
+// quot divides ints, truncating towards zero.
+func (l *ArithmeticLibrary) quot(args []Variant) Variant {
+	return integerDivision(
+		args,
+		func(a int64, b int64) (int64, error) {
+			if a == math.MinInt64 && b == -1 {
+				// the quotient is one more than the largest int, and go would wrap it round to the smallest
+				return 0, buildIntegerOverflowError(a, "int64")
+			}
+			return a / b, nil
+		},
+		"quot")
+}
+
+// rem is the remainder of quot, which has the sign of the dividend.
+func (l *ArithmeticLibrary) rem(args []Variant) Variant {
+	return integerDivision(args, func(a int64, b int64) (int64, error) { return a % b, nil }, "rem")
+}
+
+// mod is the remainder of dividing ints rounding down, which has the sign of the divisor.
+func (l *ArithmeticLibrary) mod(args []Variant) Variant {
+	return integerDivision(
+		args,
+		func(a int64, b int64) (int64, error) {
+			m := a % b
+			if m != 0 && (m < 0) != (b < 0) {
+				m += b
+			}
+			return m, nil
+		},
+		"mod")
+}
+
+func (l *ArithmeticLibrary) abs(args []Variant) Variant {
+	return unaryOpNumber(
+		args,
+		func(a int64) (int64, error) {
+			if a == math.MinInt64 {
+				return 0, buildIntegerOverflowError(a, "int64")
+			}
+			if a < 0 {
+				return -a, nil
+			}
+			return a, nil
+		},
+		func(a float64) (float64, error) { return math.Abs(a), nil },
+		"abs")
+}
+
+func (l *ArithmeticLibrary) sign(args []Variant) Variant {
+	return unaryOpNumber(
+		args,
+		func(a int64) (int64, error) {
+			switch {
+			case a < 0:
+				return -1, nil
+			case a > 0:
+				return 1, nil
+			}
+			return 0, nil
+		},
+		func(a float64) (float64, error) {
+			switch {
+			case a < 0:
+				return -1, nil
+			case a > 0:
+				return 1, nil
+			}
+			return a, nil
+		},
+		"sign")
+}
+
+// compareNumbers orders two numbers, comparing them as ints unless either is a float.
+func compareNumbers(a Variant, b Variant, functionName string) (int, error) {
+	t, e := getPromotedNumberType([]Variant{a, b}, functionName)
+	if e != nil {
+		return 0, e
+	}
+
+	if t == VAR_INT {
+		x, _ := a.CoerceToInt()
+		y, _ := b.CoerceToInt()
+		return compareInts(x, y), nil
+	}
+
+	x, _ := a.CoerceToFloat()
+	y, _ := b.CoerceToFloat()
+	return compareFloats(x, y), nil
+}
+
+// selectNumber returns whichever argument the comparison prefers, keeping its type, so (min 1 2.5) is the int 1.
+func selectNumber(args []Variant, prefer func(int) bool, functionName string) Variant {
+	if e := ensureMinimimArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureNumberArgs(args, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	result := args[0]
+	for _, a := range args[1:] {
+		c, e := compareNumbers(a, result, functionName)
+		if e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		if prefer(c) {
+			result = a
+		}
+	}
+	return result
+}
+
+func (l *ArithmeticLibrary) min(args []Variant) Variant {
+	return selectNumber(args, func(c int) bool { return c < 0 }, "min")
+}
+
+func (l *ArithmeticLibrary) max(args []Variant) Variant {
+	return selectNumber(args, func(c int) bool { return c > 0 }, "max")
+}
+
+// clamp limits the value to the bounds, returning whichever of the three is chosen with its own type.
+func (l *ArithmeticLibrary) clamp(args []Variant) Variant {
+	functionName := "clamp"
+	if e := ensureExactArity(args, 3, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureNumberArgs(args, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if c, e := compareNumbers(args[1], args[2], functionName); e != nil || c > 0 {
+		return Variant{VariantType: VAR_ERROR, VariantValue: buildInvalidRangeError(functionName)}
+	}
+
+	if c, _ := compareNumbers(args[0], args[1], functionName); c < 0 {
+		return args[1]
+	}
+	if c, _ := compareNumbers(args[0], args[2], functionName); c > 0 {
+		return args[2]
+	}
+	return args[0]
+}
+
+func (l *ArithmeticLibrary) floor(args []Variant) Variant {
+	return unaryOpNumber(args, func(a int64) (int64, error) { return a, nil }, func(a float64) (float64, error) { return math.Floor(a), nil }, "floor")
+}
+
+func (l *ArithmeticLibrary) ceil(args []Variant) Variant {
+	return unaryOpNumber(args, func(a int64) (int64, error) { return a, nil }, func(a float64) (float64, error) { return math.Ceil(a), nil }, "ceil")
+}
+
+func (l *ArithmeticLibrary) truncate(args []Variant) Variant {
+	return unaryOpNumber(args, func(a int64) (int64, error) { return a, nil }, func(a float64) (float64, error) { return math.Trunc(a), nil }, "truncate")
+}
+
+var roundingModes = map[string]func(float64) float64{
+	"half-up":   math.Round,
+	"half-even": math.RoundToEven,
+}
+
+// round rounds to the given number of decimal places (default 0), which may be negative to round to tens, hundreds and
+// so on. Halves are rounded away from zero unless the mode :half-even is given. Ints stay ints.
+func (l *ArithmeticLibrary) round(args []Variant) Variant {
+	functionName := "round"
+	if e := ensureMinimimArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureMaximumArity(args, 3, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureNumberArgs(args[:1], functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	places := int64(0)
+	if len(args) >= 2 {
+		p, e := ensureIndexArg(args[1], functionName)
+		if e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		places = p
+	}
+
+	roundHalf := roundingModes["half-up"]
+	if len(args) == 3 {
+		if e := ensureArgumentTypesMatch(args[2:], []EnumVariantType{VAR_KEYWORD, VAR_STRING}, []EnumVariantType{}, functionName); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		mode, _ := keyName(args[2])
+		f, found := roundingModes[mode]
+		if !found {
+			return Variant{VariantType: VAR_ERROR, VariantValue: buildUnknownRoundingModeError(mode)}
+		}
+		roundHalf = f
+	}
+
+	return unaryOpNumber(
+		args[:1],
+		func(a int64) (int64, error) {
+			if places >= 0 {
+				return a, nil
+			}
+			f := roundToPlaces(float64(a), places, roundHalf)
+			// the ints lie in [-2^63, 2^63), and rounding the largest of them up leaves that range
+			if f < math.MinInt64 || f >= -math.MinInt64 {
+				return 0, buildIntegerOverflowError(f, "int64")
+			}
+			return int64(f), nil
+		},
+		func(a float64) (float64, error) {
+			return roundToPlaces(a, places, roundHalf), nil
+		},
+		functionName)
+}
+
+// roundToPlaces rounds to the decimal places without overflowing the scale. A float too large to scale up has no
+// digits that far down, so it is already rounded, and any float scaled down by more than the largest float rounds to
+// zero.
+func roundToPlaces(a float64, places int64, roundHalf func(float64) float64) float64 {
+	if places > 0 {
+		scale := math.Pow(10, float64(places))
+		if math.IsInf(scale, 0) || math.IsInf(a*scale, 0) {
+			return a
+		}
+		return roundHalf(a*scale) / scale
+	}
+
+	scale := math.Pow(10, float64(-places))
+	if math.IsInf(scale, 0) {
+		return math.Copysign(0, a)
+	}
+	return roundHalf(a/scale) * scale
+}
+
+// unaryOpFloat applies a function that always returns a float, such as sqrt, to any number.
+func unaryOpFloat(args []Variant, op func(float64) float64, functionName string) Variant {
+	if e := ensureExactArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureNumberArgs(args, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	v, e := args[0].CoerceToFloat()
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return Variant{VariantType: VAR_FLOAT, VariantValue: op(v)}
+}
+
+func (l *ArithmeticLibrary) injectFloatFunctions(functions FunctionTable) FunctionTable {
+	floatFunctions := map[string]func(float64) float64{
+		"sqrt":  math.Sqrt,
+		"exp":   math.Exp,
+		"log10": math.Log10,
+		"log2":  math.Log2,
+		"sin":   math.Sin,
+		"cos":   math.Cos,
+		"tan":   math.Tan,
+		"asin":  math.Asin,
+		"acos":  math.Acos,
+		"atan":  math.Atan,
+	}
+
+	for name, f := range floatFunctions {
+		name, f := name, f
+		functions[name] = func(args []Variant) Variant { return unaryOpFloat(args, f, name) }
+	}
+	return functions
+}
+
+// log is the natural logarithm, or the logarithm in the base given as the second argument.
+func (l *ArithmeticLibrary) log(args []Variant) Variant {
+	functionName := "log"
+	if len(args) == 1 {
+		return unaryOpFloat(args, math.Log, functionName)
+	}
+
+	return binaryOpFloats(
+		args,
+		func(a float64, base float64) (float64, error) { return math.Log(a) / math.Log(base), nil },
+		functionName)
+}
+
+func (l *ArithmeticLibrary) atan2(args []Variant) Variant {
+	return binaryOpFloats(args, func(y float64, x float64) (float64, error) { return math.Atan2(y, x), nil }, "atan2")
+}
+
+// gcd is never negative, so the gcd of the smallest int and zero or itself, which is one more than the largest int,
+// overflows.
+func gcd(a int64, b int64) (int64, error) {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	if a == math.MinInt64 {
+		return 0, buildIntegerOverflowError(a, "int64")
+	}
+	if a < 0 {
+		return -a, nil
+	}
+	return a, nil
+}
+
+func (l *ArithmeticLibrary) gcd(args []Variant) Variant {
+	values, e := ensureIntArgs(args, 2, "gcd")
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	result, e := gcd(values[0], values[1])
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return Variant{VariantType: VAR_INT, VariantValue: result}
+}
+
+func (l *ArithmeticLibrary) lcm(args []Variant) Variant {
+	values, e := ensureIntArgs(args, 2, "lcm")
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if values[0] == 0 || values[1] == 0 {
+		return Variant{VariantType: VAR_INT, VariantValue: int64(0)}
+	}
+
+	g, e := gcd(values[0], values[1])
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	// multiply the magnitudes as uints, whose product overflows into the high word when it is past the largest int
+	hi, lo := bits.Mul64(magnitude(values[0]/g), magnitude(values[1]))
+	if hi != 0 || lo > math.MaxInt64 {
+		return Variant{VariantType: VAR_ERROR, VariantValue: buildIntegerOverflowError(values[0], "int64")}
+	}
+	return Variant{VariantType: VAR_INT, VariantValue: int64(lo)}
+}
+
+// magnitude is the absolute value of an int, which always fits in a uint.
+func magnitude(a int64) uint64 {
+	if a < 0 {
+		return uint64(-a)
+	}
+	return uint64(a)
+}
+
+func floatPredicate(args []Variant, predicate func(float64) bool, functionName string) Variant {
+	if e := ensureExactArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureNumberArgs(args, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	v, e := args[0].CoerceToFloat()
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return Variant{VariantType: VAR_BOOL, VariantValue: predicate(v)}
+}
+
+func (l *ArithmeticLibrary) isNaN(args []Variant) Variant {
+	return floatPredicate(args, math.IsNaN, "nan?")
+}
+
+func (l *ArithmeticLibrary) isInfinite(args []Variant) Variant {
+	return floatPredicate(args, func(f float64) bool { return math.IsInf(f, 0) }, "infinite?")
+}
+
+func (l *ArithmeticLibrary) isFinite(args []Variant) Variant {
+	return floatPredicate(args, func(f float64) bool { return !math.IsNaN(f) && !math.IsInf(f, 0) }, "finite?")
+}
+
+// bitwiseOp folds ints with a bitwise operation. Floats are rejected rather than truncated.
+func bitwiseOp(args []Variant, op func(int64, int64) int64, functionName string) Variant {
+	if e := ensureMinimimArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureArgumentTypesMatch(args, []EnumVariantType{VAR_INT}, []EnumVariantType{}, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	return foldInts(args, func(a int64, b int64) (int64, error) { return op(a, b), nil }, functionName)
+}
+
+// bitPositionOp applies an operation to an int and a bit position, which counts from 0 for the least significant bit.
+func bitPositionOp(args []Variant, op func(int64, uint) int64, functionName string) Variant {
+	if e := ensureArgumentTypesMatch(args, []EnumVariantType{VAR_INT}, []EnumVariantType{}, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	return binaryOpInts(
+		args,
+		func(a int64, position int64) (int64, error) {
+			if position < 0 || position > 63 {
+				return 0, buildBitPositionError(position, functionName)
+			}
+			return op(a, uint(position)), nil
+		},
+		functionName)
+}
+
+func (l *ArithmeticLibrary) bitAnd(args []Variant) Variant {
+	return bitwiseOp(args, func(a int64, b int64) int64 { return a & b }, "bit-and")
+}
+
+func (l *ArithmeticLibrary) bitOr(args []Variant) Variant {
+	return bitwiseOp(args, func(a int64, b int64) int64 { return a | b }, "bit-or")
+}
+
+func (l *ArithmeticLibrary) bitXor(args []Variant) Variant {
+	return bitwiseOp(args, func(a int64, b int64) int64 { return a ^ b }, "bit-xor")
+}
+
+func (l *ArithmeticLibrary) bitNot(args []Variant) Variant {
+	values, e := ensureIntArgs(args, 1, "bit-not")
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return Variant{VariantType: VAR_INT, VariantValue: ^values[0]}
+}
+
+func (l *ArithmeticLibrary) shiftLeft(args []Variant) Variant {
+	return bitPositionOp(args, func(a int64, n uint) int64 { return a << n }, "shift-left")
+}
+
+// shiftRight is an arithmetic shift, which keeps the sign of negative numbers.
+func (l *ArithmeticLibrary) shiftRight(args []Variant) Variant {
+	return bitPositionOp(args, func(a int64, n uint) int64 { return a >> n }, "shift-right")
+}
+
+// shiftRightLogical shifts zeros in from the left, treating the int as 64 unsigned bits.
+func (l *ArithmeticLibrary) shiftRightLogical(args []Variant) Variant {
+	return bitPositionOp(args, func(a int64, n uint) int64 { return int64(uint64(a) >> n) }, "shift-right-logical")
+}
+
+func (l *ArithmeticLibrary) bitSet(args []Variant) Variant {
+	return bitPositionOp(args, func(a int64, n uint) int64 { return a | 1<<n }, "bit-set")
+}
+
+func (l *ArithmeticLibrary) bitClear(args []Variant) Variant {
+	return bitPositionOp(args, func(a int64, n uint) int64 { return a &^ (1 << n) }, "bit-clear")
+}
+
+func (l *ArithmeticLibrary) bitTest(args []Variant) Variant {
+	result := bitPositionOp(args, func(a int64, n uint) int64 { return a >> n & 1 }, "bit-test")
+	if result.VariantType == VAR_ERROR {
+		return result
+	}
+	return Variant{VariantType: VAR_BOOL, VariantValue: result.VariantValue == int64(1)}
+}
+
+// popcount counts the bits that are set, including the sign bit of negative numbers.
+func (l *ArithmeticLibrary) popcount(args []Variant) Variant {
+	values, e := ensureIntArgs(args, 1, "popcount")
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return Variant{VariantType: VAR_INT, VariantValue: int64(bits.OnesCount64(uint64(values[0])))}
+}
+
 func (l *ArithmeticLibrary) InjectFunctions(functions FunctionTable) FunctionTable {
 	functions["add"] = l.add
 	functions["sub"] = l.subtract
@@ -86,5 +599,148 @@ func (l *ArithmeticLibrary) InjectFunctions(functions FunctionTable) FunctionTab
 	functions["*"] = l.multiply
 	functions["/"] = l.divide
 	functions["^"] = l.power
-	return functions
+	functions["quot"] = l.quot
+	functions["rem"] = l.rem
+	functions["mod"] = l.mod
+	functions["abs"] = l.abs
+	functions["sign"] = l.sign
+	functions["min"] = l.min
+	functions["max"] = l.max
+	functions["clamp"] = l.clamp
+	functions["floor"] = l.floor
+	functions["ceil"] = l.ceil
+	functions["round"] = l.round
+	functions["truncate"] = l.truncate
+	functions["log"] = l.log
+	functions["atan2"] = l.atan2
+	functions["gcd"] = l.gcd
+	functions["lcm"] = l.lcm
+	functions["bit-and"] = l.bitAnd
+	functions["bit-or"] = l.bitOr
+	functions["bit-xor"] = l.bitXor
+	functions["bit-not"] = l.bitNot
+	functions["shift-left"] = l.shiftLeft
+	functions["shift-right"] = l.shiftRight
+	functions["shift-right-logical"] = l.shiftRightLogical
+	functions["bit-test"] = l.bitTest
+	functions["bit-set"] = l.bitSet
+	functions["bit-clear"] = l.bitClear
+	functions["popcount"] = l.popcount
+	functions["nan?"] = l.isNaN
+	functions["infinite?"] = l.isInfinite
+	functions["finite?"] = l.isFinite
+	return l.injectFloatFunctions(functions)
+}
+
+func (l *ArithmeticLibrary) Documentation() map[string]string {
+	return map[string]string{
+		"add":                 "(add x y ...) sums the numbers, giving a float if any of them is one.",
+		"sub":                 "(sub x y) subtracts y from x, and (sub x) negates x.",
+		"mul":                 "(mul x y ...) multiplies the numbers, giving a float if any of them is one.",
+		"div":                 "(div x y) divides x by y, always giving a float.",
+		"pow":                 "(pow x y) raises x to the power y, giving a float.",
+		"+":                   "(+ x y ...) sums the numbers, giving a float if any of them is one.",
+		"-":                   "(- x y) subtracts y from x, and (- x) negates x.",
+		"*":                   "(* x y ...) multiplies the numbers, giving a float if any of them is one.",
+		"/":                   "(/ x y) divides x by y, always giving a float.",
+		"^":                   "(^ x y) raises x to the power y, giving a float.",
+		"quot":                "(quot x y) divides the ints, truncating towards zero.",
+		"rem":                 "(rem x y) is the remainder of (quot x y), with the sign of x.",
+		"mod":                 "(mod x y) is the remainder of dividing the ints rounding down, with the sign of y.",
+		"abs":                 "(abs x) is the absolute value of x.",
+		"sign":                "(sign x) is -1, 0 or 1 as x is negative, zero or positive.",
+		"min":                 "(min x y ...) is the smallest of the numbers, keeping its type.",
+		"max":                 "(max x y ...) is the largest of the numbers, keeping its type.",
+		"clamp":               "(clamp x low high) limits x to the range from low to high.",
+		"floor":               "(floor x) rounds x down to a whole number.",
+		"ceil":                "(ceil x) rounds x up to a whole number.",
+		"round":               "(round x [places [:half-even]]) rounds x to the decimal places, rounding halves away from zero unless :half-even is given.",
+		"truncate":            "(truncate x) rounds x towards zero to a whole number.",
+		"log":                 "(log x [base]) is the natural logarithm of x, or its logarithm in the base.",
+		"atan2":               "(atan2 y x) is the angle in radians of the point (x, y) from the x axis.",
+		"gcd":                 "(gcd x y) is the greatest common divisor of the ints.",
+		"lcm":                 "(lcm x y) is the least common multiple of the ints.",
+		"bit-and":             "(bit-and x y ...) is the bitwise and of the ints.",
+		"bit-or":              "(bit-or x y ...) is the bitwise or of the ints.",
+		"bit-xor":             "(bit-xor x y ...) is the bitwise exclusive or of the ints.",
+		"bit-not":             "(bit-not x) flips every bit of the int.",
+		"shift-left":          "(shift-left x n) shifts the bits of x left by n places.",
+		"shift-right":         "(shift-right x n) shifts the bits of x right by n places, keeping its sign.",
+		"shift-right-logical": "(shift-right-logical x n) shifts the bits of x right by n places, shifting in zeros.",
+		"bit-test":            "(bit-test x n) is true if bit n of x is set, counting from 0 for the least significant bit.",
+		"bit-set":             "(bit-set x n) sets bit n of x.",
+		"bit-clear":           "(bit-clear x n) clears bit n of x.",
+		"popcount":            "(popcount x) counts the bits of x that are set.",
+		"nan?":                "(nan? x) is true if x is not a number.",
+		"infinite?":           "(infinite? x) is true if x is positive or negative infinity.",
+		"finite?":             "(finite? x) is true if x is neither infinite nor not a number.",
+		"sqrt":                "(sqrt x) is the square root of x.",
+		"exp":                 "(exp x) is e raised to the power x.",
+		"log10":               "(log10 x) is the logarithm of x in base 10.",
+		"log2":                "(log2 x) is the logarithm of x in base 2.",
+		"sin":                 "(sin x) is the sine of x radians.",
+		"cos":                 "(cos x) is the cosine of x radians.",
+		"tan":                 "(tan x) is the tangent of x radians.",
+		"asin":                "(asin x) is the arcsine of x, in radians.",
+		"acos":                "(acos x) is the arccosine of x, in radians.",
+		"atan":                "(atan x) is the arctangent of x, in radians.",
+	}
+}
+
+func (l *ArithmeticLibrary) Signatures() map[string]Signature {
+	number := exactly(1, numberTypes)
+	twoNumbers := exactly(2, numberTypes, numberTypes)
+	twoInts := exactly(2, intType, intType)
+	return map[string]Signature{
+		"add":                 atLeast(1, numberTypes),
+		"sub":                 between(1, 2, numberTypes),
+		"mul":                 atLeast(1, numberTypes),
+		"div":                 twoNumbers,
+		"pow":                 twoNumbers,
+		"+":                   atLeast(1, numberTypes),
+		"-":                   between(1, 2, numberTypes),
+		"*":                   atLeast(1, numberTypes),
+		"/":                   twoNumbers,
+		"^":                   twoNumbers,
+		"quot":                twoInts,
+		"rem":                 twoInts,
+		"mod":                 twoInts,
+		"abs":                 number,
+		"sign":                number,
+		"min":                 atLeast(1, numberTypes),
+		"max":                 atLeast(1, numberTypes),
+		"clamp":               exactly(3, numberTypes, numberTypes, numberTypes),
+		"floor":               number,
+		"ceil":                number,
+		"round":               between(1, 3, numberTypes, intType, []EnumVariantType{VAR_STRING, VAR_KEYWORD}),
+		"truncate":            number,
+		"log":                 between(1, 2, numberTypes, numberTypes),
+		"atan2":               twoNumbers,
+		"gcd":                 twoInts,
+		"lcm":                 twoInts,
+		"bit-and":             atLeast(1, intType),
+		"bit-or":              atLeast(1, intType),
+		"bit-xor":             atLeast(1, intType),
+		"bit-not":             exactly(1, intType),
+		"shift-left":          twoInts,
+		"shift-right":         twoInts,
+		"shift-right-logical": twoInts,
+		"bit-test":            twoInts,
+		"bit-set":             twoInts,
+		"bit-clear":           twoInts,
+		"popcount":            exactly(1, intType),
+		"nan?":                number,
+		"infinite?":           number,
+		"finite?":             number,
+		"sqrt":                number,
+		"exp":                 number,
+		"log10":               number,
+		"log2":                number,
+		"sin":                 number,
+		"cos":                 number,
+		"tan":                 number,
+		"asin":                number,
+		"acos":                number,
+		"atan":                number,
+	}
 }
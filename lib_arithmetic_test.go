@@ -311,3 +311,127 @@ func TestPower(t *testing.T) {
 		})
 	}
 }
+
+func floatVariant(f float64) Variant {
+	return Variant{VariantType: VAR_FLOAT, VariantValue: f}
+}
+
+func TestMathFunctions(t *testing.T) {
+	context := NewEvaluationContext(nil)
+
+	tests := [...]struct {
+		desc     string
+		input    string
+		expected Variant
+	}{
+		{desc: "sum of nothing", input: "(+)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildMinimumArityError(1, "add")}},
+		{desc: "product of nothing", input: "(reduce * [])", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildMinimumArityError(1, "mul")}},
+		{desc: "quot", input: "(quot -7 2)", expected: intVariant(-3)},
+		{desc: "rem", input: "(rem -7 2)", expected: intVariant(-1)},
+		{desc: "mod", input: "(mod -7 2)", expected: intVariant(1)},
+		{desc: "mod with negative divisor", input: "(mod 7 -2)", expected: intVariant(-1)},
+		{desc: "quot of the smallest int by -1", input: "(quot -9223372036854775808 -1)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildIntegerOverflowError(-9223372036854775808, "int64")}},
+		{desc: "rem of the smallest int by -1", input: "(rem -9223372036854775808 -1)", expected: intVariant(0)},
+		{desc: "mod of the smallest int by -1", input: "(mod -9223372036854775808 -1)", expected: intVariant(0)},
+		{desc: "mod by zero", input: "(mod 7 0)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildDivideByZeroError()}},
+		{desc: "quot of a float", input: "(quot 7.0 2)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnacceptableTypeError(VAR_FLOAT, "quot")}},
+		{desc: "abs int", input: "(abs -3)", expected: intVariant(3)},
+		{desc: "abs float", input: "(abs -3.1415)", expected: floatVariant(3.1415)},
+		{desc: "abs of the smallest int", input: "(abs -9223372036854775808)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildIntegerOverflowError(-9223372036854775808, "int64")}},
+		{desc: "sign", input: "(sign -12)", expected: intVariant(-1)},
+		{desc: "sign float", input: "(sign 0.125)", expected: floatVariant(1)},
+		{desc: "min keeps the type", input: "(min 3 1 2.0)", expected: intVariant(1)},
+		{desc: "max keeps the type", input: "(max 3 1 4.0)", expected: floatVariant(4)},
+		{desc: "min of nothing", input: "(min)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildMinimumArityError(1, "min")}},
+		{desc: "clamp below", input: "(clamp -5 0 10)", expected: intVariant(0)},
+		{desc: "clamp above", input: "(clamp 12.0 0 10)", expected: intVariant(10)},
+		{desc: "clamp within", input: "(clamp 5 0 10)", expected: intVariant(5)},
+		{desc: "clamp inverted bounds", input: "(clamp 5 10 0)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildInvalidRangeError("clamp")}},
+		{desc: "floor", input: "(floor -3.1415)", expected: floatVariant(-4)},
+		{desc: "ceil", input: "(ceil 3.1415)", expected: floatVariant(4)},
+		{desc: "truncate", input: "(truncate -3.75)", expected: floatVariant(-3)},
+		{desc: "floor of an int", input: "(floor 3)", expected: intVariant(3)},
+		{desc: "round half up", input: "(round 0.125 2)", expected: floatVariant(0.13)},
+		{desc: "round half even", input: "(round 0.125 2 :half-even)", expected: floatVariant(0.12)},
+		{desc: "round to places", input: "(round 3.14159 2)", expected: floatVariant(3.14)},
+		{desc: "round an int to hundreds", input: "(round 12500 -3)", expected: intVariant(13000)},
+		{desc: "round an int to hundreds half even", input: "(round 12500 -3 \"half-even\")", expected: intVariant(12000)},
+		{desc: "round an int past its digits", input: "(round 5 -400)", expected: intVariant(0)},
+		{desc: "round an int up past the largest int", input: "(round (bit-not -9223372036854775808) -1)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildIntegerOverflowError(9.22337203685477581e18, "int64")}},
+		{desc: "round a float past its digits", input: "(round (/ 3 2) 400)", expected: floatVariant(1.5)},
+		{desc: "round a float to more places than the largest float", input: "(round (/ 3 2) -400)", expected: floatVariant(0)},
+		{desc: "round with unknown mode", input: "(round 2.0 0 :sideways)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnknownRoundingModeError("sideways")}},
+		{desc: "sqrt of an int", input: "(sqrt 16)", expected: floatVariant(4)},
+		{desc: "exp", input: "(exp 0)", expected: floatVariant(1)},
+		{desc: "log", input: "(log 1)", expected: floatVariant(0)},
+		{desc: "log with base", input: "(log 8 2)", expected: floatVariant(3)},
+		{desc: "log10", input: "(log10 100)", expected: floatVariant(2)},
+		{desc: "log2", input: "(log2 64)", expected: floatVariant(6)},
+		{desc: "sin", input: "(sin 0)", expected: floatVariant(0)},
+		{desc: "cos", input: "(cos 0)", expected: floatVariant(1)},
+		{desc: "atan2", input: "(atan2 0 1)", expected: floatVariant(0)},
+		{desc: "sqrt of a string", input: "(sqrt \"16\")", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnacceptableTypeError(VAR_STRING, "sqrt")}},
+		{desc: "gcd", input: "(gcd 12 -18)", expected: intVariant(6)},
+		{desc: "lcm", input: "(lcm 4 6)", expected: intVariant(12)},
+		{desc: "lcm with zero", input: "(lcm 4 0)", expected: intVariant(0)},
+		{desc: "gcd of the smallest int and zero", input: "(gcd -9223372036854775808 0)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildIntegerOverflowError(-9223372036854775808, "int64")}},
+		{desc: "lcm past the largest int", input: "(lcm (shift-left 1 62) 3)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildIntegerOverflowError(4611686018427387904, "int64")}},
+		{desc: "lcm of negatives", input: "(lcm -4 6)", expected: intVariant(12)},
+		{desc: "nan?", input: "(nan? (sqrt -1))", expected: boolVariant(true)},
+		{desc: "infinite?", input: "(infinite? (exp 99999))", expected: boolVariant(true)},
+		{desc: "finite?", input: "(finite? 3)", expected: boolVariant(true)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			sexpr, e := Parse(test.input)
+			assert.Nil(t, e, "parse error")
+
+			actual := sexpr.Eval(context)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestBitwiseFunctions(t *testing.T) {
+	context := NewEvaluationContext(nil)
+
+	tests := [...]struct {
+		desc     string
+		input    string
+		expected Variant
+	}{
+		{desc: "binary literal", input: "0b1010_1010", expected: intVariant(170)},
+		{desc: "octal literal", input: "0o17", expected: intVariant(15)},
+		{desc: "hex literal", input: "-0x_FF", expected: intVariant(-255)},
+		{desc: "separated int literal", input: "1_000", expected: intVariant(1000)},
+		{desc: "separated float literal", input: "1_000.5", expected: floatVariant(1000.5)},
+		{desc: "bit-and", input: "(bit-and 0b1100 0b1010 0b1000)", expected: intVariant(0b1000)},
+		{desc: "bit-or", input: "(bit-or 0b1100 0b0011)", expected: intVariant(0b1111)},
+		{desc: "bit-xor", input: "(bit-xor 0b1100 0b1010)", expected: intVariant(0b0110)},
+		{desc: "bit-and of a float", input: "(bit-and 3 2.0)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnacceptableTypeError(VAR_FLOAT, "bit-and")}},
+		{desc: "bit-not", input: "(bit-not 0)", expected: intVariant(-1)},
+		{desc: "shift-left", input: "(shift-left 1 4)", expected: intVariant(16)},
+		{desc: "shift-right", input: "(shift-right -16 2)", expected: intVariant(-4)},
+		{desc: "shift-right-logical", input: "(shift-right-logical -1 60)", expected: intVariant(15)},
+		{desc: "shift too far", input: "(shift-left 1 64)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildBitPositionError(64, "shift-left")}},
+		{desc: "shift backwards", input: "(shift-right 1 -1)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildBitPositionError(-1, "shift-right")}},
+		{desc: "bit-test set", input: "(bit-test 0b0100 2)", expected: boolVariant(true)},
+		{desc: "bit-test clear", input: "(bit-test 0b0100 1)", expected: boolVariant(false)},
+		{desc: "bit-test sign", input: "(bit-test -1 63)", expected: boolVariant(true)},
+		{desc: "bit-set", input: "(bit-set 0b0001 3)", expected: intVariant(0b1001)},
+		{desc: "bit-clear", input: "(bit-clear 0b1111 0)", expected: intVariant(0b1110)},
+		{desc: "popcount", input: "(popcount 0b1011)", expected: intVariant(3)},
+		{desc: "popcount negative", input: "(popcount -1)", expected: intVariant(64)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			sexpr, e := Parse(test.input)
+			assert.Nil(t, e, "parse error")
+
+			actual := sexpr.Eval(context)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
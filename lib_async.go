@@ -0,0 +1,506 @@
+package golisp
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+const asyncNamespace = "async"
+
+// defaultParallelism is the number of goroutines pmap uses unless it is told otherwise.
+const defaultParallelism = 8
+
+// maxChannelCapacity bounds the buffer of a channel whatever the limits, as go allocates it when the channel is made.
+const maxChannelCapacity = 1 << 16
+
+// AsyncLibrary runs work concurrently, with futures, a parallel map and channels.
+//
+// (future expr) is evaluated as a special form: it evaluates its expression in a new goroutine, and takes the go context
+// of the evaluation so that waiting on it stops when the evaluation is cancelled. Waiting on a channel stops when the
+// evaluation that is waiting is cancelled. The work done in other goroutines counts against the limits of the
+// evaluation. Futures are cancelled when EvalContext returns.
+type AsyncLibrary struct {
+}
+
+func (l *AsyncLibrary) Namespace() string {
+	return asyncNamespace
+}
+
+type future struct {
+	done  chan struct{}
+	value Variant
+}
+
+type channel struct {
+	items     chan Variant
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newFuture() *future {
+	return &future{done: make(chan struct{})}
+}
+
+func (f *future) resolve(v Variant) {
+	f.value = v
+	close(f.done)
+}
+
+func newChannel(capacity int64) *channel {
+	return &channel{items: make(chan Variant, capacity), closed: make(chan struct{})}
+}
+
+// cancelled returns a channel that is closed when the go context is done, or nil, which never is, if there isn't one.
+func cancelled(goContext context.Context) <-chan struct{} {
+	if goContext == nil {
+		return nil
+	}
+	return goContext.Done()
+}
+
+func cancellationVariant(goContext context.Context) Variant {
+	return Variant{VariantType: VAR_ERROR, VariantValue: buildEvaluationCancelledError(goContext.Err())}
+}
+
+// done returns a channel that is closed when the evaluation is cancelled, or nil, which never is, if it can't be.
+func (ctx *EvaluationContext) done() <-chan struct{} {
+	if ctx == nil {
+		return nil
+	}
+	return cancelled(ctx.goContext)
+}
+
+// asyncForms are evaluated with their arguments unevaluated, when they are called by a name that the async library owns.
+var asyncForms = map[string]func(*EvaluationContext, []SExpr) Variant{
+	"future": evalFuture,
+}
+
+// asyncForm returns the special form named by the head of a list, if there is one.
+func (ctx *EvaluationContext) asyncForm(head SExpr) func(*EvaluationContext, []SExpr) Variant {
+	a, ok := head.(*atom)
+	if !ok || a.typedValue.VariantType != VAR_IDENT {
+		return nil
+	}
+
+	// a symbol bound under the name of a form shadows it, as it does a function
+	name, e := a.typedValue.GetIdentifierValue()
+	if e != nil || ctx.isSymbol(name) || ctx.capabilityOf(name) != asyncNamespace {
+		return nil
+	}
+
+	return asyncForms[strings.TrimPrefix(name, asyncNamespace+namespaceSeparator)]
+}
+
+// evalFuture starts evaluating the expression in a new goroutine, which has a context of its own.
+func evalFuture(ctx *EvaluationContext, args []SExpr) Variant {
+	functionName := "future"
+	if len(args) != 1 {
+		return Variant{VariantType: VAR_ERROR, VariantValue: buildExactArityError(1, functionName)}
+	}
+
+	child := NewEvaluationContext(ctx)
+	child.meter = ctx.meter.fork()
+
+	f := newFuture()
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				f.resolve(Variant{VariantType: VAR_ERROR, VariantValue: buildFuturePanicError(r)})
+			}
+		}()
+		f.resolve(evalArgument(child, args[0]))
+	}()
+	return Variant{VariantType: VAR_FUTURE, VariantValue: f}
+}
+
+// makeChan makes a channel with the capacity given, which is checked against the limits as the buffer is allocated
+// when the channel is made.
+func (l *AsyncLibrary) makeChan(ctx *EvaluationContext, args []Variant) Variant {
+	functionName := "chan"
+	if e := ensureMaximumArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	capacity := int64(0)
+	if len(args) == 1 {
+		c, e := ensureIndexArg(args[0], functionName)
+		if e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		if c < 0 {
+			return Variant{VariantType: VAR_ERROR, VariantValue: buildNegativeArgumentError(c, functionName)}
+		}
+		if c > maxChannelCapacity {
+			return Variant{VariantType: VAR_ERROR, VariantValue: buildValueTooLargeError(c, functionName)}
+		}
+		if e := ctx.ensureCollectionFits(c, functionName); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		capacity = c
+	}
+	return Variant{VariantType: VAR_CHANNEL, VariantValue: newChannel(capacity)}
+}
+
+// future called as a function, such as when it is passed to map, has a value already, so its future is complete.
+func (l *AsyncLibrary) future(args []Variant) Variant {
+	if e := ensureExactArity(args, 1, "future"); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	f := newFuture()
+	f.resolve(args[0])
+	return Variant{VariantType: VAR_FUTURE, VariantValue: f}
+}
+
+func ensureFutureArg(arg Variant, functionName string) (*future, error) {
+	if e := ensureArgumentTypesMatch([]Variant{arg}, []EnumVariantType{VAR_FUTURE}, []EnumVariantType{}, functionName); e != nil {
+		return nil, e
+	}
+	return arg.VariantValue.(*future), nil
+}
+
+func ensureChannelArg(arg Variant, functionName string) (*channel, error) {
+	if e := ensureArgumentTypesMatch([]Variant{arg}, []EnumVariantType{VAR_CHANNEL}, []EnumVariantType{}, functionName); e != nil {
+		return nil, e
+	}
+	return arg.VariantValue.(*channel), nil
+}
+
+// ensureTimeoutArg reads a timeout in milliseconds, returning a channel that fires when it expires.
+func ensureTimeoutArg(arg Variant, functionName string) (<-chan time.Time, error) {
+	ms, e := ensureIndexArg(arg, functionName)
+	if e != nil {
+		return nil, e
+	}
+	if ms < 0 {
+		return nil, buildNegativeArgumentError(ms, functionName)
+	}
+	return time.After(time.Duration(ms) * time.Millisecond), nil
+}
+
+// deref waits for the value of a future, as (deref f [timeout-ms [timeout-value]]).
+// If the timeout expires first it returns the timeout value, which defaults to NIL. The waiting stops when the
+// evaluation that derefs is cancelled, whichever evaluation made the future.
+func (l *AsyncLibrary) deref(ctx *EvaluationContext, args []Variant) Variant {
+	functionName := "deref"
+	if e := ensureMinimimArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureMaximumArity(args, 3, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	f, e := ensureFutureArg(args[0], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	var timeout <-chan time.Time
+	if len(args) >= 2 {
+		if timeout, e = ensureTimeoutArg(args[1], functionName); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+	}
+
+	// a future that has finished gives its value even if the evaluation has since been cancelled
+	select {
+	case <-f.done:
+		return f.value
+	default:
+	}
+
+	select {
+	case <-f.done:
+		return f.value
+	case <-ctx.done():
+		return cancellationVariant(ctx.goContext)
+	case <-timeout:
+		if len(args) == 3 {
+			return args[2]
+		}
+		return Variant{VariantType: VAR_NULL}
+	}
+}
+
+func (l *AsyncLibrary) isRealized(args []Variant) Variant {
+	functionName := "realized?"
+	if e := ensureExactArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	f, e := ensureFutureArg(args[0], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	select {
+	case <-f.done:
+		return Variant{VariantType: VAR_BOOL, VariantValue: true}
+	default:
+		return Variant{VariantType: VAR_BOOL, VariantValue: false}
+	}
+}
+
+// pmap calls the function on each item of the collection from a pool of goroutines, as (pmap f coll [workers]).
+// The results are in the order of the items. After a call fails no more are started, and the first failure is returned.
+func (l *AsyncLibrary) pmap(args []Variant) Variant {
+	functionName := "pmap"
+	if e := ensureMinimimArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureMaximumArity(args, 3, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureArgumentTypesMatch(args[:1], []EnumVariantType{VAR_FUNCTION}, []EnumVariantType{}, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	items, e := ensureSequenceArg(args[1], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	workers := int64(defaultParallelism)
+	if len(args) == 3 {
+		if workers, e = ensureIndexArg(args[2], functionName); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		if workers <= 0 {
+			return Variant{VariantType: VAR_ERROR, VariantValue: buildNonPositiveArgumentError(workers, functionName)}
+		}
+	}
+
+	results := make([]Variant, len(items))
+	jobs := make(chan int)
+	failed := make(chan struct{})
+	failOnce := sync.Once{}
+	wg := sync.WaitGroup{}
+
+	call := func(i int) {
+		defer func() {
+			if r := recover(); r != nil {
+				results[i] = Variant{VariantType: VAR_ERROR, VariantValue: buildFuturePanicError(r)}
+			}
+			if results[i].VariantType == VAR_ERROR {
+				failOnce.Do(func() { close(failed) })
+			}
+		}()
+		results[i] = callFunction(args[0], []Variant{items[i]}, functionName)
+	}
+
+	for w := int64(0); w < workers && w < int64(len(items)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				call(i)
+			}
+		}()
+	}
+
+feed:
+	for i := range items {
+		select {
+		case jobs <- i:
+		case <-failed:
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, r := range results {
+		if r.VariantType == VAR_ERROR {
+			return r
+		}
+	}
+	return vectorOf(results)
+}
+
+// send puts a value on a channel, waiting for room, and returns true.
+func (l *AsyncLibrary) send(ctx *EvaluationContext, args []Variant) Variant {
+	functionName := "send!"
+	if e := ensureExactArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	c, e := ensureChannelArg(args[0], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureTypeIsNotInvalid(args[1]); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	select {
+	case <-c.closed:
+		return Variant{VariantType: VAR_ERROR, VariantValue: buildClosedChannelError(functionName)}
+	default:
+	}
+
+	select {
+	case c.items <- args[1]:
+		return Variant{VariantType: VAR_BOOL, VariantValue: true}
+	case <-c.closed:
+		return Variant{VariantType: VAR_ERROR, VariantValue: buildClosedChannelError(functionName)}
+	case <-ctx.done():
+		return cancellationVariant(ctx.goContext)
+	}
+}
+
+// receive takes the next value from a channel, waiting for one. A closed channel gives NIL once it is empty.
+func (l *AsyncLibrary) receive(ctx *EvaluationContext, args []Variant) Variant {
+	functionName := "recv!"
+	if e := ensureExactArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	c, e := ensureChannelArg(args[0], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	select {
+	case v := <-c.items:
+		return v
+	case <-c.closed:
+		return c.drain()
+	case <-ctx.done():
+		return cancellationVariant(ctx.goContext)
+	}
+}
+
+// drain takes a value left in a closed channel, or gives NIL.
+func (c *channel) drain() Variant {
+	select {
+	case v := <-c.items:
+		return v
+	default:
+		return Variant{VariantType: VAR_NULL}
+	}
+}
+
+func (l *AsyncLibrary) closeChannel(args []Variant) Variant {
+	functionName := "close!"
+	if e := ensureExactArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	c, e := ensureChannelArg(args[0], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	c.closeOnce.Do(func() { close(c.closed) })
+	return Variant{VariantType: VAR_NULL}
+}
+
+// selectChannel waits for the first of the channels to have a value, as (select channels [timeout-ms]), and returns
+// the channel and the value as [ch value]. The value is NIL if the channel was closed, and the result is NIL if the
+// timeout expires first. There must be at least one channel, as otherwise only the timeout could end the wait.
+func (l *AsyncLibrary) selectChannel(ctx *EvaluationContext, args []Variant) Variant {
+	functionName := "select"
+	if e := ensureMinimimArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureMaximumArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	items, e := ensureSequenceArg(args[0], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if len(items) == 0 {
+		return Variant{VariantType: VAR_ERROR, VariantValue: buildEmptyCollectionArgumentError(functionName)}
+	}
+
+	// each channel is selected on for a value and for being closed, followed by the cancellation and the timeout
+	channels := make([]*channel, len(items))
+	cases := make([]reflect.SelectCase, 0, 2*len(items)+2)
+	for i, item := range items {
+		if channels[i], e = ensureChannelArg(item, functionName); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		cases = append(cases,
+			reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(channels[i].items)},
+			reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(channels[i].closed)})
+	}
+
+	cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.done())})
+
+	if len(args) == 2 {
+		timeout, e := ensureTimeoutArg(args[1], functionName)
+		if e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(timeout)})
+	}
+
+	chosen, received, _ := reflect.Select(cases)
+	switch {
+	case chosen < 2*len(channels) && chosen%2 == 0:
+		return vectorOf([]Variant{items[chosen/2], received.Interface().(Variant)})
+	case chosen < 2*len(channels):
+		return vectorOf([]Variant{items[chosen/2], channels[chosen/2].drain()})
+	case chosen == 2*len(channels):
+		return cancellationVariant(ctx.goContext)
+	default:
+		return Variant{VariantType: VAR_NULL}
+	}
+}
+
+func (l *AsyncLibrary) InjectFunctions(functions FunctionTable) FunctionTable {
+	functions["future"] = l.future
+	functions["realized?"] = l.isRealized
+	functions["pmap"] = l.pmap
+	functions["close!"] = l.closeChannel
+	return functions
+}
+
+func (l *AsyncLibrary) InjectContextFunctions(functions ContextFunctionTable) ContextFunctionTable {
+	functions["chan"] = l.makeChan
+	functions["deref"] = l.deref
+	functions["send!"] = l.send
+	functions["recv!"] = l.receive
+	functions["select"] = l.selectChannel
+	return functions
+}
+
+func (l *AsyncLibrary) Documentation() map[string]string {
+	return map[string]string{
+		"future":    "(future expr) evaluates the expression in another goroutine, giving a future of its value.",
+		"deref":     "(deref f [timeout-ms [timeout-value]]) waits for the value of the future.",
+		"realized?": "(realized? f) is true if the future has its value.",
+		"pmap":      "(pmap f coll [workers]) is map calling f on the items from a pool of goroutines.",
+		"chan":      "(chan [capacity]) makes a channel.",
+		"send!":     "(send! ch x) puts the value on the channel, waiting for room.",
+		"recv!":     "(recv! ch) takes the next value from the channel, waiting for one; NIL once it is closed and empty.",
+		"close!":    "(close! ch) closes the channel.",
+		"select":    "(select channels [timeout-ms]) waits for the first channel with a value, giving [ch value], or NIL on timeout.",
+	}
+}
+
+func (l *AsyncLibrary) Signatures() map[string]Signature {
+	channel := []EnumVariantType{VAR_CHANNEL}
+	return map[string]Signature{
+		"future":    exactly(1),
+		"deref":     between(1, 3, []EnumVariantType{VAR_FUTURE}, intType),
+		"realized?": exactly(1, []EnumVariantType{VAR_FUTURE}),
+		"pmap":      between(2, 3, functionType, sequenceArgTypes, intType),
+		"chan":      between(0, 1, intType),
+		"send!":     exactly(2, channel),
+		"recv!":     exactly(1, channel),
+		"close!":    exactly(1, channel),
+		"select":    between(1, 2, sequenceArgTypes, intType),
+	}
+}
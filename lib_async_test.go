@@ -0,0 +1,172 @@
+package golisp
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func evalString(t *testing.T, ctx *EvaluationContext, s string) Variant {
+	sexpr, e := Parse(s)
+	assert.Nil(t, e, "parse error")
+	return sexpr.Eval(ctx)
+}
+
+func TestFutures(t *testing.T) {
+	ctx := NewEvaluationContext(nil)
+	_ = ctx.RegisterGoFunc("slow", func(x int64) int64 {
+		time.Sleep(50 * time.Millisecond)
+		return x
+	})
+	_ = ctx.RegisterGoFunc("boom", func() int64 { panic("boom") })
+
+	start := time.Now()
+	assert.Equal(t, intVariant(10), evalString(t, ctx, "(reduce + 0 (map deref [(future (slow 1)) (future (slow 2)) (future (slow 3)) (future (slow 4))]))"))
+	assert.Less(t, int64(time.Since(start)), int64(150*time.Millisecond), "futures run concurrently")
+
+	tests := [...]struct {
+		desc     string
+		input    string
+		expected Variant
+	}{
+		{desc: "timeout value", input: "(deref (future (slow 1)) 1 :late)", expected: Variant{VariantType: VAR_KEYWORD, VariantValue: ":late"}},
+		{desc: "timeout without a value", input: "(deref (future (slow 1)) 1)", expected: Variant{VariantType: VAR_NULL}},
+		{desc: "qualified future", input: "(deref (async/future (+ 1 2)))", expected: intVariant(3)},
+		{desc: "future as a function", input: "(map deref (map future [1 2]))", expected: vectorVariant(intVariant(1), intVariant(2))},
+		{desc: "completed future", input: "(map realized? (map future [1]))", expected: vectorVariant(boolVariant(true))},
+		{desc: "running future", input: "(realized? (future (slow 1)))", expected: boolVariant(false)},
+		{desc: "future of an error", input: "(deref (future (/ 1 0)))", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildDivideByZeroError()}},
+		{desc: "future of a panicking go function", input: "(deref (future (boom)))", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildGoFunctionPanicError("boom", "boom")}},
+		{desc: "future arity", input: "(future 1 2)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildExactArityError(1, "future")}},
+		{desc: "deref of a value", input: "(deref 1)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnacceptableTypeError(VAR_INT, "deref")}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			assert.Equal(t, test.expected, evalString(t, ctx, test.input))
+		})
+	}
+}
+
+func TestFutureCancellationAndLimits(t *testing.T) {
+	ctx := NewEvaluationContext(nil, WithLimits(Limits{MaxSteps: 10}))
+	_ = ctx.RegisterGoFunc("slow", func(x int64) int64 {
+		time.Sleep(20 * time.Millisecond)
+		return x
+	})
+
+	sexpr, _ := Parse("(deref (future (reduce + 0 (map slow (range 0 100)))))")
+	result := EvalWithTimeout(sexpr, ctx, time.Second)
+	assert.True(t, IsLimitExceeded(result), "work in futures counts against the limits")
+
+	ctx = NewEvaluationContext(nil)
+	_ = ctx.RegisterGoFunc("slow", func(x int64) int64 {
+		time.Sleep(20 * time.Millisecond)
+		return x
+	})
+
+	sexpr, _ = Parse("(deref (future (reduce + 0 (map slow (range 0 100)))))")
+	result = EvalWithTimeout(sexpr, ctx, 50*time.Millisecond)
+	assert.True(t, IsCancellation(result))
+
+	// a future made by another evaluation stops the waiting of the evaluation that derefs it
+	ctx.SymbolTable["pending"] = evalString(t, ctx, "(future (slow 100))")
+	sexpr, _ = Parse("(deref pending)")
+	assert.True(t, IsCancellation(EvalWithTimeout(sexpr, ctx, 10*time.Millisecond)))
+}
+
+func TestShadowedFuture(t *testing.T) {
+	ctx := NewEvaluationContext(nil)
+	child := NewEvaluationContext(ctx)
+	child.SymbolTable["future"] = evalString(t, ctx, "abs")
+
+	assert.Equal(t, intVariant(1), evalString(t, child, "(future -1)"), "a symbol shadows the future form")
+}
+
+func TestParallelMap(t *testing.T) {
+	ctx := NewEvaluationContext(nil)
+
+	mutex := sync.Mutex{}
+	running, peak := 0, 0
+	_ = ctx.RegisterGoFunc("track", func(x int64) int64 {
+		mutex.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		mutex.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mutex.Lock()
+		running--
+		mutex.Unlock()
+		return x * 10
+	})
+
+	assert.Equal(t, vectorVariant(intVariant(0), intVariant(10), intVariant(20), intVariant(30), intVariant(40), intVariant(50)), evalString(t, ctx, "(pmap track (range 0 6) 3)"))
+	assert.Equal(t, 3, peak, "the pool is bounded")
+
+	tests := [...]struct {
+		desc     string
+		input    string
+		expected Variant
+	}{
+		{desc: "default pool", input: "(pmap abs [-1 -2 -3])", expected: vectorVariant(intVariant(1), intVariant(2), intVariant(3))},
+		{desc: "empty", input: "(pmap abs [])", expected: vectorVariant()},
+		{desc: "failure", input: "(pmap abs [1 \"a\" 2])", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnacceptableTypeError(VAR_STRING, "abs")}},
+		{desc: "empty pool", input: "(pmap abs [1] 0)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildNonPositiveArgumentError(0, "pmap")}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			assert.Equal(t, test.expected, evalString(t, ctx, test.input))
+		})
+	}
+}
+
+func TestChannels(t *testing.T) {
+	ctx := NewEvaluationContext(nil)
+	c := evalString(t, ctx, "(chan 2)")
+	ctx.SymbolTable["c"] = c
+	ctx.SymbolTable["empty"] = evalString(t, ctx, "(chan)")
+
+	tests := [...]struct {
+		desc     string
+		input    string
+		expected Variant
+	}{
+		{desc: "send", input: "(send! c 1)", expected: boolVariant(true)},
+		{desc: "send again", input: "(send! c 2)", expected: boolVariant(true)},
+		{desc: "receive", input: "(recv! c)", expected: intVariant(1)},
+		{desc: "select", input: "(select [empty c] 10)", expected: vectorVariant(c, intVariant(2))},
+		{desc: "select timeout", input: "(select [empty c] 10)", expected: Variant{VariantType: VAR_NULL}},
+		{desc: "send before closing", input: "(send! c 3)", expected: boolVariant(true)},
+		{desc: "close", input: "(close! c)", expected: Variant{VariantType: VAR_NULL}},
+		{desc: "receive what is left", input: "(recv! c)", expected: intVariant(3)},
+		{desc: "receive from closed", input: "(recv! c)", expected: Variant{VariantType: VAR_NULL}},
+		{desc: "select closed", input: "(select [empty c])", expected: vectorVariant(c, Variant{VariantType: VAR_NULL})},
+		{desc: "send to closed", input: "(send! c 4)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildClosedChannelError("send!")}},
+		{desc: "negative capacity", input: "(chan -1)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildNegativeArgumentError(-1, "chan")}},
+		{desc: "capacity too large", input: "(chan 1_000_000_000_000_000)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildValueTooLargeError(1_000_000_000_000_000, "chan")}},
+		{desc: "select nothing", input: "(select [])", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildEmptyCollectionArgumentError("select")}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			assert.Equal(t, test.expected, evalString(t, ctx, test.input))
+		})
+	}
+
+	sexpr, _ := Parse("(recv! (chan))")
+	assert.True(t, IsCancellation(EvalWithTimeout(sexpr, NewEvaluationContext(nil), 20*time.Millisecond)), "waiting stops when the evaluation is cancelled")
+
+	// a channel made by go code, outside any evaluation, stops the waiting of the evaluation that waits on it
+	ctx.SymbolTable["outside"] = (&AsyncLibrary{}).makeChan(nil, nil)
+	for _, input := range []string{"(recv! outside)", "(send! (chan) 1)", "(select [outside])"} {
+		sexpr, _ := Parse(input)
+		assert.True(t, IsCancellation(EvalWithTimeout(sexpr, ctx, 20*time.Millisecond)), input)
+	}
+}
@@ -1,7 +1,37 @@
 package golisp
 
+// FunctionLibrary is implemented by every pluggable library of functions.
+// Namespace is used to build qualified names (e.g. "str/concat") for each function.
 type FunctionLibrary interface {
-	InjectFunctions(*FunctionTable) *FunctionTable
+	Namespace() string
+	InjectFunctions(FunctionTable) FunctionTable
+}
+
+// DocumentedLibrary is implemented by libraries that describe their functions, for help in editors and the like.
+// The descriptions are keyed by the names the library injects its functions under, and start with an example call.
+type DocumentedLibrary interface {
+	FunctionLibrary
+	Documentation() map[string]string
+}
+
+// SignedLibrary is implemented by libraries that describe the arguments their functions take, so that the linter and
+// editors can check calls without making them. The signatures are keyed like the descriptions of Documentation.
+type SignedLibrary interface {
+	FunctionLibrary
+	Signatures() map[string]Signature
+}
+
+// ContextFunctionType is a library function that is given the context evaluating the call, so that it can check the
+// limits before it builds a large value, or stop waiting when the evaluation is cancelled. Called through the
+// FunctionTable, as go code calls functions, it is given a nil context, and only the built-in bounds apply.
+type ContextFunctionType func(*EvaluationContext, []Variant) Variant
+type ContextFunctionTable map[string]ContextFunctionType
+
+// ContextLibrary is implemented by libraries with functions that need the context evaluating them. They are named and
+// namespaced like the functions of InjectFunctions, and the two mustn't share names.
+type ContextLibrary interface {
+	FunctionLibrary
+	InjectContextFunctions(ContextFunctionTable) ContextFunctionTable
 }
 
 func ensureMaximumArity(args []Variant, arity int, functionName string) error {
@@ -72,8 +102,28 @@ func ensureArgumentTypesMatch(args []Variant, acceptableTypes []EnumVariantType,
 	return nil
 }
 
+func ensureStringArg(arg Variant, functionName string) (string, error) {
+	if e := ensureArgumentTypesMatch([]Variant{arg}, []EnumVariantType{VAR_STRING}, []EnumVariantType{}, functionName); e != nil {
+		return "", e
+	}
+	return arg.CoerceToString()
+}
+
+// callFunction invokes a function value that was passed as an argument.
+func callFunction(f Variant, args []Variant, functionName string) Variant {
+	if e := ensureArgumentTypesMatch([]Variant{f}, []EnumVariantType{VAR_FUNCTION}, []EnumVariantType{}, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	function, ok := f.VariantValue.(FunctionType)
+	if !ok {
+		return Variant{VariantType: VAR_ERROR, VariantValue: buildInconsistentTypeError(f.VariantValue, f.VariantType)}
+	}
+	return function(args)
+}
+
 func ensureBooleanArgs(args []Variant, functionName string) error {
-	return ensureArgumentTypesMatch(args, []EnumVariantType{VAR_BOOL, VAR_INT}, []EnumVariantType{}, functionName)
+	return ensureArgumentTypesMatch(args, booleanTypes, []EnumVariantType{}, functionName)
 }
 
 func unaryOpBoolean(args []Variant, unaryOp func(bool) bool, functionName string) Variant {
@@ -133,7 +183,7 @@ func foldBooleans(args []Variant, bool_folder func(bool, bool) bool, functionNam
 }
 
 func ensureNumberArgs(args []Variant, functionName string) error {
-	return ensureArgumentTypesMatch(args, []EnumVariantType{VAR_BOOL, VAR_INT, VAR_FLOAT}, []EnumVariantType{}, functionName)
+	return ensureArgumentTypesMatch(args, numberTypes, []EnumVariantType{}, functionName)
 }
 
 func getPromotedNumberType(args []Variant, functionName string) (EnumVariantType, error) {
@@ -257,6 +307,10 @@ func binaryOpInts(args []Variant, int_folder func(int64, int64) (int64, error),
 }
 
 func foldNumbers(args []Variant, int_folder func(int64, int64) (int64, error), float_folder func(float64, float64) (float64, error), functionName string) Variant {
+	if e := ensureMinimimArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
 	if e := ensureNumberArgs(args, functionName); e != nil {
 		return Variant{VariantType: VAR_ERROR, VariantValue: e}
 	}
@@ -318,24 +372,3 @@ func foldInts(args []Variant, int_folder func(int64, int64) (int64, error), func
 
 	return Variant{VariantType: VAR_INT, VariantValue: res}
 }
-
-func foldStrings(args []Variant, string_folder func(string, string) (string, error), functionName string) Variant {
-	v, e := args[0].CoerceToString()
-	if e != nil {
-		return Variant{VariantType: VAR_ERROR, VariantValue: e}
-	}
-	res := v
-
-	for _, a := range args[1:] {
-		v, e := a.CoerceToString()
-		if e != nil {
-			return Variant{VariantType: VAR_ERROR, VariantValue: e}
-		}
-
-		if res, e = string_folder(res, v); e != nil {
-			return Variant{VariantType: VAR_ERROR, VariantValue: e}
-		}
-	}
-
-	return Variant{VariantType: VAR_STRING, VariantValue: res}
-}
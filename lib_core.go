@@ -0,0 +1,174 @@
+package golisp
+
+type CoreLibrary struct {
+}
+
+func (l *CoreLibrary) Namespace() string {
+	return "core"
+}
+
+func allEqual(args []Variant, functionName string) (bool, error) {
+	if e := ensureMinimimArity(args, 2, functionName); e != nil {
+		return false, e
+	}
+
+	if e := ensureValidArgs(args); e != nil {
+		return false, e
+	}
+
+	for _, a := range args[1:] {
+		if !args[0].Equals(a) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (l *CoreLibrary) equal(args []Variant) Variant {
+	res, e := allEqual(args, "=")
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return Variant{VariantType: VAR_BOOL, VariantValue: res}
+}
+
+func (l *CoreLibrary) notEqual(args []Variant) Variant {
+	res, e := allEqual(args, "not=")
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return Variant{VariantType: VAR_BOOL, VariantValue: !res}
+}
+
+func (l *CoreLibrary) compare(args []Variant) Variant {
+	functionName := "compare"
+	if e := ensureExactArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureValidArgs(args); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	c, e := args[0].Compare(args[1])
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return Variant{VariantType: VAR_INT, VariantValue: int64(c)}
+}
+
+// allOrdered checks that every adjacent pair of arguments satisfies the ordering, so that (< a b c) means a < b < c.
+func allOrdered(args []Variant, ordered func(int) bool, functionName string) Variant {
+	if e := ensureMinimimArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureValidArgs(args); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	for i := 1; i < len(args); i++ {
+		c, e := args[i-1].Compare(args[i])
+		if e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		if !ordered(c) {
+			return Variant{VariantType: VAR_BOOL, VariantValue: false}
+		}
+	}
+	return Variant{VariantType: VAR_BOOL, VariantValue: true}
+}
+
+func (l *CoreLibrary) lessThan(args []Variant) Variant {
+	return allOrdered(args, func(c int) bool { return c < 0 }, "<")
+}
+
+func (l *CoreLibrary) lessThanOrEqual(args []Variant) Variant {
+	return allOrdered(args, func(c int) bool { return c <= 0 }, "<=")
+}
+
+func (l *CoreLibrary) greaterThan(args []Variant) Variant {
+	return allOrdered(args, func(c int) bool { return c > 0 }, ">")
+}
+
+func (l *CoreLibrary) greaterThanOrEqual(args []Variant) Variant {
+	return allOrdered(args, func(c int) bool { return c >= 0 }, ">=")
+}
+
+func (l *CoreLibrary) keyword(args []Variant) Variant {
+	functionName := "keyword"
+	if e := ensureExactArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureArgumentTypesMatch(args, []EnumVariantType{VAR_STRING, VAR_KEYWORD}, []EnumVariantType{}, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	name, e := keyName(args[0])
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if !isKeywordName(name) {
+		return Variant{VariantType: VAR_ERROR, VariantValue: buildInvalidKeywordError(name)}
+	}
+	return Variant{VariantType: VAR_KEYWORD, VariantValue: keywordPrefix + name}
+}
+
+func (l *CoreLibrary) isKeyword(args []Variant) Variant {
+	functionName := "keyword?"
+	if e := ensureExactArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureTypeIsNotInvalid(args[0]); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	return Variant{VariantType: VAR_BOOL, VariantValue: args[0].VariantType == VAR_KEYWORD}
+}
+
+func (l *CoreLibrary) InjectFunctions(functions FunctionTable) FunctionTable {
+	functions["="] = l.equal
+	functions["not="] = l.notEqual
+	functions["!="] = l.notEqual
+	functions["compare"] = l.compare
+	functions["<"] = l.lessThan
+	functions["<="] = l.lessThanOrEqual
+	functions[">"] = l.greaterThan
+	functions[">="] = l.greaterThanOrEqual
+	functions["keyword"] = l.keyword
+	functions["keyword?"] = l.isKeyword
+	return functions
+}
+
+func (l *CoreLibrary) Documentation() map[string]string {
+	return map[string]string{
+		"=":        "(= a b ...) is true if all the arguments are equal.",
+		"not=":     "(not= a b ...) is true unless all the arguments are equal.",
+		"!=":       "(!= a b ...) is true unless all the arguments are equal.",
+		"compare":  "(compare a b) is negative, zero or positive as a is less than, equal to or greater than b.",
+		"<":        "(< a b ...) is true if every argument is less than the next.",
+		"<=":       "(<= a b ...) is true if every argument is less than or equal to the next.",
+		">":        "(> a b ...) is true if every argument is greater than the next.",
+		">=":       "(>= a b ...) is true if every argument is greater than or equal to the next.",
+		"keyword":  "(keyword name) makes the keyword :name from a string or keyword.",
+		"keyword?": "(keyword? x) is true if x is a keyword.",
+	}
+}
+
+func (l *CoreLibrary) Signatures() map[string]Signature {
+	return map[string]Signature{
+		"=":        atLeast(2),
+		"not=":     atLeast(2),
+		"!=":       atLeast(2),
+		"compare":  exactly(2),
+		"<":        atLeast(2),
+		"<=":       atLeast(2),
+		">":        atLeast(2),
+		">=":       atLeast(2),
+		"keyword":  exactly(1, []EnumVariantType{VAR_STRING, VAR_KEYWORD}),
+		"keyword?": exactly(1),
+	}
+}
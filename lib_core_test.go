@@ -0,0 +1,104 @@
+package golisp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func keywordVariant(name string) Variant {
+	return Variant{VariantType: VAR_KEYWORD, VariantValue: keywordPrefix + name}
+}
+
+func TestKeywords(t *testing.T) {
+	context := NewEvaluationContext(nil)
+	context.SymbolTable["status"] = keywordVariant("approved")
+	decision, _ := ParseJSON(`{"reason":"ok"}`)
+	context.SymbolTable["decision"] = decision
+
+	tests := [...]struct {
+		desc     string
+		input    string
+		expected Variant
+	}{
+		{desc: "self-evaluating", input: ":approved", expected: keywordVariant("approved")},
+		{desc: "identity", input: "(= status :approved)", expected: Variant{VariantType: VAR_BOOL, VariantValue: true}},
+		{desc: "different keywords", input: "(= status :rejected)", expected: Variant{VariantType: VAR_BOOL, VariantValue: false}},
+		{desc: "keyword is not a string", input: `(= :approved ":approved")`, expected: Variant{VariantType: VAR_BOOL, VariantValue: false}},
+		{desc: "not=", input: "(not= status :rejected)", expected: Variant{VariantType: VAR_BOOL, VariantValue: true}},
+		{desc: "map keys", input: "(get {:approved false :reason \"limit\"} :reason)", expected: stringVariant("limit")},
+		{desc: "dotted access to keyword keys", input: "(get-in {:order {:total 5}} :order :total)", expected: intVariant(5)},
+		{desc: "prints back", input: "(concat {:approved false})", expected: stringVariant("{:approved false}")},
+		{desc: "keyword from string", input: `(keyword "rejected")`, expected: keywordVariant("rejected")},
+		{desc: "invalid keyword name", input: `(keyword "a b")`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildInvalidKeywordError("a b")}},
+		{desc: "keyword name with the keyword prefix", input: `(keyword ":a")`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildInvalidKeywordError(":a")}},
+		{desc: "keyword?", input: "(keyword? :a)", expected: Variant{VariantType: VAR_BOOL, VariantValue: true}},
+		{desc: "json output uses names", input: "(json-stringify {:approved false :status status})", expected: stringVariant(`{"approved":false,"status":"approved"}`)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			sexpr, e := Parse(test.input)
+			assert.Nil(t, e, "parse error")
+
+			actual := sexpr.Eval(context)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestEqual(t *testing.T) {
+	core := &CoreLibrary{}
+	tests := [...]struct {
+		desc     string
+		input    []Variant
+		expected Variant
+	}{
+		{desc: "ints", input: []Variant{intVariant(1), intVariant(1), intVariant(1)}, expected: Variant{VariantType: VAR_BOOL, VariantValue: true}},
+		{desc: "int and float", input: []Variant{intVariant(1), {VariantType: VAR_FLOAT, VariantValue: 1.0}}, expected: Variant{VariantType: VAR_BOOL, VariantValue: false}},
+		{desc: "lists", input: []Variant{{VariantType: VAR_LIST, VariantValue: []Variant{intVariant(1)}}, {VariantType: VAR_LIST, VariantValue: []Variant{intVariant(1)}}}, expected: Variant{VariantType: VAR_BOOL, VariantValue: true}},
+		{desc: "maps ignore order", input: []Variant{mapVariant(intVariant(1), intVariant(2), intVariant(3), intVariant(4)), mapVariant(intVariant(3), intVariant(4), intVariant(1), intVariant(2))}, expected: Variant{VariantType: VAR_BOOL, VariantValue: true}},
+		{desc: "arity", input: []Variant{intVariant(1)}, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildMinimumArityError(2, "=")}},
+		{desc: "error", input: []Variant{intVariant(1), {VariantType: VAR_ERROR, VariantValue: errRandom}}, expected: Variant{VariantType: VAR_ERROR, VariantValue: errRandom}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			actual := core.equal(test.input)
+			assert.Equal(t, test.expected, actual, "computation error")
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	context := NewEvaluationContext(nil)
+
+	tests := [...]struct {
+		desc     string
+		input    string
+		expected Variant
+	}{
+		{desc: "compare ints", input: "(compare 1 2)", expected: intVariant(-1)},
+		{desc: "compare equal strings", input: `(compare "b" "b")`, expected: intVariant(0)},
+		{desc: "compare vectors", input: "(compare [1 2 3] [1 2])", expected: intVariant(1)},
+		{desc: "compare mixed numbers", input: "(compare 2 1.75)", expected: intVariant(1)},
+		{desc: "incomparable", input: `(compare 1 "1")`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildIncomparableTypesError(VAR_INT, VAR_STRING)}},
+		{desc: "unordered maps", input: "(compare {} {})", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildIncomparableTypesError(VAR_MAP, VAR_MAP)}},
+		{desc: "less than chain", input: "(< 1 2 3)", expected: Variant{VariantType: VAR_BOOL, VariantValue: true}},
+		{desc: "less than broken chain", input: "(< 1 3 2)", expected: Variant{VariantType: VAR_BOOL, VariantValue: false}},
+		{desc: "less than or equal", input: "(<= 1 1 2)", expected: Variant{VariantType: VAR_BOOL, VariantValue: true}},
+		{desc: "greater than", input: "(> :b :a)", expected: Variant{VariantType: VAR_BOOL, VariantValue: true}},
+		{desc: "greater than or equal", input: "(>= 1.0 2)", expected: Variant{VariantType: VAR_BOOL, VariantValue: false}},
+		{desc: "arity", input: "(< 1)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildMinimumArityError(2, "<")}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			sexpr, e := Parse(test.input)
+			assert.Nil(t, e, "parse error")
+
+			actual := sexpr.Eval(context)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
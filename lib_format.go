@@ -0,0 +1,341 @@
+package golisp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// FormatLibrary builds display strings for messages, such as explanations of decisions that customers will read.
+type FormatLibrary struct {
+}
+
+func (l *FormatLibrary) Namespace() string {
+	return "fmt"
+}
+
+type numberSeparators struct {
+	group   string
+	decimal string
+}
+
+// numberLocales maps locale tags to the separators used to write numbers, using non-breaking spaces where a space is
+// usual. A tag with a region that isn't listed falls back to its language, so "en-GB" is written like "en".
+var numberLocales = map[string]numberSeparators{
+	"en":    {group: ",", decimal: "."},
+	"de":    {group: ".", decimal: ","},
+	"de-CH": {group: "'", decimal: "."},
+	"es":    {group: ".", decimal: ","},
+	"fr":    {group: "\u202f", decimal: ","},
+	"it":    {group: ".", decimal: ","},
+	"nl":    {group: ".", decimal: ","},
+	"pt":    {group: ".", decimal: ","},
+	"sv":    {group: "\u00a0", decimal: ","},
+}
+
+func lookupNumberLocale(locale string) (numberSeparators, error) {
+	if separators, found := numberLocales[locale]; found {
+		return separators, nil
+	}
+
+	if i := strings.IndexAny(locale, "-_"); i > 0 {
+		if separators, found := numberLocales[locale[:i]]; found {
+			return separators, nil
+		}
+	}
+	return numberSeparators{}, buildUnknownLocaleError(locale)
+}
+
+// displayString renders a variant for people to read: strings without quotes and floats in plain decimal notation.
+func displayString(v Variant) (string, error) {
+	if e := ensureTypeIsNotInvalid(v); e != nil {
+		return "", e
+	}
+
+	switch v.VariantType {
+	case VAR_STRING:
+		return v.CoerceToString()
+	case VAR_FLOAT:
+		f, e := v.CoerceToFloat()
+		if e != nil {
+			return "", e
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	}
+	return v.ToDebugString(), nil
+}
+
+// formatArg converts a variant to the go value that best suits the fmt verbs, so that %d takes ints, %f floats and so on.
+func formatArg(v Variant) (interface{}, error) {
+	if e := ensureTypeIsNotInvalid(v); e != nil {
+		return nil, e
+	}
+
+	switch v.VariantType {
+	case VAR_INT, VAR_FLOAT, VAR_BOOL, VAR_DATE:
+		return v.GetTypeConsistentValue()
+	}
+	return displayString(v)
+}
+
+// sprintf formats its arguments with go's fmt verbs, such as (sprintf "%s: %.2f" name total). The length that the
+// widths, precisions and arguments may take is checked against the limits before the string is formatted.
+func (l *FormatLibrary) sprintf(ctx *EvaluationContext, args []Variant) Variant {
+	functionName := "sprintf"
+	if e := ensureMinimimArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	format, e := ensureStringArg(args[0], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	verbs, padding, e := formatVerbs(format)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if len(verbs) != len(args)-1 {
+		return Variant{VariantType: VAR_ERROR, VariantValue: buildFormatArgumentCountError(len(verbs), len(args)-1)}
+	}
+
+	values := make([]interface{}, len(args)-1)
+	for i, a := range args[1:] {
+		if values[i], e = formatArg(a); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		if !verbAccepts(verbs[i], values[i]) {
+			return Variant{VariantType: VAR_ERROR, VariantValue: buildFormatVerbError(verbs[i], i+1, a.VariantType)}
+		}
+		padding = addedLength(padding, formattedLength(verbs[i], values[i]))
+	}
+
+	if e := ctx.ensureStringFits(addedLength(int64(len(format)), padding), functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return stringVariantOf(fmt.Sprintf(format, values...))
+}
+
+// maxFormatWidth is the largest width or precision that fmt accepts, beyond which it writes a complaint instead.
+const maxFormatWidth = 1_000_000
+
+// formatVerbs lists the verbs of the format in order, with a '*' for each width or precision taken from an argument,
+// so that they can be matched against the arguments before fmt writes its own complaints into the result.
+// It also adds up the widths and precisions written in the format, which may pad the result out that far.
+func formatVerbs(format string) ([]rune, int64, error) {
+	verbs := []rune{}
+	padding := int64(0)
+	runes := []rune(format)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '%' {
+			continue
+		}
+
+		number := int64(0)
+		for i++; i < len(runes) && strings.ContainsRune("+-# 0123456789.*[]", runes[i]); i++ {
+			switch {
+			case runes[i] == '*':
+				verbs = append(verbs, '*')
+			case runes[i] == '[':
+				return nil, 0, buildInvalidFormatError(format)
+			case unicode.IsDigit(runes[i]):
+				if number <= maxFormatWidth {
+					number = number*10 + int64(runes[i]-'0')
+				}
+				continue
+			}
+			padding, number = addedLength(padding, number), 0
+		}
+		padding = addedLength(padding, number)
+
+		if i == len(runes) {
+			return nil, 0, buildInvalidFormatError(format)
+		}
+		if runes[i] != '%' {
+			verbs = append(verbs, runes[i])
+		}
+	}
+	return verbs, padding, nil
+}
+
+// formattedLength bounds the length that fmt writes the value in with the verb, apart from the padding given in the
+// format. A width taken from an argument pads the next value. Quoting escapes a byte in at most four bytes, and hex
+// writes each byte in up to three with spaces between; other values are short unless they are long strings or floats
+// with many whole digits.
+func formattedLength(verb rune, value interface{}) int64 {
+	switch v := value.(type) {
+	case int64:
+		if verb != '*' {
+			// an int in binary, with its sign
+			return 65
+		}
+		if v < -maxFormatWidth || v > maxFormatWidth {
+			return 0
+		}
+		if v < 0 {
+			return -v
+		}
+		return v
+
+	case float64:
+		return int64(len(strconv.FormatFloat(v, 'f', 0, 64))) + 32
+
+	case string:
+		switch verb {
+		case 'q':
+			return 4*int64(len(v)) + 2
+		case 'x', 'X':
+			return 3 * int64(len(v))
+		}
+		return int64(len(v))
+	}
+	// bools and dates
+	return 64
+}
+
+// verbAccepts reports whether fmt can write the value, as converted by formatArg, with the verb.
+func verbAccepts(verb rune, value interface{}) bool {
+	switch value.(type) {
+	case int64:
+		return strings.ContainsRune("*vdbcoOqxXU", verb)
+	case float64:
+		return strings.ContainsRune("vbeEfFgGxX", verb)
+	case bool:
+		return strings.ContainsRune("vt", verb)
+	case string:
+		return strings.ContainsRune("vsqxX", verb)
+	}
+	// dates
+	return strings.ContainsRune("vsq", verb)
+}
+
+// formatNumber writes a number with its digits grouped in thousands, as (format-number x [decimals [locale]]).
+// Ints default to no decimals and floats to as many as they need. The locale defaults to "en". The length of the number
+// with the decimals asked for is checked against the limits before it is written.
+func (l *FormatLibrary) formatNumber(ctx *EvaluationContext, args []Variant) Variant {
+	functionName := "format-number"
+	if e := ensureMinimimArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureMaximumArity(args, 3, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureArgumentTypesMatch(args[:1], []EnumVariantType{VAR_INT, VAR_FLOAT}, []EnumVariantType{}, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	decimals := int64(-1)
+	if len(args) >= 2 {
+		d, e := ensureIndexArg(args[1], functionName)
+		if e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		if d < 0 {
+			return Variant{VariantType: VAR_ERROR, VariantValue: buildNegativeArgumentError(d, functionName)}
+		}
+		decimals = d
+	}
+
+	locale := "en"
+	if len(args) == 3 {
+		s, e := ensureStringArg(args[2], functionName)
+		if e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		locale = s
+	}
+
+	separators, e := lookupNumberLocale(locale)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	var digits string
+	if args[0].VariantType == VAR_INT && decimals <= 0 {
+		i, e := args[0].CoerceToInt()
+		if e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		digits = strconv.FormatInt(i, 10)
+	} else {
+		f, e := args[0].CoerceToFloat()
+		if e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+
+		if decimals > 0 {
+			// a float has no more than 309 whole digits, so they are cheap to write out and measure
+			whole := int64(len(strconv.FormatFloat(f, 'f', 0, 64)))
+			length := whole + whole/3*int64(len(separators.group)) + int64(len(separators.decimal))
+			if e := ctx.ensureStringFits(addedLength(length, decimals), functionName); e != nil {
+				return Variant{VariantType: VAR_ERROR, VariantValue: e}
+			}
+		}
+		digits = strconv.FormatFloat(f, 'f', int(decimals), 64)
+	}
+
+	return stringVariantOf(groupDigits(digits, separators))
+}
+
+// groupDigits rewrites a number formatted by strconv with the separators of a locale. NaN and infinities are left alone.
+func groupDigits(digits string, separators numberSeparators) string {
+	if strings.ContainsAny(digits, "NI") {
+		return digits
+	}
+
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+
+	whole, fraction := digits, ""
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		whole, fraction = digits[:i], digits[i+1:]
+	}
+
+	builder := strings.Builder{}
+	builder.WriteString(sign)
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			builder.WriteString(separators.group)
+		}
+		builder.WriteRune(r)
+	}
+
+	if fraction != "" {
+		builder.WriteString(separators.decimal)
+		builder.WriteString(fraction)
+	}
+	return builder.String()
+}
+
+func (l *FormatLibrary) InjectFunctions(functions FunctionTable) FunctionTable {
+	return functions
+}
+
+func (l *FormatLibrary) InjectContextFunctions(functions ContextFunctionTable) ContextFunctionTable {
+	functions["sprintf"] = l.sprintf
+	functions["format"] = l.sprintf
+	functions["format-number"] = l.formatNumber
+	return functions
+}
+
+func (l *FormatLibrary) Documentation() map[string]string {
+	return map[string]string{
+		"sprintf":       "(sprintf format args ...) formats the arguments with go's fmt verbs, such as %s and %.2f.",
+		"format":        "(format format args ...) formats the arguments with go's fmt verbs, such as %s and %.2f.",
+		"format-number": "(format-number x [decimals [locale]]) writes the number with its digits grouped in thousands.",
+	}
+}
+
+func (l *FormatLibrary) Signatures() map[string]Signature {
+	return map[string]Signature{
+		"sprintf":       atLeast(1, stringType, anyType),
+		"format":        atLeast(1, stringType, anyType),
+		"format-number": between(1, 3, []EnumVariantType{VAR_INT, VAR_FLOAT}, intType, stringType),
+	}
+}
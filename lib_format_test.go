@@ -0,0 +1,62 @@
+package golisp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	context := NewEvaluationContext(nil)
+	context.SymbolTable["name"] = stringVariant("Jo")
+	context.SymbolTable["qty"] = intVariant(3)
+	context.SymbolTable["price"] = Variant{VariantType: VAR_FLOAT, VariantValue: 2.5}
+	context.SymbolTable["amount"] = Variant{VariantType: VAR_FLOAT, VariantValue: 1234.5}
+
+	tests := [...]struct {
+		desc     string
+		input    string
+		expected Variant
+	}{
+		{desc: "sprintf", input: `(sprintf "%s: %.2f" name (* qty price))`, expected: stringVariant("Jo: 7.50")},
+		{desc: "format with ints", input: `(format "%d items, %05d" qty 42)`, expected: stringVariant("3 items, 00042")},
+		{desc: "format with %v", input: `(format "%v %v %v %v" 3.0 true :ok [1 "a"])`, expected: stringVariant(`3 true :ok [1 "a"]`)},
+		{desc: "format with a width from an argument", input: `(format "%*d|%%" 4 qty)`, expected: stringVariant("   3|%")},
+		{desc: "format with the wrong type", input: `(sprintf "%d" "x")`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildFormatVerbError('d', 1, VAR_STRING)}},
+		{desc: "format with a missing argument", input: `(sprintf "%s: %d" name)`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildFormatArgumentCountError(2, 1)}},
+		{desc: "format with an extra argument", input: `(sprintf "%s" name qty)`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildFormatArgumentCountError(1, 2)}},
+		{desc: "format without a verb", input: `(sprintf "100%")`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildInvalidFormatError("100%")}},
+		{desc: "format with a non-string format", input: `(format 1)`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnacceptableTypeError(VAR_INT, "sprintf")}},
+		{desc: "format propagates errors", input: `(format "%v" nope)`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnresolvedIdentifierError("nope")}},
+		{desc: "format-number int", input: `(format-number 1234567)`, expected: stringVariant("1,234,567")},
+		{desc: "format-number negative", input: `(format-number -1234)`, expected: stringVariant("-1,234")},
+		{desc: "format-number small", input: `(format-number 123)`, expected: stringVariant("123")},
+		{desc: "format-number float", input: `(format-number amount)`, expected: stringVariant("1,234.5")},
+		{desc: "format-number decimals", input: `(format-number 1234567 2)`, expected: stringVariant("1,234,567.00")},
+		{desc: "format-number rounds", input: `(format-number 0.125 2)`, expected: stringVariant("0.12")},
+		{desc: "format-number de", input: `(format-number 1234567.891 2 "de")`, expected: stringVariant("1.234.567,89")},
+		{desc: "format-number region falls back", input: `(format-number amount 1 "fr-CA")`, expected: stringVariant("1\u202f234,5")},
+		{desc: "format-number de-CH", input: `(format-number amount 1 "de-CH")`, expected: stringVariant("1'234.5")},
+		{desc: "format-number unknown locale", input: `(format-number 1 0 "xx")`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnknownLocaleError("xx")}},
+		{desc: "format-number of a string", input: `(format-number "1")`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnacceptableTypeError(VAR_STRING, "format-number")}},
+		{desc: "interpolation", input: `$"Hello {name}, you owe {(* qty price)}"`, expected: stringVariant("Hello Jo, you owe 7.5")},
+		{desc: "interpolation without expressions", input: `$"plain"`, expected: stringVariant("plain")},
+		{desc: "empty interpolation", input: `$""`, expected: stringVariant("")},
+		{desc: "interpolation of collections", input: `$"{[1 2]} and {{:a 1}}"`, expected: stringVariant("[1 2] and {:a 1}")},
+		{desc: "escaped braces", input: `$"{{literal}} {qty}"`, expected: stringVariant("{literal} 3")},
+		{desc: "interpolation with a map literal", input: `$"{(get {:a 1} :a)}"`, expected: stringVariant("1")},
+		{desc: "interpolation with format-number", input: `$"Total: {(format-number amount 2)}"`, expected: stringVariant("Total: 1,234.50")},
+		{desc: "interpolation error", input: `$"Hello {nope}"`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnresolvedIdentifierError("nope")}},
+		{desc: "interpolation in a list", input: `(concat $"{qty}" "!")`, expected: stringVariant("3!")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			sexpr, e := Parse(test.input)
+			assert.Nil(t, e, "parse error")
+
+			actual := sexpr.Eval(context)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package golisp
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+type JSONLibrary struct {
+}
+
+func (l *JSONLibrary) Namespace() string {
+	return "json"
+}
+
+func (l *JSONLibrary) parse(args []Variant) Variant {
+	functionName := "json-parse"
+	if e := ensureExactArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	s, e := ensureStringArg(args[0], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	v, e := ParseJSON(s)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return v
+}
+
+func (l *JSONLibrary) stringify(args []Variant) Variant {
+	functionName := "json-stringify"
+	if e := ensureMinimimArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureMaximumArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureTypeIsNotInvalid(args[0]); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	pretty := false
+	if len(args) == 2 {
+		if e := ensureBooleanArgs(args[1:], functionName); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		var e error
+		if pretty, e = args[1].CoerceToBool(); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+	}
+
+	encoded, e := json.Marshal(args[0])
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if pretty {
+		indented := &bytes.Buffer{}
+		if e := json.Indent(indented, encoded, "", "  "); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		encoded = indented.Bytes()
+	}
+
+	return Variant{VariantType: VAR_STRING, VariantValue: string(encoded)}
+}
+
+// splitJSONPath accepts paths like "$.lines[0].sku" as well as "lines.0.sku".
+func splitJSONPath(path string) ([]string, error) {
+	trimmed := strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if trimmed == "" {
+		return []string{}, nil
+	}
+
+	trimmed = strings.ReplaceAll(strings.ReplaceAll(trimmed, "[", "."), "]", "")
+	segments := strings.Split(strings.TrimPrefix(trimmed, "."), ".")
+	for _, s := range segments {
+		if s == "" {
+			return nil, buildInvalidJSONPathError(path)
+		}
+	}
+	return segments, nil
+}
+
+func (l *JSONLibrary) path(args []Variant) Variant {
+	functionName := "json-path"
+	if e := ensureExactArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureContainerArg(args, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	path, e := ensureStringArg(args[1], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	segments, e := splitJSONPath(path)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	v := args[0]
+	for _, segment := range segments {
+		switch v.VariantType {
+		case VAR_MAP, VAR_OBJECT, VAR_LIST, VAR_VECTOR:
+		default:
+			return Variant{VariantType: VAR_NULL}
+		}
+
+		r, found, e := getPathSegment(v, segment)
+		if e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		if !found {
+			return Variant{VariantType: VAR_NULL}
+		}
+		v = r
+	}
+	return v
+}
+
+func (l *JSONLibrary) InjectFunctions(functions FunctionTable) FunctionTable {
+	functions["json-parse"] = l.parse
+	functions["json-stringify"] = l.stringify
+	functions["json-path"] = l.path
+	return functions
+}
+
+func (l *JSONLibrary) Documentation() map[string]string {
+	return map[string]string{
+		"json-parse":     "(json-parse s) reads the JSON document in the string.",
+		"json-stringify": "(json-stringify x [pretty]) writes the value as JSON, indented if pretty is true.",
+		"json-path":      "(json-path x path) is the value at a path such as \"$.lines[0].sku\", or NIL.",
+	}
+}
+
+func (l *JSONLibrary) Signatures() map[string]Signature {
+	return map[string]Signature{
+		"json-parse":     exactly(1, stringType),
+		"json-stringify": between(1, 2, anyType, booleanTypes),
+		"json-path":      exactly(2, []EnumVariantType{VAR_VECTOR, VAR_MAP}, stringType),
+	}
+}
@@ -0,0 +1,40 @@
+package golisp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONLibrary(t *testing.T) {
+	context := NewEvaluationContext(nil)
+	event, _ := ParseJSON(`{"order":{"lines":[{"sku":"A-1","qty":2},{"sku":"B-2","qty":3}]}}`)
+	context.SymbolTable["event"] = event
+
+	tests := [...]struct {
+		desc     string
+		input    string
+		expected Variant
+	}{
+		{desc: "parse", input: `(json-parse "[1, 2.0]")`, expected: Variant{VariantType: VAR_VECTOR, VariantValue: []Variant{{VariantType: VAR_INT, VariantValue: int64(1)}, {VariantType: VAR_FLOAT, VariantValue: float64(2)}}}},
+		{desc: "parse error", input: `(json-parse 1)`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnacceptableTypeError(VAR_INT, "json-parse")}},
+		{desc: "stringify", input: `(json-stringify (+ 1 2.0))`, expected: stringVariant("3.0")},
+		{desc: "stringify pretty", input: `(json-stringify event.order.lines.0 true)`, expected: stringVariant("{\n  \"sku\": \"A-1\",\n  \"qty\": 2\n}")},
+		{desc: "stringify propagates errors", input: `(json-stringify nope)`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnresolvedIdentifierError("nope")}},
+		{desc: "path", input: `(json-path event "$.order.lines[1].sku")`, expected: stringVariant("B-2")},
+		{desc: "dotted path", input: `(* 10 (json-path event "order.lines.1.qty"))`, expected: Variant{VariantType: VAR_INT, VariantValue: int64(30)}},
+		{desc: "missing path", input: `(json-path event "order.customer.name")`, expected: Variant{VariantType: VAR_NULL}},
+		{desc: "invalid path", input: `(json-path event "order..lines")`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildInvalidJSONPathError("order..lines")}},
+		{desc: "dotted identifier into json", input: `event.order.lines.0.sku`, expected: stringVariant("A-1")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			sexpr, e := Parse(test.input)
+			assert.Nil(t, e, "parse error")
+
+			actual := sexpr.Eval(context)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
@@ -3,6 +3,10 @@ package golisp
 type LogicalLibrary struct {
 }
 
+func (l *LogicalLibrary) Namespace() string {
+	return "logic"
+}
+
 func (l *LogicalLibrary) not(args []Variant) Variant {
 	return unaryOpBoolean(
 		args,
@@ -79,3 +83,35 @@ func (l *LogicalLibrary) InjectFunctions(functions FunctionTable) FunctionTable
 	functions["!"] = l.not
 	return functions
 }
+
+func (l *LogicalLibrary) Documentation() map[string]string {
+	return map[string]string{
+		"or":   "(or a b ...) is true if any of the arguments is true.",
+		"nor":  "(nor a b ...) is true if none of the arguments is true.",
+		"and":  "(and a b ...) is true if all the arguments are true.",
+		"nand": "(nand a b ...) is true unless all the arguments are true.",
+		"xor":  "(xor a b) is true if exactly one of the arguments is true.",
+		"xnor": "(xnor a b) is true if both arguments are true or both are false.",
+		"not":  "(not a) is true if a is false.",
+		"||":   "(|| a b ...) is true if any of the arguments is true.",
+		"&&":   "(&& a b ...) is true if all the arguments are true.",
+		"^^":   "(^^ a b) is true if exactly one of the arguments is true.",
+		"!":    "(! a) is true if a is false.",
+	}
+}
+
+func (l *LogicalLibrary) Signatures() map[string]Signature {
+	return map[string]Signature{
+		"or":   atLeast(2, booleanTypes),
+		"nor":  atLeast(2, booleanTypes),
+		"and":  atLeast(2, booleanTypes),
+		"nand": atLeast(2, booleanTypes),
+		"xor":  exactly(2, booleanTypes, booleanTypes),
+		"xnor": exactly(2, booleanTypes, booleanTypes),
+		"not":  exactly(1, booleanTypes),
+		"||":   atLeast(2, booleanTypes),
+		"&&":   atLeast(2, booleanTypes),
+		"^^":   exactly(2, booleanTypes, booleanTypes),
+		"!":    exactly(1, booleanTypes),
+	}
+}
@@ -0,0 +1,241 @@
+package golisp
+
+type MapLibrary struct {
+}
+
+func (l *MapLibrary) Namespace() string {
+	return "map"
+}
+
+var mapType = []EnumVariantType{VAR_MAP}
+
+func ensureMapArgs(args []Variant, functionName string) error {
+	return ensureArgumentTypesMatch(args, mapType, []EnumVariantType{}, functionName)
+}
+
+func ensureValidArgs(args []Variant) error {
+	for _, a := range args {
+		if e := ensureTypeIsNotInvalid(a); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+func assocPairs(m *VariantMap, pairs []Variant, functionName string) Variant {
+	if len(pairs)%2 != 0 {
+		return Variant{VariantType: VAR_ERROR, VariantValue: buildEvenArityError(functionName)}
+	}
+
+	if e := ensureValidArgs(pairs); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	result := m.clone()
+	for i := 0; i < len(pairs); i += 2 {
+		if e := result.put(pairs[i], pairs[i+1]); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+	}
+	return Variant{VariantType: VAR_MAP, VariantValue: result}
+}
+
+func (l *MapLibrary) hashMap(args []Variant) Variant {
+	return assocPairs(NewVariantMap(), args, "hash-map")
+}
+
+func (l *MapLibrary) assoc(args []Variant) Variant {
+	functionName := "assoc"
+	if e := ensureMinimimArity(args, 3, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureMapArgs(args[:1], functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	m, e := args[0].GetMapValue()
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	return assocPairs(m, args[1:], functionName)
+}
+
+func (l *MapLibrary) dissoc(args []Variant) Variant {
+	functionName := "dissoc"
+	if e := ensureMinimimArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureMapArgs(args[:1], functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureValidArgs(args[1:]); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	m, e := args[0].GetMapValue()
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	for _, k := range args[1:] {
+		if m, e = m.Delete(k); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+	}
+	return Variant{VariantType: VAR_MAP, VariantValue: m}
+}
+
+func unaryOpMap(args []Variant, unaryOp func(*VariantMap) Variant, functionName string) Variant {
+	if e := ensureExactArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureMapArgs(args, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	m, e := args[0].GetMapValue()
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	return unaryOp(m)
+}
+
+func (l *MapLibrary) keys(args []Variant) Variant {
+	return unaryOpMap(
+		args,
+		func(m *VariantMap) Variant { return Variant{VariantType: VAR_LIST, VariantValue: m.Keys()} },
+		"keys")
+}
+
+func (l *MapLibrary) vals(args []Variant) Variant {
+	return unaryOpMap(
+		args,
+		func(m *VariantMap) Variant { return Variant{VariantType: VAR_LIST, VariantValue: m.Values()} },
+		"vals")
+}
+
+func (l *MapLibrary) merge(args []Variant) Variant {
+	functionName := "merge"
+	if e := ensureMinimimArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureMapArgs(args, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	result, e := args[0].GetMapValue()
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	for _, a := range args[1:] {
+		m, e := a.GetMapValue()
+		if e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		result = result.Merge(m)
+	}
+	return Variant{VariantType: VAR_MAP, VariantValue: result}
+}
+
+// contains? is true if the map has the key. It is registered as map/contains? only, since contains? on its own belongs to the string library.
+func (l *MapLibrary) contains(args []Variant) Variant {
+	functionName := "map/contains?"
+	if e := ensureExactArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureMapArgs(args[:1], functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureTypeIsNotInvalid(args[1]); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	m, e := args[0].GetMapValue()
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	_, found := m.Get(args[1])
+	return Variant{VariantType: VAR_BOOL, VariantValue: found}
+}
+
+// update replaces the value under the key with the result of calling the function on it, followed by any extra arguments.
+// A missing key is passed to the function as NIL.
+func (l *MapLibrary) update(args []Variant) Variant {
+	functionName := "update"
+	if e := ensureMinimimArity(args, 3, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureMapArgs(args[:1], functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureTypeIsNotInvalid(args[1]); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	m, e := args[0].GetMapValue()
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	current, _ := m.Get(args[1])
+	updated := callFunction(args[2], append([]Variant{current}, args[3:]...), functionName)
+	if e := ensureTypeIsNotInvalid(updated); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if m, e = m.Set(args[1], updated); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return Variant{VariantType: VAR_MAP, VariantValue: m}
+}
+
+func (l *MapLibrary) InjectFunctions(functions FunctionTable) FunctionTable {
+	functions["hash-map"] = l.hashMap
+	functions["assoc"] = l.assoc
+	functions["dissoc"] = l.dissoc
+	functions["keys"] = l.keys
+	functions["vals"] = l.vals
+	functions["merge"] = l.merge
+	functions[QualifiedName(l.Namespace(), "contains?")] = l.contains
+	functions["update"] = l.update
+	return functions
+}
+
+func (l *MapLibrary) Documentation() map[string]string {
+	return map[string]string{
+		"hash-map":      "(hash-map k v ...) makes a map of the keys and values.",
+		"assoc":         "(assoc m k v ...) is a copy of the map with the keys set to the values.",
+		"dissoc":        "(dissoc m k ...) is a copy of the map without the keys.",
+		"keys":          "(keys m) is a list of the keys of the map.",
+		"vals":          "(vals m) is a list of the values of the map.",
+		"merge":         "(merge m ...) merges the maps, with the values of later maps taking precedence.",
+		"map/contains?": "(map/contains? m k) is true if the map has the key.",
+		"update":        "(update m k f args ...) is a copy of the map with the value under k replaced by (f value args ...).",
+	}
+}
+
+func (l *MapLibrary) Signatures() map[string]Signature {
+	return map[string]Signature{
+		"hash-map":      {MinArity: 0, MaxArity: VariadicArity, ArityStep: 2},
+		"assoc":         inPairs(1, mapType, anyType),
+		"dissoc":        atLeast(1, mapType, anyType),
+		"keys":          exactly(1, mapType),
+		"vals":          exactly(1, mapType),
+		"merge":         atLeast(1, mapType),
+		"map/contains?": exactly(2, mapType, anyType),
+		"update":        atLeast(3, mapType, anyType, functionType, anyType),
+	}
+}
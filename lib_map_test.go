@@ -0,0 +1,102 @@
+package golisp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var maps = &(MapLibrary{})
+
+func intVariant(i int64) Variant {
+	return Variant{VariantType: VAR_INT, VariantValue: i}
+}
+
+func mapVariant(kvs ...Variant) Variant {
+	m := NewVariantMap()
+	for i := 0; i < len(kvs); i += 2 {
+		m.put(kvs[i], kvs[i+1])
+	}
+	return Variant{VariantType: VAR_MAP, VariantValue: m}
+}
+
+func TestAssoc(t *testing.T) {
+	tests := [...]struct {
+		desc     string
+		input    []Variant
+		expected Variant
+	}{
+		{
+			desc:     "add and replace",
+			input:    []Variant{mapVariant(stringVariant("a"), intVariant(1)), stringVariant("b"), intVariant(2), stringVariant("a"), intVariant(3)},
+			expected: mapVariant(stringVariant("a"), intVariant(3), stringVariant("b"), intVariant(2)),
+		},
+		{
+			desc:     "odd pairs",
+			input:    []Variant{mapVariant(), stringVariant("a"), intVariant(1), stringVariant("b")},
+			expected: Variant{VariantType: VAR_ERROR, VariantValue: buildEvenArityError("assoc")},
+		},
+		{
+			desc:     "not a map",
+			input:    []Variant{intVariant(1), stringVariant("a"), intVariant(1)},
+			expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnacceptableTypeError(VAR_INT, "assoc")},
+		},
+		{
+			desc:     "unhashable key",
+			input:    []Variant{mapVariant(), {VariantType: VAR_FUNCTION, VariantValue: FunctionType(maps.keys)}, intVariant(1)},
+			expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnhashableTypeError(VAR_FUNCTION)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			actual := maps.assoc(test.input)
+			assert.Equal(t, test.expected, actual, "computation error")
+		})
+	}
+}
+
+func TestDissoc(t *testing.T) {
+	input := mapVariant(stringVariant("a"), intVariant(1), stringVariant("b"), intVariant(2), stringVariant("c"), intVariant(3))
+
+	actual := maps.dissoc([]Variant{input, stringVariant("b"), stringVariant("z")})
+	assert.Equal(t, mapVariant(stringVariant("a"), intVariant(1), stringVariant("c"), intVariant(3)), actual)
+	assert.Equal(t, 3, input.VariantValue.(*VariantMap).Len(), "input must not be modified")
+}
+
+func TestMap(t *testing.T) {
+	context := NewEvaluationContext(nil)
+
+	tests := [...]struct {
+		desc     string
+		input    string
+		expected Variant
+	}{
+		{desc: "literal", input: `{"a" 1 "b" (+ 1 1)}`, expected: mapVariant(stringVariant("a"), intVariant(1), stringVariant("b"), intVariant(2))},
+		{desc: "empty literal", input: `{}`, expected: mapVariant()},
+		{desc: "nested literal", input: `{"a" {1 2}}`, expected: mapVariant(stringVariant("a"), mapVariant(intVariant(1), intVariant(2)))},
+		{desc: "literal with error", input: `{"a" nope}`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnresolvedIdentifierError("nope")}},
+		{desc: "hash-map", input: `(hash-map "a" 1)`, expected: mapVariant(stringVariant("a"), intVariant(1))},
+		{desc: "get", input: `(get {"a" 1} "a")`, expected: intVariant(1)},
+		{desc: "get with default", input: `(get {"a" 1} "b" 0)`, expected: intVariant(0)},
+		{desc: "keys", input: `(keys {"b" 1 "a" 2})`, expected: Variant{VariantType: VAR_LIST, VariantValue: []Variant{stringVariant("b"), stringVariant("a")}}},
+		{desc: "vals", input: `(vals {"b" 1 "a" 2})`, expected: Variant{VariantType: VAR_LIST, VariantValue: []Variant{intVariant(1), intVariant(2)}}},
+		{desc: "merge", input: `(merge {"a" 1 "b" 2} {"b" 3} {"c" 4})`, expected: mapVariant(stringVariant("a"), intVariant(1), stringVariant("b"), intVariant(3), stringVariant("c"), intVariant(4))},
+		{desc: "map/contains?", input: `(map/contains? {"a" ()} "a")`, expected: Variant{VariantType: VAR_BOOL, VariantValue: true}},
+		{desc: "contains? on a map", input: `(contains? {"a" ()} "a")`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnacceptableTypeError(VAR_MAP, "contains?")}},
+		{desc: "update", input: `(update {"a" 1} "a" + 10)`, expected: mapVariant(stringVariant("a"), intVariant(11))},
+		{desc: "update missing", input: `(update {"a" 1} "b" json-stringify)`, expected: mapVariant(stringVariant("a"), intVariant(1), stringVariant("b"), stringVariant("null"))},
+		{desc: "update with non-function", input: `(update {"a" 1} "a" 1)`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnacceptableTypeError(VAR_INT, "update")}},
+		{desc: "structured decision", input: `(concat "decision: " {"approved" false "reason" "limit"})`, expected: stringVariant(`decision: {"approved" false "reason" "limit"}`)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			sexpr, e := Parse(test.input)
+			assert.Nil(t, e, "parse error")
+
+			actual := sexpr.Eval(context)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
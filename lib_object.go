@@ -0,0 +1,145 @@
+package golisp
+
+import "fmt"
+
+type ObjectLibrary struct {
+}
+
+func (l *ObjectLibrary) Namespace() string {
+	return "obj"
+}
+
+var containerTypes = []EnumVariantType{VAR_MAP, VAR_OBJECT, VAR_LIST, VAR_VECTOR}
+
+func ensureContainerArg(args []Variant, functionName string) error {
+	return ensureArgumentTypesMatch(args[:1], containerTypes, []EnumVariantType{}, functionName)
+}
+
+// get and get-in read the functions that host objects hold, which the sandbox of the context may not permit.
+func (l *ObjectLibrary) get(ctx *EvaluationContext, args []Variant) Variant {
+	functionName := "get"
+	if e := ensureMinimimArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureMaximumArity(args, 3, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureContainerArg(args, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureTypeIsNotInvalid(args[1]); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	v, found, e := getProperty(args[0], args[1])
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if !found && len(args) == 3 {
+		return args[2]
+	}
+
+	if e := ctx.checkFunctionProperty(fmt.Sprint(args[1].VariantValue), v); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return v
+}
+
+func (l *ObjectLibrary) getIn(ctx *EvaluationContext, args []Variant) Variant {
+	functionName := "get-in"
+	if e := ensureMinimimArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureContainerArg(args, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	path := args[1:]
+	if len(path) == 1 && (path[0].VariantType == VAR_LIST || path[0].VariantType == VAR_VECTOR) {
+		items, e := path[0].GetSequenceValue()
+		if e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		path = items
+	}
+
+	v := args[0]
+	for _, key := range path {
+		if e := ensureTypeIsNotInvalid(key); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+
+		switch v.VariantType {
+		case VAR_MAP, VAR_OBJECT, VAR_LIST, VAR_VECTOR:
+		default:
+			return Variant{VariantType: VAR_NULL}
+		}
+
+		r, found, e := getProperty(v, key)
+		if e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		if !found {
+			return Variant{VariantType: VAR_NULL}
+		}
+		v = r
+	}
+
+	if e := ctx.checkFunctionProperty(fmt.Sprint(path[len(path)-1].VariantValue), v); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return v
+}
+
+func (l *ObjectLibrary) has(args []Variant) Variant {
+	functionName := "has?"
+	if e := ensureExactArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureContainerArg(args, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureTypeIsNotInvalid(args[1]); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	_, found, e := getProperty(args[0], args[1])
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return Variant{VariantType: VAR_BOOL, VariantValue: found}
+}
+
+func (l *ObjectLibrary) InjectFunctions(functions FunctionTable) FunctionTable {
+	functions["has?"] = l.has
+	return functions
+}
+
+func (l *ObjectLibrary) InjectContextFunctions(functions ContextFunctionTable) ContextFunctionTable {
+	functions["get"] = l.get
+	functions["get-in"] = l.getIn
+	return functions
+}
+
+func (l *ObjectLibrary) Documentation() map[string]string {
+	return map[string]string{
+		"get":    "(get x key [default]) is the property, entry or item of x under the key, or the default.",
+		"get-in": "(get-in x key ...) follows the keys through nested objects, maps and lists, or gives NIL.",
+		"has?":   "(has? x key) is true if x has a property, entry or item under the key.",
+	}
+}
+
+func (l *ObjectLibrary) Signatures() map[string]Signature {
+	return map[string]Signature{
+		"get":    between(2, 3, containerTypes),
+		"get-in": atLeast(2, containerTypes, anyType),
+		"has?":   exactly(2, containerTypes),
+	}
+}
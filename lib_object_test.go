@@ -0,0 +1,165 @@
+package golisp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var object = &(ObjectLibrary{})
+
+type testAddress struct {
+	Country string `golisp:"country"`
+}
+
+type testShopper struct {
+	Name    string
+	Address testAddress `golisp:"address"`
+	Lines   []testLineItem
+	Meta    map[string]int
+}
+
+type testLazyTotals struct {
+	calls int
+}
+
+func (t *testLazyTotals) GetProperty(name string) (interface{}, bool) {
+	if name != "total" {
+		return nil, false
+	}
+	t.calls++
+	return 99.5, true
+}
+
+func TestGet(t *testing.T) {
+	shopper := Variant{VariantType: VAR_OBJECT, VariantValue: &testShopper{Name: "Ada", Address: testAddress{Country: "UK"}}}
+	m, _ := NewVariantMap().Set(stringVariant("a"), Variant{VariantType: VAR_INT, VariantValue: int64(1)})
+
+	tests := [...]struct {
+		desc     string
+		input    []Variant
+		expected Variant
+	}{
+		{
+			desc:     "struct field",
+			input:    []Variant{shopper, stringVariant("Name")},
+			expected: stringVariant("Ada"),
+		},
+		{
+			desc:     "nested struct stays an object",
+			input:    []Variant{shopper, stringVariant("address")},
+			expected: Variant{VariantType: VAR_OBJECT, VariantValue: testAddress{Country: "UK"}},
+		},
+		{
+			desc:     "missing field",
+			input:    []Variant{shopper, stringVariant("Address")},
+			expected: Variant{VariantType: VAR_NULL},
+		},
+		{
+			desc:     "missing field with default",
+			input:    []Variant{shopper, stringVariant("nope"), stringVariant("default")},
+			expected: stringVariant("default"),
+		},
+		{
+			desc:     "map key",
+			input:    []Variant{{VariantType: VAR_MAP, VariantValue: m}, stringVariant("a")},
+			expected: Variant{VariantType: VAR_INT, VariantValue: int64(1)},
+		},
+		{
+			desc:     "list index",
+			input:    []Variant{{VariantType: VAR_LIST, VariantValue: []Variant{stringVariant("x")}}, {VariantType: VAR_INT, VariantValue: int64(0)}},
+			expected: stringVariant("x"),
+		},
+		{
+			desc:     "lazy property",
+			input:    []Variant{{VariantType: VAR_OBJECT, VariantValue: &testLazyTotals{}}, stringVariant("total")},
+			expected: Variant{VariantType: VAR_FLOAT, VariantValue: float64(99.5)},
+		},
+		{
+			desc:     "not a container",
+			input:    []Variant{stringVariant("abc"), stringVariant("a")},
+			expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnacceptableTypeError(VAR_STRING, "get")},
+		},
+		{
+			desc:     "arity",
+			input:    []Variant{shopper},
+			expected: Variant{VariantType: VAR_ERROR, VariantValue: buildMinimumArityError(2, "get")},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			actual := object.get(nil, test.input)
+			assert.Equal(t, test.expected, actual, "computation error")
+		})
+	}
+}
+
+func TestGetIn(t *testing.T) {
+	shopper := Variant{VariantType: VAR_OBJECT, VariantValue: testShopper{Address: testAddress{Country: "UK"}, Lines: []testLineItem{{Sku: "A-1"}}}}
+
+	tests := [...]struct {
+		desc     string
+		input    []Variant
+		expected Variant
+	}{
+		{desc: "nested field", input: []Variant{shopper, stringVariant("address"), stringVariant("country")}, expected: stringVariant("UK")},
+		{desc: "through a list", input: []Variant{shopper, stringVariant("Lines"), {VariantType: VAR_INT, VariantValue: int64(0)}, stringVariant("Sku")}, expected: stringVariant("A-1")},
+		{desc: "path as a list", input: []Variant{shopper, {VariantType: VAR_LIST, VariantValue: []Variant{stringVariant("address"), stringVariant("country")}}}, expected: stringVariant("UK")},
+		{desc: "missing", input: []Variant{shopper, stringVariant("address"), stringVariant("city")}, expected: Variant{VariantType: VAR_NULL}},
+		{desc: "past a leaf", input: []Variant{shopper, stringVariant("address"), stringVariant("country"), stringVariant("code")}, expected: Variant{VariantType: VAR_NULL}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			actual := object.getIn(nil, test.input)
+			assert.Equal(t, test.expected, actual, "computation error")
+		})
+	}
+}
+
+func TestHas(t *testing.T) {
+	shopper := Variant{VariantType: VAR_OBJECT, VariantValue: &testShopper{Meta: map[string]int{"visits": 3}}}
+
+	assert.Equal(t, Variant{VariantType: VAR_BOOL, VariantValue: true}, object.has([]Variant{shopper, stringVariant("Meta")}))
+	assert.Equal(t, Variant{VariantType: VAR_BOOL, VariantValue: false}, object.has([]Variant{shopper, stringVariant("meta")}))
+}
+
+func TestEval_HostObjects(t *testing.T) {
+	lazy := &testLazyTotals{}
+	context := NewEvaluationContext(nil)
+	context.SymbolTable["order"] = Variant{VariantType: VAR_OBJECT, VariantValue: &testShopper{
+		Name:    "Ada",
+		Address: testAddress{Country: "UK"},
+		Lines:   []testLineItem{{Sku: "A-1", Quantity: 2, Price: 1.5}},
+		Meta:    map[string]int{"visits": 3},
+	}}
+	context.SymbolTable["totals"] = Variant{VariantType: VAR_OBJECT, VariantValue: lazy}
+
+	tests := [...]struct {
+		desc     string
+		input    string
+		expected Variant
+	}{
+		{desc: "dotted field", input: "order.address.country", expected: stringVariant("UK")},
+		{desc: "dotted list index", input: "(* order.Lines.0.Quantity order.Lines.0.Price)", expected: Variant{VariantType: VAR_FLOAT, VariantValue: float64(3)}},
+		{desc: "dotted map entry", input: "(+ 1 order.Meta.visits)", expected: Variant{VariantType: VAR_INT, VariantValue: int64(4)}},
+		{desc: "lazy property", input: "totals.total", expected: Variant{VariantType: VAR_FLOAT, VariantValue: float64(99.5)}},
+		{desc: "get", input: "(get order \"Name\")", expected: stringVariant("Ada")},
+		{desc: "get-in", input: "(get-in order \"address\" \"country\")", expected: stringVariant("UK")},
+		{desc: "unresolved property", input: "order.address.city", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnresolvedPropertyError("order.address.city", "city")}},
+		{desc: "unresolved root", input: "customer.name", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnresolvedIdentifierError("customer")}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			sexpr, e := Parse(test.input)
+			assert.Nil(t, e, "parse error")
+
+			actual := sexpr.Eval(context)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+
+	assert.Equal(t, 1, lazy.calls)
+}
@@ -0,0 +1,230 @@
+package golisp
+
+import (
+	"regexp"
+	"sync"
+)
+
+// maxCachedPatterns bounds the number of compiled patterns a RegexLibrary keeps, so scripts that build patterns
+// dynamically can't grow the cache without limit.
+const maxCachedPatterns = 256
+
+// RegexLibrary matches strings against RE2 patterns, which run in time linear in the size of their input.
+// Compiled patterns are cached by the library, so every context that loads its own libraries has its own cache.
+type RegexLibrary struct {
+	mutex    sync.Mutex
+	compiled map[string]*regexp.Regexp
+}
+
+func (l *RegexLibrary) Namespace() string {
+	return "re"
+}
+
+func (l *RegexLibrary) compile(pattern string) (*regexp.Regexp, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if re, found := l.compiled[pattern]; found {
+		return re, nil
+	}
+
+	re, e := regexp.Compile(pattern)
+	if e != nil {
+		return nil, buildInvalidRegexError(pattern, e)
+	}
+
+	if l.compiled == nil || len(l.compiled) >= maxCachedPatterns {
+		l.compiled = map[string]*regexp.Regexp{}
+	}
+	l.compiled[pattern] = re
+	return re, nil
+}
+
+// ensurePatternArgs checks that the pattern and every other argument are strings, and compiles the pattern.
+func (l *RegexLibrary) ensurePatternArgs(args []Variant, arity int, functionName string) (*regexp.Regexp, []string, error) {
+	if e := ensureExactArity(args, arity, functionName); e != nil {
+		return nil, nil, e
+	}
+
+	if e := ensureArgumentTypesMatch(args, []EnumVariantType{VAR_STRING}, []EnumVariantType{}, functionName); e != nil {
+		return nil, nil, e
+	}
+
+	strs := make([]string, len(args))
+	for i, a := range args {
+		s, e := a.CoerceToString()
+		if e != nil {
+			return nil, nil, e
+		}
+		strs[i] = s
+	}
+
+	re, e := l.compile(strs[0])
+	if e != nil {
+		return nil, nil, e
+	}
+	return re, strs[1:], nil
+}
+
+func stringsToVector(strs []string) Variant {
+	items := make([]Variant, len(strs))
+	for i, s := range strs {
+		items[i] = stringVariantOf(s)
+	}
+	return Variant{VariantType: VAR_VECTOR, VariantValue: items}
+}
+
+// match checks that the pattern matches the whole string, rather than just a part of it.
+func (l *RegexLibrary) match(args []Variant) Variant {
+	re, strs, e := l.ensurePatternArgs(args, 2, "re-match?")
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	anchored, e := l.compile("^(?:" + re.String() + ")$")
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return boolVariantOf(anchored.MatchString(strs[0]))
+}
+
+// find returns the first match of the pattern in the string, or NIL if there is none.
+func (l *RegexLibrary) find(args []Variant) Variant {
+	re, strs, e := l.ensurePatternArgs(args, 2, "re-find")
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	loc := re.FindStringIndex(strs[0])
+	if loc == nil {
+		return Variant{VariantType: VAR_NULL}
+	}
+	return stringVariantOf(strs[0][loc[0]:loc[1]])
+}
+
+func (l *RegexLibrary) findAll(args []Variant) Variant {
+	re, strs, e := l.ensurePatternArgs(args, 2, "re-find-all")
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return stringsToVector(re.FindAllString(strs[0], -1))
+}
+
+// groups returns a map of the groups in the first match, or NIL if there is none. Named groups are keyed by name and
+// the others by number, with the whole match under 0. Groups that did not take part in the match are NIL.
+func (l *RegexLibrary) groups(args []Variant) Variant {
+	re, strs, e := l.ensurePatternArgs(args, 2, "re-groups")
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	loc := re.FindStringSubmatchIndex(strs[0])
+	if loc == nil {
+		return Variant{VariantType: VAR_NULL}
+	}
+
+	result := NewVariantMap()
+	for i, name := range re.SubexpNames() {
+		key := Variant{VariantType: VAR_INT, VariantValue: int64(i)}
+		if name != "" {
+			key = stringVariantOf(name)
+		}
+
+		value := Variant{VariantType: VAR_NULL}
+		if loc[2*i] >= 0 {
+			value = stringVariantOf(strs[0][loc[2*i]:loc[2*i+1]])
+		}
+
+		if e := result.put(key, value); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+	}
+	return Variant{VariantType: VAR_MAP, VariantValue: result}
+}
+
+// replace replaces every match of the pattern, expanding $1 or ${name} in the replacement to the matched groups.
+// The length of the result is checked against the limits before it is built.
+func (l *RegexLibrary) replace(ctx *EvaluationContext, args []Variant) Variant {
+	functionName := "re-replace"
+	re, strs, e := l.ensurePatternArgs(args, 3, functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ctx.ensureStringFits(replacedLength(re, strs[0], strs[1]), functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return stringVariantOf(re.ReplaceAllString(strs[0], strs[1]))
+}
+
+// replacedLength is at least the length of the string with every match of the pattern replaced. A group is no longer
+// than the match it is part of, so each reference to a group in the replacement is counted as the whole match.
+func replacedLength(re *regexp.Regexp, s string, replacement string) int64 {
+	groups := make([]int, 2*(re.NumSubexp()+1))
+	for i := range groups {
+		groups[i] = -1
+	}
+	literal := int64(len(re.ExpandString(nil, replacement, "", groups)))
+
+	// with every group matching one character, the expansion grows by one for each reference
+	for i := range groups {
+		groups[i] = i % 2
+	}
+	references := int64(len(re.ExpandString(nil, replacement, "x", groups))) - literal
+
+	// replacing the matches with nothing counts them without keeping their positions
+	matches, matched := int64(0), int64(0)
+	re.ReplaceAllStringFunc(s, func(match string) string {
+		matches++
+		matched += int64(len(match))
+		return ""
+	})
+
+	length := addedLength(int64(len(s))-matched, repeatedLength(literal, matches))
+	return addedLength(length, repeatedLength(references, matched))
+}
+
+func (l *RegexLibrary) split(args []Variant) Variant {
+	re, strs, e := l.ensurePatternArgs(args, 2, "re-split")
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return stringsToVector(re.Split(strs[0], -1))
+}
+
+func (l *RegexLibrary) InjectFunctions(functions FunctionTable) FunctionTable {
+	functions["re-match?"] = l.match
+	functions["re-find"] = l.find
+	functions["re-find-all"] = l.findAll
+	functions["re-groups"] = l.groups
+	functions["re-split"] = l.split
+	return functions
+}
+
+func (l *RegexLibrary) InjectContextFunctions(functions ContextFunctionTable) ContextFunctionTable {
+	functions["re-replace"] = l.replace
+	return functions
+}
+
+func (l *RegexLibrary) Documentation() map[string]string {
+	return map[string]string{
+		"re-match?":   "(re-match? pattern s) is true if the pattern matches the whole string.",
+		"re-find":     "(re-find pattern s) is the first match of the pattern in the string, or NIL.",
+		"re-find-all": "(re-find-all pattern s) is a vector of the matches of the pattern in the string.",
+		"re-groups":   "(re-groups pattern s) is a map of the groups of the first match, by name or number, or NIL.",
+		"re-replace":  "(re-replace pattern s replacement) replaces every match, expanding $1 or ${name} to the groups.",
+		"re-split":    "(re-split pattern s) is a vector of the pieces of the string between matches.",
+	}
+}
+
+func (l *RegexLibrary) Signatures() map[string]Signature {
+	twoStrings := exactly(2, stringType, stringType)
+	return map[string]Signature{
+		"re-match?":   twoStrings,
+		"re-find":     twoStrings,
+		"re-find-all": twoStrings,
+		"re-groups":   twoStrings,
+		"re-replace":  exactly(3, stringType, stringType, stringType),
+		"re-split":    twoStrings,
+	}
+}
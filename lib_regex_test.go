@@ -0,0 +1,69 @@
+package golisp
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegexLibrary_CachesPatterns(t *testing.T) {
+	l := &RegexLibrary{}
+
+	first, e := l.compile("[A-Z]{2}[0-9]+")
+	assert.Nil(t, e)
+
+	second, e := l.compile("[A-Z]{2}[0-9]+")
+	assert.Nil(t, e)
+	assert.Same(t, first, second)
+}
+
+func TestRegexLibrary_BoundsCache(t *testing.T) {
+	l := &RegexLibrary{}
+	for i := 0; i <= maxCachedPatterns; i++ {
+		_, e := l.compile(fmt.Sprintf("a{%d}", i))
+		assert.Nil(t, e)
+	}
+	assert.LessOrEqual(t, len(l.compiled), maxCachedPatterns)
+}
+
+func TestRegex(t *testing.T) {
+	context := NewEvaluationContext(nil)
+
+	_, compileError := regexp.Compile("(")
+
+	tests := [...]struct {
+		desc     string
+		input    string
+		expected Variant
+	}{
+		{desc: "re-match?", input: `(re-match? "[A-Z]{1,2}[0-9][A-Z0-9]? [0-9][A-Z]{2}" "SW1A 1AA")`, expected: boolVariant(true)},
+		{desc: "re-match? needs the whole string", input: `(re-match? "[0-9]+" "SKU-123")`, expected: boolVariant(false)},
+		{desc: "re-match? with alternation", input: `(re-match? "a|ab" "ab")`, expected: boolVariant(true)},
+		{desc: "invalid pattern", input: `(re-match? "(" "a")`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildInvalidRegexError("(", compileError)}},
+		{desc: "non-string input", input: `(re-find "a" 1)`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnacceptableTypeError(VAR_INT, "re-find")}},
+		{desc: "re-find", input: `(re-find "[0-9]+" "SKU-123-45")`, expected: stringVariant("123")},
+		{desc: "re-find nothing", input: `(re-find "[0-9]+" "SKU")`, expected: Variant{VariantType: VAR_NULL}},
+		{desc: "re-find-all", input: `(re-find-all "[0-9]+" "SKU-123-45")`, expected: vectorVariant(stringVariant("123"), stringVariant("45"))},
+		{desc: "re-find-all nothing", input: `(re-find-all "[0-9]+" "SKU")`, expected: vectorVariant()},
+		{desc: "re-groups", input: `(re-groups "(?P<user>[^@]+)@(?P<domain>.+)" "jo@example.com")`, expected: mapVariant(intVariant(0), stringVariant("jo@example.com"), stringVariant("user"), stringVariant("jo"), stringVariant("domain"), stringVariant("example.com"))},
+		{desc: "re-groups unnamed and missing", input: `(re-groups "([a-z]+)(-[0-9]+)?" "sku")`, expected: mapVariant(intVariant(0), stringVariant("sku"), intVariant(1), stringVariant("sku"), intVariant(2), Variant{VariantType: VAR_NULL})},
+		{desc: "re-groups nothing", input: `(re-groups "(a)" "b")`, expected: Variant{VariantType: VAR_NULL}},
+		{desc: "email domain", input: `(get (re-groups "@(?P<domain>.+)$" "jo@example.com") "domain")`, expected: stringVariant("example.com")},
+		{desc: "re-replace", input: `(re-replace "([a-z]+)-([0-9]+)" "abc-12 de-3" "$2:$1")`, expected: stringVariant("12:abc 3:de")},
+		{desc: "re-split", input: `(re-split "[,;] *" "a, b;c")`, expected: vectorVariant(stringVariant("a"), stringVariant("b"), stringVariant("c"))},
+		{desc: "no arguments", input: `(re-match?)`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildExactArityError(2, "re-match?")}},
+		{desc: "arity", input: `(re-split "a")`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildExactArityError(2, "re-split")}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			sexpr, e := Parse(test.input)
+			assert.Nil(t, e, "parse error")
+
+			actual := sexpr.Eval(context)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
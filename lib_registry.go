@@ -0,0 +1,208 @@
+package golisp
+
+import (
+	"sort"
+	"strings"
+)
+
+const namespaceSeparator = "/"
+
+// LibraryRegistry composes a set of function libraries into a single FunctionTable.
+// Every function is available by its plain name and by its qualified name ("str/concat").
+// Libraries registered with RegisterQualified are only available by their qualified names.
+type LibraryRegistry struct {
+	libraries        []FunctionLibrary
+	functions        FunctionTable
+	contextFunctions ContextFunctionTable
+	owners           map[string]string
+}
+
+func NewLibraryRegistry(libraries ...FunctionLibrary) (*LibraryRegistry, error) {
+	r := &LibraryRegistry{
+		libraries:        []FunctionLibrary{},
+		functions:        FunctionTable{},
+		contextFunctions: ContextFunctionTable{},
+		owners:           map[string]string{},
+	}
+
+	for _, l := range libraries {
+		if e := r.Register(l); e != nil {
+			return nil, e
+		}
+	}
+
+	return r, nil
+}
+
+func DefaultLibraries() []FunctionLibrary {
+	return []FunctionLibrary{
+		&CoreLibrary{},
+		&ArithmeticLibrary{},
+		&LogicalLibrary{},
+		&StringLibrary{},
+		&ObjectLibrary{},
+		&MapLibrary{},
+		&JSONLibrary{},
+		&VectorLibrary{},
+		&SetLibrary{},
+		&SequenceLibrary{},
+		&RegexLibrary{},
+		&FormatLibrary{},
+		&AsyncLibrary{},
+	}
+}
+
+func QualifiedName(namespace string, functionName string) string {
+	if namespace == "" {
+		return functionName
+	}
+	return namespace + namespaceSeparator + functionName
+}
+
+func (r *LibraryRegistry) Register(library FunctionLibrary) error {
+	return r.register(library, false)
+}
+
+func (r *LibraryRegistry) RegisterQualified(library FunctionLibrary) error {
+	return r.register(library, true)
+}
+
+func (r *LibraryRegistry) register(library FunctionLibrary, qualifiedOnly bool) error {
+	namespace := library.Namespace()
+	for _, l := range r.libraries {
+		if namespace != "" && l.Namespace() == namespace {
+			return buildDuplicateNamespaceError(namespace)
+		}
+	}
+
+	functions := library.InjectFunctions(FunctionTable{})
+	contextFunctions := ContextFunctionTable{}
+	if l, ok := library.(ContextLibrary); ok {
+		contextFunctions = l.InjectContextFunctions(contextFunctions)
+		for name, f := range contextFunctions {
+			functions[name] = withoutContext(f)
+		}
+	}
+
+	names := map[string]FunctionType{}
+	contextNames := ContextFunctionTable{}
+	for name, f := range functions {
+		for _, n := range registeredNames(namespace, name, qualifiedOnly) {
+			names[n] = f
+			if cf, found := contextFunctions[name]; found {
+				contextNames[n] = cf
+			}
+		}
+	}
+
+	for name := range names {
+		if owner, e := r.owners[name]; e {
+			return buildFunctionNameCollisionError(name, namespace, owner)
+		}
+	}
+
+	for name, f := range names {
+		r.functions[name] = f
+		r.owners[name] = namespace
+	}
+	for name, f := range contextNames {
+		r.contextFunctions[name] = f
+	}
+	r.libraries = append(r.libraries, library)
+
+	return nil
+}
+
+// registeredNames returns the names that a function injected by a library is available by.
+func registeredNames(namespace string, name string, qualifiedOnly bool) []string {
+	if strings.Contains(name, namespaceSeparator) {
+		return []string{name}
+	}
+	if qualifiedOnly {
+		return []string{QualifiedName(namespace, name)}
+	}
+	return []string{QualifiedName(namespace, name), name}
+}
+
+// withoutContext adapts a context function for the FunctionTable, for calls that aren't made by an evaluation.
+func withoutContext(f ContextFunctionType) FunctionType {
+	return func(args []Variant) Variant {
+		return f(nil, args)
+	}
+}
+
+func (r *LibraryRegistry) Libraries() []FunctionLibrary {
+	return append([]FunctionLibrary{}, r.libraries...)
+}
+
+func (r *LibraryRegistry) FunctionNames() []string {
+	names := make([]string, 0, len(r.functions))
+	for name := range r.functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Owner returns the namespace of the library that provides the function.
+func (r *LibraryRegistry) Owner(functionName string) (string, bool) {
+	namespace, found := r.owners[functionName]
+	return namespace, found
+}
+
+// contextFunction returns the function, by its plain or qualified name, if its library needs the context evaluating it.
+func (r *LibraryRegistry) contextFunction(functionName string) (ContextFunctionType, bool) {
+	f, found := r.contextFunctions[functionName]
+	return f, found
+}
+
+func (r *LibraryRegistry) InjectFunctions(functions FunctionTable) FunctionTable {
+	for name, f := range r.functions {
+		functions[name] = f
+	}
+	return functions
+}
+
+// Documentation returns the description of the function, by its plain or qualified name, if its library documents it.
+func (r *LibraryRegistry) Documentation(functionName string) (string, bool) {
+	library, names := r.owningLibrary(functionName)
+	if documented, ok := library.(DocumentedLibrary); ok {
+		docs := documented.Documentation()
+		for _, name := range names {
+			if doc, found := docs[name]; found {
+				return doc, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Signature returns the arguments the function takes, by its plain or qualified name, if its library describes them.
+func (r *LibraryRegistry) Signature(functionName string) (Signature, bool) {
+	library, names := r.owningLibrary(functionName)
+	if signed, ok := library.(SignedLibrary); ok {
+		signatures := signed.Signatures()
+		for _, name := range names {
+			if signature, found := signatures[name]; found {
+				return signature, true
+			}
+		}
+	}
+	return Signature{}, false
+}
+
+// owningLibrary returns the library that registered the function, and the names the library may know it by.
+func (r *LibraryRegistry) owningLibrary(functionName string) (FunctionLibrary, []string) {
+	namespace, found := r.owners[functionName]
+	if !found {
+		return nil, nil
+	}
+
+	baseName := strings.TrimPrefix(functionName, namespace+namespaceSeparator)
+	for _, l := range r.libraries {
+		if l.Namespace() == namespace {
+			return l, []string{functionName, baseName, QualifiedName(namespace, baseName)}
+		}
+	}
+	return nil, nil
+}
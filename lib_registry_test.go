@@ -0,0 +1,133 @@
+package golisp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testDomainLibrary struct {
+	namespace string
+}
+
+func (l *testDomainLibrary) Namespace() string {
+	return l.namespace
+}
+
+func (l *testDomainLibrary) answer(args []Variant) Variant {
+	return Variant{VariantType: VAR_INT, VariantValue: int64(42)}
+}
+
+func (l *testDomainLibrary) InjectFunctions(functions FunctionTable) FunctionTable {
+	functions["answer"] = l.answer
+	functions["concat"] = l.answer
+	return functions
+}
+
+func TestLibraryRegistry(t *testing.T) {
+	tests := [...]struct {
+		desc      string
+		libraries []FunctionLibrary
+		qualified []FunctionLibrary
+		expected  error
+	}{
+		{desc: "default libraries", libraries: DefaultLibraries()},
+		{desc: "duplicate namespace", libraries: []FunctionLibrary{&StringLibrary{}, &StringLibrary{}}, expected: buildDuplicateNamespaceError("str")},
+		{desc: "name collision", libraries: []FunctionLibrary{&StringLibrary{}, &testDomainLibrary{namespace: "domain"}}, expected: buildFunctionNameCollisionError("concat", "domain", "str")},
+		{desc: "qualified only avoids collision", libraries: []FunctionLibrary{&StringLibrary{}}, qualified: []FunctionLibrary{&testDomainLibrary{namespace: "domain"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			r, e := NewLibraryRegistry()
+			assert.Nil(t, e)
+
+			for _, l := range test.libraries {
+				if e = r.Register(l); e != nil {
+					break
+				}
+			}
+			for _, l := range test.qualified {
+				if e == nil {
+					e = r.RegisterQualified(l)
+				}
+			}
+
+			assert.Equal(t, test.expected, e)
+		})
+	}
+}
+
+func TestLibraryRegistry_QualifiedNames(t *testing.T) {
+	r, e := NewLibraryRegistry(&testDomainLibrary{namespace: "base"})
+	assert.Nil(t, e)
+	assert.Nil(t, r.RegisterQualified(&testDomainLibrary{namespace: "domain"}))
+
+	assert.Equal(t, []string{"answer", "base/answer", "base/concat", "concat", "domain/answer", "domain/concat"}, r.FunctionNames())
+}
+
+func TestWithLibraries(t *testing.T) {
+	context := NewEvaluationContext(nil, WithLibraries(&ArithmeticLibrary{}, &testDomainLibrary{namespace: "domain"}))
+
+	tests := [...]struct {
+		desc     string
+		input    string
+		expected Variant
+	}{
+		{desc: "plain name", input: "(+ 1 (answer))", expected: Variant{VariantType: VAR_INT, VariantValue: int64(43)}},
+		{desc: "qualified name", input: "(math/+ 1 (domain/answer))", expected: Variant{VariantType: VAR_INT, VariantValue: int64(43)}},
+		{desc: "library replaces default", input: "(concat 1 2)", expected: Variant{VariantType: VAR_INT, VariantValue: int64(42)}},
+		{desc: "excluded library", input: "(str/concat 1 2)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildFunctionNameNotFoundError("scope error: unresolved identifier \"str/concat\"")}},
+		{desc: "excluded library", input: "(or 1 2)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildFunctionNameNotFoundError("scope error: unresolved identifier \"or\"")}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			sexpr, e := Parse(test.input)
+			assert.Nil(t, e, "parse error")
+
+			actual := sexpr.Eval(context)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+
+	assert.Panics(t, func() { WithLibraries(&StringLibrary{}, &testDomainLibrary{namespace: "domain"}) })
+}
+
+func TestLibraryRegistry_Documentation(t *testing.T) {
+	r := loadDefaultLibraries()
+	for _, name := range r.FunctionNames() {
+		doc, found := r.Documentation(name)
+		assert.True(t, found, name)
+		assert.True(t, strings.HasPrefix(doc, "("), name)
+	}
+
+	doc, _ := r.Documentation("math/clamp")
+	assert.Equal(t, "(clamp x low high) limits x to the range from low to high.", doc)
+	doc, _ = r.Documentation("str/contains?")
+	assert.Equal(t, "(contains? s sub) is true if sub is in s.", doc)
+
+	r, _ = NewLibraryRegistry(&testDomainLibrary{namespace: "domain"})
+	_, found := r.Documentation("answer")
+	assert.False(t, found, "the library doesn't document its functions")
+	_, found = r.Documentation("missing")
+	assert.False(t, found)
+}
+
+func TestLibraryRegistry_Signature(t *testing.T) {
+	r := loadDefaultLibraries()
+	for _, name := range r.FunctionNames() {
+		_, found := r.Signature(name)
+		assert.True(t, found, name)
+	}
+
+	signature, _ := r.Signature("math/clamp")
+	assert.Equal(t, exactly(3, numberTypes, numberTypes, numberTypes), signature)
+	signature, _ = r.Signature("str/contains?")
+	assert.Equal(t, exactly(2, stringType, stringType), signature)
+
+	r, _ = NewLibraryRegistry(&testDomainLibrary{namespace: "domain"})
+	_, found := r.Signature("answer")
+	assert.False(t, found, "the library doesn't describe its functions")
+}
@@ -0,0 +1,647 @@
+package golisp
+
+import (
+	"math"
+	"sort"
+)
+
+// SequenceLibrary holds the higher-order functions, which take functions as arguments and work over any collection.
+// Lists, vectors and sets are walked in order, maps as [key value] vectors and NIL as an empty collection.
+// Every function returns a vector, whatever the collection it was given.
+type SequenceLibrary struct {
+}
+
+func (l *SequenceLibrary) Namespace() string {
+	return "seq"
+}
+
+var sequenceTypes = []EnumVariantType{VAR_LIST, VAR_VECTOR, VAR_SET}
+
+// sequenceArgTypes are the types ensureSequenceArg accepts, which also takes NIL as empty and a map as its entries.
+var sequenceArgTypes = []EnumVariantType{VAR_NULL, VAR_LIST, VAR_VECTOR, VAR_SET, VAR_MAP}
+
+func ensureSequenceArg(arg Variant, functionName string) ([]Variant, error) {
+	switch arg.VariantType {
+	case VAR_NULL:
+		return []Variant{}, nil
+
+	case VAR_MAP:
+		m, e := arg.GetMapValue()
+		if e != nil {
+			return nil, e
+		}
+		entries := make([]Variant, m.Len())
+		for i := range m.keys {
+			entries[i] = Variant{VariantType: VAR_VECTOR, VariantValue: []Variant{m.keys[i], m.values[i]}}
+		}
+		return entries, nil
+	}
+
+	if e := ensureArgumentTypesMatch([]Variant{arg}, sequenceTypes, []EnumVariantType{}, functionName); e != nil {
+		return nil, e
+	}
+	return arg.GetSequenceValue()
+}
+
+func callPredicate(f Variant, item Variant, functionName string) (bool, error) {
+	r := callFunction(f, []Variant{item}, functionName)
+	if e := ensureTypeIsNotInvalid(r); e != nil {
+		return false, e
+	}
+	return r.CoerceToBool()
+}
+
+func vectorOf(items []Variant) Variant {
+	return Variant{VariantType: VAR_VECTOR, VariantValue: items}
+}
+
+// mapFn calls the function with the nth item of every collection, stopping at the end of the shortest one.
+func (l *SequenceLibrary) mapFn(args []Variant) Variant {
+	functionName := "map"
+	if e := ensureMinimimArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	colls := make([][]Variant, len(args)-1)
+	length := -1
+	for i, a := range args[1:] {
+		items, e := ensureSequenceArg(a, functionName)
+		if e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		colls[i] = items
+		if length < 0 || len(items) < length {
+			length = len(items)
+		}
+	}
+
+	result := make([]Variant, length)
+	for i := range result {
+		fargs := make([]Variant, len(colls))
+		for j := range colls {
+			fargs[j] = colls[j][i]
+		}
+		result[i] = callFunction(args[0], fargs, functionName)
+		if e := ensureTypeIsNotInvalid(result[i]); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+	}
+	return vectorOf(result)
+}
+
+func filterItems(args []Variant, keep bool, functionName string) Variant {
+	if e := ensureExactArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	items, e := ensureSequenceArg(args[1], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	result := []Variant{}
+	for _, item := range items {
+		matched, e := callPredicate(args[0], item, functionName)
+		if e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		if matched == keep {
+			result = append(result, item)
+		}
+	}
+	return vectorOf(result)
+}
+
+func (l *SequenceLibrary) filter(args []Variant) Variant {
+	return filterItems(args, true, "filter")
+}
+
+func (l *SequenceLibrary) remove(args []Variant) Variant {
+	return filterItems(args, false, "remove")
+}
+
+func foldItems(f Variant, accumulator Variant, items []Variant, functionName string) Variant {
+	for _, item := range items {
+		accumulator = callFunction(f, []Variant{accumulator, item}, functionName)
+		if e := ensureTypeIsNotInvalid(accumulator); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+	}
+	return accumulator
+}
+
+// reduce folds the collection with the function, starting from the initial value if one is given and from the
+// first item otherwise. Reducing an empty collection without an initial value calls the function with no arguments.
+func (l *SequenceLibrary) reduce(args []Variant) Variant {
+	functionName := "reduce"
+	if e := ensureMinimimArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureMaximumArity(args, 3, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	items, e := ensureSequenceArg(args[len(args)-1], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if len(args) == 3 {
+		if e := ensureTypeIsNotInvalid(args[1]); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		return foldItems(args[0], args[1], items, functionName)
+	}
+
+	if len(items) == 0 {
+		return callFunction(args[0], []Variant{}, functionName)
+	}
+	return foldItems(args[0], items[0], items[1:], functionName)
+}
+
+// fold is reduce with a mandatory initial value.
+func (l *SequenceLibrary) fold(args []Variant) Variant {
+	functionName := "fold"
+	if e := ensureExactArity(args, 3, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureTypeIsNotInvalid(args[1]); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	items, e := ensureSequenceArg(args[2], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return foldItems(args[0], args[1], items, functionName)
+}
+
+// findFirst returns the index of the first item matching the predicate, or -1 if there is none.
+func findFirst(args []Variant, functionName string) ([]Variant, int, error) {
+	if e := ensureExactArity(args, 2, functionName); e != nil {
+		return nil, -1, e
+	}
+
+	items, e := ensureSequenceArg(args[1], functionName)
+	if e != nil {
+		return nil, -1, e
+	}
+
+	for i, item := range items {
+		matched, e := callPredicate(args[0], item, functionName)
+		if e != nil {
+			return nil, -1, e
+		}
+		if matched {
+			return items, i, nil
+		}
+	}
+	return items, -1, nil
+}
+
+func (l *SequenceLibrary) any(args []Variant) Variant {
+	_, i, e := findFirst(args, "any?")
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return Variant{VariantType: VAR_BOOL, VariantValue: i >= 0}
+}
+
+func (l *SequenceLibrary) every(args []Variant) Variant {
+	functionName := "every?"
+	if e := ensureExactArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	items, e := ensureSequenceArg(args[1], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	for _, item := range items {
+		matched, e := callPredicate(args[0], item, functionName)
+		if e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		if !matched {
+			return Variant{VariantType: VAR_BOOL, VariantValue: false}
+		}
+	}
+	return Variant{VariantType: VAR_BOOL, VariantValue: true}
+}
+
+func (l *SequenceLibrary) find(args []Variant) Variant {
+	items, i, e := findFirst(args, "find")
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	if i < 0 {
+		return Variant{VariantType: VAR_NULL}
+	}
+	return items[i]
+}
+
+func (l *SequenceLibrary) countIf(args []Variant) Variant {
+	matches := filterItems(args, true, "count-if")
+	if e := ensureTypeIsNotInvalid(matches); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return Variant{VariantType: VAR_INT, VariantValue: int64(len(matches.VariantValue.([]Variant)))}
+}
+
+// compareWith orders two values with a comparator, which returns either a boolean meaning "less than" or a number
+// like compare does. Without a comparator the natural ordering of the values is used.
+func compareWith(comparator *Variant, a Variant, b Variant, functionName string) (bool, error) {
+	if comparator == nil {
+		c, e := a.Compare(b)
+		return c < 0, e
+	}
+
+	r := callFunction(*comparator, []Variant{a, b}, functionName)
+	if e := ensureTypeIsNotInvalid(r); e != nil {
+		return false, e
+	}
+
+	switch r.VariantType {
+	case VAR_BOOL:
+		return r.CoerceToBool()
+	case VAR_INT:
+		c, e := r.CoerceToInt()
+		return c < 0, e
+	}
+	return false, buildUnacceptableTypeError(r.VariantType, functionName)
+}
+
+// sortItems is a stable sort of the items by their keys, stopping at the first error from the comparator.
+func sortItems(items []Variant, keys []Variant, comparator *Variant, functionName string) Variant {
+	indices := make([]int, len(items))
+	for i := range indices {
+		indices[i] = i
+	}
+
+	var err error
+	sort.SliceStable(indices, func(i, j int) bool {
+		if err != nil {
+			return false
+		}
+		less, e := compareWith(comparator, keys[indices[i]], keys[indices[j]], functionName)
+		if e != nil {
+			err = e
+		}
+		return less
+	})
+
+	if err != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: err}
+	}
+
+	result := make([]Variant, len(items))
+	for i, index := range indices {
+		result[i] = items[index]
+	}
+	return vectorOf(result)
+}
+
+// sortFn sorts the collection by its natural ordering, or with a comparator given before the collection.
+func (l *SequenceLibrary) sortFn(args []Variant) Variant {
+	functionName := "sort"
+	if e := ensureMinimimArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureMaximumArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	items, e := ensureSequenceArg(args[len(args)-1], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	var comparator *Variant
+	if len(args) == 2 {
+		comparator = &args[0]
+	}
+	return sortItems(items, items, comparator, functionName)
+}
+
+// sortBy sorts the collection by the result of calling the key function on each item, with an optional comparator
+// between the key function and the collection.
+func (l *SequenceLibrary) sortBy(args []Variant) Variant {
+	functionName := "sort-by"
+	if e := ensureMinimimArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureMaximumArity(args, 3, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	items, e := ensureSequenceArg(args[len(args)-1], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	keys := make([]Variant, len(items))
+	for i, item := range items {
+		keys[i] = callFunction(args[0], []Variant{item}, functionName)
+		if e := ensureTypeIsNotInvalid(keys[i]); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+	}
+
+	var comparator *Variant
+	if len(args) == 3 {
+		comparator = &args[1]
+	}
+	return sortItems(items, keys, comparator, functionName)
+}
+
+// groupBy builds a map from the result of calling the function on each item to a vector of the items that produced it.
+func (l *SequenceLibrary) groupBy(args []Variant) Variant {
+	functionName := "group-by"
+	if e := ensureExactArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	items, e := ensureSequenceArg(args[1], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	groups := NewVariantMap()
+	for _, item := range items {
+		key := callFunction(args[0], []Variant{item}, functionName)
+		if e := ensureTypeIsNotInvalid(key); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+
+		group := []Variant{}
+		if existing, found := groups.Get(key); found {
+			group = existing.VariantValue.([]Variant)
+		}
+		if e := groups.put(key, vectorOf(append(group, item))); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+	}
+	return Variant{VariantType: VAR_MAP, VariantValue: groups}
+}
+
+func ensurePositiveArg(arg Variant, functionName string) (int64, error) {
+	n, e := ensureIndexArg(arg, functionName)
+	if e != nil {
+		return 0, e
+	}
+	if n <= 0 {
+		return 0, buildNonPositiveArgumentError(n, functionName)
+	}
+	return n, nil
+}
+
+// partition splits the collection into vectors of n items, starting a new one every step items. A final partition
+// with fewer than n items is dropped.
+func (l *SequenceLibrary) partition(args []Variant) Variant {
+	functionName := "partition"
+	if e := ensureMinimimArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureMaximumArity(args, 3, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	n, e := ensurePositiveArg(args[0], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	step := n
+	if len(args) == 3 {
+		if step, e = ensurePositiveArg(args[1], functionName); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+	}
+
+	items, e := ensureSequenceArg(args[len(args)-1], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	result := []Variant{}
+	for start := int64(0); start+n <= int64(len(items)); start += step {
+		result = append(result, vectorOf(append([]Variant{}, items[start:start+n]...)))
+	}
+	return vectorOf(result)
+}
+
+// splitItems returns the collection split after n items, with n clamped to the bounds of the collection.
+func splitItems(args []Variant, functionName string) ([]Variant, []Variant, error) {
+	if e := ensureExactArity(args, 2, functionName); e != nil {
+		return nil, nil, e
+	}
+
+	n, e := ensureIndexArg(args[0], functionName)
+	if e != nil {
+		return nil, nil, e
+	}
+
+	items, e := ensureSequenceArg(args[1], functionName)
+	if e != nil {
+		return nil, nil, e
+	}
+
+	if n < 0 {
+		n = 0
+	}
+	if n > int64(len(items)) {
+		n = int64(len(items))
+	}
+	return append([]Variant{}, items[:n]...), append([]Variant{}, items[n:]...), nil
+}
+
+func (l *SequenceLibrary) take(args []Variant) Variant {
+	head, _, e := splitItems(args, "take")
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return vectorOf(head)
+}
+
+func (l *SequenceLibrary) drop(args []Variant) Variant {
+	_, tail, e := splitItems(args, "drop")
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return vectorOf(tail)
+}
+
+// zip pairs up the items of the collections into vectors, stopping at the end of the shortest one.
+func (l *SequenceLibrary) zip(args []Variant) Variant {
+	return l.mapFn(append([]Variant{{VariantType: VAR_FUNCTION, VariantValue: FunctionType(vectorOf)}}, args...))
+}
+
+// rangeFn returns the integers from start (default 0) up to, but not including, end, counting by step (default 1).
+// The number of integers is checked against the limits before the vector is built, and the evaluation is checked for
+// cancellation while it is filled.
+func (l *SequenceLibrary) rangeFn(ctx *EvaluationContext, args []Variant) Variant {
+	functionName := "range"
+	if e := ensureMinimimArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureMaximumArity(args, 3, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	bounds := []int64{0, 0, 1}
+	for i, a := range args {
+		n, e := ensureIndexArg(a, functionName)
+		if e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		bounds[i] = n
+	}
+	if len(args) == 1 {
+		bounds[0], bounds[1] = 0, bounds[0]
+	}
+
+	start, end, step := bounds[0], bounds[1], bounds[2]
+	if step == 0 {
+		return Variant{VariantType: VAR_ERROR, VariantValue: buildZeroStepError(functionName)}
+	}
+
+	count := rangeCount(start, end, step)
+	if e := ctx.ensureCollectionFits(count, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	result := make([]Variant, count)
+	for i := range result {
+		if i%rangeCancellationInterval == 0 {
+			if e := ctx.cancellation(); e != nil {
+				return Variant{VariantType: VAR_ERROR, VariantValue: e}
+			}
+		}
+		// the count guarantees that this doesn't overflow
+		result[i] = Variant{VariantType: VAR_INT, VariantValue: start + int64(i)*step}
+	}
+	return vectorOf(result)
+}
+
+// rangeCancellationInterval is the number of integers range fills between checks for cancellation.
+const rangeCancellationInterval = 1 << 16
+
+// rangeCount is the number of integers from start up to, but not including, end, counting by a step that isn't zero.
+// The distances are taken as unsigned so that they can't overflow, and a count beyond an int64 is clamped to the largest.
+func rangeCount(start int64, end int64, step int64) int64 {
+	var distance, stride uint64
+	switch {
+	case step > 0 && start < end:
+		distance, stride = uint64(end)-uint64(start), uint64(step)
+	case step < 0 && start > end:
+		distance, stride = uint64(start)-uint64(end), uint64(-(step+1))+1
+	default:
+		return 0
+	}
+
+	count := (distance-1)/stride + 1
+	if count > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(count)
+}
+
+// apply calls the function with any leading arguments followed by the items of the final collection.
+func (l *SequenceLibrary) apply(args []Variant) Variant {
+	functionName := "apply"
+	if e := ensureMinimimArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureValidArgs(args[1 : len(args)-1]); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	items, e := ensureSequenceArg(args[len(args)-1], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	fargs := append(append([]Variant{}, args[1:len(args)-1]...), items...)
+	return callFunction(args[0], fargs, functionName)
+}
+
+func (l *SequenceLibrary) InjectFunctions(functions FunctionTable) FunctionTable {
+	functions["map"] = l.mapFn
+	functions["filter"] = l.filter
+	functions["remove"] = l.remove
+	functions["reduce"] = l.reduce
+	functions["fold"] = l.fold
+	functions["any?"] = l.any
+	functions["every?"] = l.every
+	functions["find"] = l.find
+	functions["count-if"] = l.countIf
+	functions["sort"] = l.sortFn
+	functions["sort-by"] = l.sortBy
+	functions["group-by"] = l.groupBy
+	functions["partition"] = l.partition
+	functions["take"] = l.take
+	functions["drop"] = l.drop
+	functions["zip"] = l.zip
+	functions["apply"] = l.apply
+	return functions
+}
+
+func (l *SequenceLibrary) InjectContextFunctions(functions ContextFunctionTable) ContextFunctionTable {
+	functions["range"] = l.rangeFn
+	return functions
+}
+
+func (l *SequenceLibrary) Documentation() map[string]string {
+	return map[string]string{
+		"map":       "(map f coll ...) is a vector of f called on the items of the collections in turn.",
+		"filter":    "(filter pred coll) is a vector of the items for which the predicate is true.",
+		"remove":    "(remove pred coll) is a vector of the items for which the predicate is false.",
+		"reduce":    "(reduce f [init] coll) folds the collection with f, starting from init or else the first item.",
+		"fold":      "(fold f init coll) folds the collection with f, starting from init.",
+		"any?":      "(any? pred coll) is true if the predicate is true for any item.",
+		"every?":    "(every? pred coll) is true if the predicate is true for every item.",
+		"find":      "(find pred coll) is the first item for which the predicate is true, or NIL.",
+		"count-if":  "(count-if pred coll) counts the items for which the predicate is true.",
+		"sort":      "(sort [comparator] coll) sorts the collection, by its natural order or with the comparator.",
+		"sort-by":   "(sort-by keyfn [comparator] coll) sorts the collection by the keys that keyfn gives its items.",
+		"group-by":  "(group-by f coll) is a map from the results of f to vectors of the items that gave them.",
+		"partition": "(partition n [step] coll) splits the collection into vectors of n items, starting one every step items.",
+		"take":      "(take n coll) is a vector of the first n items.",
+		"drop":      "(drop n coll) is a vector of the items after the first n.",
+		"zip":       "(zip coll ...) is a vector of vectors pairing up the items of the collections.",
+		"range":     "(range [start] end [step]) is a vector of the ints from start up to, but not including, end.",
+		"apply":     "(apply f args ... coll) calls f with the arguments followed by the items of the collection.",
+	}
+}
+
+func (l *SequenceLibrary) Signatures() map[string]Signature {
+	predicate := exactly(2, functionType, sequenceArgTypes)
+	return map[string]Signature{
+		"map":       atLeast(2, functionType, sequenceArgTypes),
+		"filter":    predicate,
+		"remove":    predicate,
+		"reduce":    between(2, 3, functionType),
+		"fold":      exactly(3, functionType),
+		"any?":      predicate,
+		"every?":    predicate,
+		"find":      predicate,
+		"count-if":  predicate,
+		"sort":      between(1, 2),
+		"sort-by":   between(2, 3, functionType),
+		"group-by":  predicate,
+		"partition": between(2, 3, intType),
+		"take":      exactly(2, intType, sequenceArgTypes),
+		"drop":      exactly(2, intType, sequenceArgTypes),
+		"zip":       atLeast(1, sequenceArgTypes),
+		"range":     between(1, 3, intType, intType, intType),
+		"apply":     atLeast(2, functionType, anyType),
+	}
+}
@@ -0,0 +1,97 @@
+package golisp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func boolVariant(b bool) Variant {
+	return Variant{VariantType: VAR_BOOL, VariantValue: b}
+}
+
+func TestSequence(t *testing.T) {
+	context := NewEvaluationContext(nil)
+	assert.Nil(t, context.RegisterGoFunc("even?", func(i int) bool { return i%2 == 0 }))
+	assert.Nil(t, context.RegisterGoFunc("line-total", func(item map[string]int) int { return item["qty"] * item["price"] }))
+	assert.Nil(t, context.RegisterGoFunc("by-length-desc", func(a, b string) bool { return len(a) > len(b) }))
+	items, _ := ParseJSON(`[{"qty": 2, "price": 5}, {"qty": 1, "price": 7}, {"qty": 3, "price": 1}]`)
+	context.SymbolTable["items"] = items
+
+	tests := [...]struct {
+		desc     string
+		input    string
+		expected Variant
+	}{
+		{desc: "map", input: "(map vec-length [[1] [] [1 2]])", expected: vectorVariant(intVariant(1), intVariant(0), intVariant(2))},
+		{desc: "map over several collections", input: "(map + [1 2 3] [10 20])", expected: vectorVariant(intVariant(11), intVariant(22))},
+		{desc: "map over a list", input: "(map - (vec->list [1 2]))", expected: vectorVariant(intVariant(-1), intVariant(-2))},
+		{desc: "map over a map", input: "(map vec-length {:a 1 :b 2})", expected: vectorVariant(intVariant(2), intVariant(2))},
+		{desc: "map over nil", input: "(map - ())", expected: vectorVariant()},
+		{desc: "map with a non-function", input: "(map 1 [1])", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnacceptableTypeError(VAR_INT, "map")}},
+		{desc: "map propagates errors", input: "(map / [1 2] [1 0])", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildDivideByZeroError()}},
+		{desc: "filter", input: "(filter even? (range 6))", expected: vectorVariant(intVariant(0), intVariant(2), intVariant(4))},
+		{desc: "filter a set", input: "(filter keyword? #{:a 1 :b})", expected: vectorVariant(keywordVariant("a"), keywordVariant("b"))},
+		{desc: "remove", input: "(remove even? [1 2 3])", expected: vectorVariant(intVariant(1), intVariant(3))},
+		{desc: "filter with a non-boolean predicate", input: `(filter concat ["a"])`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildTypeError(VAR_STRING, VAR_BOOL)}},
+		{desc: "reduce", input: "(reduce + [1 2 3])", expected: intVariant(6)},
+		{desc: "reduce with initial value", input: "(reduce + 10 [1 2 3])", expected: intVariant(16)},
+		{desc: "reduce empty", input: "(reduce concat [])", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildMinimumArityError(1, "concat")}},
+		{desc: "fold", input: "(fold concat \"x\" [\"a\" \"b\"])", expected: stringVariant("xab")},
+		{desc: "fold arity", input: "(fold + [1])", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildExactArityError(3, "fold")}},
+		{desc: "line item total", input: "(reduce + 0 (map line-total items))", expected: intVariant(20)},
+		{desc: "any?", input: "(any? even? [1 3 4])", expected: boolVariant(true)},
+		{desc: "any? of empty", input: "(any? even? [])", expected: boolVariant(false)},
+		{desc: "every?", input: "(every? even? [2 4 5])", expected: boolVariant(false)},
+		{desc: "every? of empty", input: "(every? even? [])", expected: boolVariant(true)},
+		{desc: "find", input: "(find even? [1 4 6])", expected: intVariant(4)},
+		{desc: "find nothing", input: "(find even? [1 3])", expected: Variant{VariantType: VAR_NULL}},
+		{desc: "count-if", input: "(count-if even? (range 10))", expected: intVariant(5)},
+		{desc: "sort", input: "(sort [3 1.75 2])", expected: vectorVariant(Variant{VariantType: VAR_FLOAT, VariantValue: 1.75}, intVariant(2), intVariant(3))},
+		{desc: "sort with comparator", input: "(sort > [1 3 2])", expected: vectorVariant(intVariant(3), intVariant(2), intVariant(1))},
+		{desc: "sort with numeric comparator", input: "(sort compare [\"b\" \"a\"])", expected: vectorVariant(stringVariant("a"), stringVariant("b"))},
+		{desc: "sort incomparable", input: "(sort [1 \"a\"])", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildIncomparableTypesError(VAR_STRING, VAR_INT)}},
+		{desc: "sort-by", input: "(sort-by line-total items)", expected: vectorVariant(mapVariant(stringVariant("qty"), intVariant(3), stringVariant("price"), intVariant(1)), mapVariant(stringVariant("qty"), intVariant(1), stringVariant("price"), intVariant(7)), mapVariant(stringVariant("qty"), intVariant(2), stringVariant("price"), intVariant(5)))},
+		{desc: "sort-by is stable", input: "(sort-by vec-length [[2] [1 1] [1]])", expected: vectorVariant(vectorVariant(intVariant(2)), vectorVariant(intVariant(1)), vectorVariant(intVariant(1), intVariant(1)))},
+		{desc: "sort-by with comparator", input: "(sort-by concat by-length-desc [\"a\" \"ccc\" \"bb\"])", expected: vectorVariant(stringVariant("ccc"), stringVariant("bb"), stringVariant("a"))},
+		{desc: "group-by", input: "(group-by even? [1 2 3 4])", expected: mapVariant(boolVariant(false), vectorVariant(intVariant(1), intVariant(3)), boolVariant(true), vectorVariant(intVariant(2), intVariant(4)))},
+		{desc: "partition", input: "(partition 2 [1 2 3 4 5])", expected: vectorVariant(vectorVariant(intVariant(1), intVariant(2)), vectorVariant(intVariant(3), intVariant(4)))},
+		{desc: "partition with step", input: "(partition 2 1 [1 2 3])", expected: vectorVariant(vectorVariant(intVariant(1), intVariant(2)), vectorVariant(intVariant(2), intVariant(3)))},
+		{desc: "partition of zero", input: "(partition 0 [1])", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildNonPositiveArgumentError(0, "partition")}},
+		{desc: "take", input: "(take 2 [1 2 3])", expected: vectorVariant(intVariant(1), intVariant(2))},
+		{desc: "take more than there is", input: "(take 5 [1])", expected: vectorVariant(intVariant(1))},
+		{desc: "drop", input: "(drop 2 [1 2 3])", expected: vectorVariant(intVariant(3))},
+		{desc: "drop negative", input: "(drop -1 [1])", expected: vectorVariant(intVariant(1))},
+		{desc: "zip", input: "(zip [1 2] [:a :b :c])", expected: vectorVariant(vectorVariant(intVariant(1), keywordVariant("a")), vectorVariant(intVariant(2), keywordVariant("b")))},
+		{desc: "range", input: "(range 3)", expected: vectorVariant(intVariant(0), intVariant(1), intVariant(2))},
+		{desc: "range with start", input: "(range 2 4)", expected: vectorVariant(intVariant(2), intVariant(3))},
+		{desc: "range counting down", input: "(range 3 0 -2)", expected: vectorVariant(intVariant(3), intVariant(1))},
+		{desc: "range with zero step", input: "(range 0 3 0)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildZeroStepError("range")}},
+		{desc: "range up to the largest integer", input: "(range 0 9_223_372_036_854_775_807 4_611_686_018_427_387_904)", expected: vectorVariant(intVariant(0), intVariant(4611686018427387904))},
+		{desc: "range down to the smallest integer", input: "(range 0 -9_223_372_036_854_775_808 -4_611_686_018_427_387_904)", expected: vectorVariant(intVariant(0), intVariant(-4611686018427387904))},
+		{desc: "range too long to build", input: "(range -9_223_372_036_854_775_808 9_223_372_036_854_775_807)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildValueTooLargeError(9223372036854775807, "range")}},
+		{desc: "apply", input: "(apply + 1 [2 3])", expected: intVariant(6)},
+		{desc: "apply to a set", input: "(apply + #{1 2})", expected: intVariant(3)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			sexpr, e := Parse(test.input)
+			assert.Nil(t, e, "parse error")
+
+			actual := sexpr.Eval(context)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestRangeCancellation(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ctx := NewEvaluationContext(nil)
+	ctx.goContext = cancelled
+	actual := (&SequenceLibrary{}).rangeFn(ctx, []Variant{intVariant(1_000_000)})
+	assert.Equal(t, Variant{VariantType: VAR_ERROR, VariantValue: buildEvaluationCancelledError(context.Canceled)}, actual)
+}
@@ -0,0 +1,166 @@
+package golisp
+
+type SetLibrary struct {
+}
+
+func (l *SetLibrary) Namespace() string {
+	return "set"
+}
+
+var setType = []EnumVariantType{VAR_SET}
+
+func ensureSetArgs(args []Variant, functionName string) ([]*VariantSet, error) {
+	if e := ensureArgumentTypesMatch(args, setType, []EnumVariantType{}, functionName); e != nil {
+		return nil, e
+	}
+
+	sets := make([]*VariantSet, len(args))
+	for i := range args {
+		s, e := args[i].GetSetValue()
+		if e != nil {
+			return nil, e
+		}
+		sets[i] = s
+	}
+	return sets, nil
+}
+
+// set builds a set from the items of a list, vector or set, collapsing duplicates.
+func (l *SetLibrary) set(args []Variant) Variant {
+	functionName := "set"
+	if e := ensureMaximumArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	result := NewVariantSet()
+	if len(args) == 0 {
+		return Variant{VariantType: VAR_SET, VariantValue: result}
+	}
+
+	if e := ensureArgumentTypesMatch(args, sequenceTypes, []EnumVariantType{}, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	items, e := args[0].GetSequenceValue()
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	for _, item := range items {
+		if e := result.put(item); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+	}
+	return Variant{VariantType: VAR_SET, VariantValue: result}
+}
+
+func (l *SetLibrary) member(args []Variant) Variant {
+	functionName := "member?"
+	if e := ensureExactArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureTypeIsNotInvalid(args[1]); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	sets, e := ensureSetArgs(args[:1], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	return Variant{VariantType: VAR_BOOL, VariantValue: sets[0].Contains(args[1])}
+}
+
+func foldSets(args []Variant, op func(*VariantSet, *VariantSet) *VariantSet, functionName string) Variant {
+	if e := ensureMinimimArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	sets, e := ensureSetArgs(args, functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	result := sets[0]
+	for _, s := range sets[1:] {
+		result = op(result, s)
+	}
+	return Variant{VariantType: VAR_SET, VariantValue: result}
+}
+
+func (l *SetLibrary) union(args []Variant) Variant {
+	return foldSets(args, (*VariantSet).Union, "union")
+}
+
+func (l *SetLibrary) intersection(args []Variant) Variant {
+	return foldSets(args, (*VariantSet).Intersection, "intersection")
+}
+
+// difference removes the items of every later set from the first.
+func (l *SetLibrary) difference(args []Variant) Variant {
+	return foldSets(args, (*VariantSet).Difference, "difference")
+}
+
+func (l *SetLibrary) subset(args []Variant) Variant {
+	functionName := "subset?"
+	if e := ensureExactArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	sets, e := ensureSetArgs(args, functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	return Variant{VariantType: VAR_BOOL, VariantValue: sets[0].IsSubset(sets[1])}
+}
+
+func (l *SetLibrary) size(args []Variant) Variant {
+	functionName := "set-size"
+	if e := ensureExactArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	sets, e := ensureSetArgs(args, functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	return Variant{VariantType: VAR_INT, VariantValue: int64(sets[0].Len())}
+}
+
+func (l *SetLibrary) InjectFunctions(functions FunctionTable) FunctionTable {
+	functions["set"] = l.set
+	functions["member?"] = l.member
+	functions["union"] = l.union
+	functions["intersection"] = l.intersection
+	functions["difference"] = l.difference
+	functions["subset?"] = l.subset
+	functions["set-size"] = l.size
+	return functions
+}
+
+func (l *SetLibrary) Documentation() map[string]string {
+	return map[string]string{
+		"set":          "(set [coll]) makes a set of the items of a list, vector or set.",
+		"member?":      "(member? s x) is true if x is in the set.",
+		"union":        "(union s ...) is the set of the items in any of the sets.",
+		"intersection": "(intersection s ...) is the set of the items in all of the sets.",
+		"difference":   "(difference s ...) is the set of the items of the first set that aren't in any of the others.",
+		"subset?":      "(subset? a b) is true if every item of a is in b.",
+		"set-size":     "(set-size s) counts the items of the set.",
+	}
+}
+
+func (l *SetLibrary) Signatures() map[string]Signature {
+	return map[string]Signature{
+		"set":          between(0, 1, sequenceTypes),
+		"member?":      exactly(2, setType),
+		"union":        atLeast(1, setType),
+		"intersection": atLeast(1, setType),
+		"difference":   atLeast(1, setType),
+		"subset?":      exactly(2, setType, setType),
+		"set-size":     exactly(1, setType),
+	}
+}
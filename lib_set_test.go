@@ -0,0 +1,67 @@
+package golisp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var sets = &(SetLibrary{})
+
+func setVariant(items ...Variant) Variant {
+	s := NewVariantSet()
+	for _, item := range items {
+		s.put(item)
+	}
+	return Variant{VariantType: VAR_SET, VariantValue: s}
+}
+
+func TestUnionDoesNotModifyInput(t *testing.T) {
+	input := setVariant(intVariant(1))
+
+	actual := sets.union([]Variant{input, setVariant(intVariant(2))})
+	assert.Equal(t, setVariant(intVariant(1), intVariant(2)), actual)
+	assert.Equal(t, 1, input.VariantValue.(*VariantSet).Len(), "input must not be modified")
+}
+
+func TestSet(t *testing.T) {
+	context := NewEvaluationContext(nil)
+
+	tests := [...]struct {
+		desc     string
+		input    string
+		expected Variant
+	}{
+		{desc: "literal", input: `#{"a" 1 (+ 1 1)}`, expected: setVariant(stringVariant("a"), intVariant(1), intVariant(2))},
+		{desc: "literal collapses duplicates", input: `#{1 (+ 0 1) 2}`, expected: setVariant(intVariant(1), intVariant(2))},
+		{desc: "empty literal", input: `#{}`, expected: setVariant()},
+		{desc: "literal with error", input: `#{1 nope}`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnresolvedIdentifierError("nope")}},
+		{desc: "set from vector", input: `(set [1 2 1])`, expected: setVariant(intVariant(1), intVariant(2))},
+		{desc: "set from json array", input: `(set (json-parse "[1, 1]"))`, expected: setVariant(intVariant(1))},
+		{desc: "set of a map", input: `(set {})`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnacceptableTypeError(VAR_MAP, "set")}},
+		{desc: "member?", input: `(member? #{"RU" "IR"} "IR")`, expected: Variant{VariantType: VAR_BOOL, VariantValue: true}},
+		{desc: "member? is type-strict", input: `(member? #{1} 1.0)`, expected: Variant{VariantType: VAR_BOOL, VariantValue: false}},
+		{desc: "member? of a vector", input: `(member? [1] 1)`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnacceptableTypeError(VAR_VECTOR, "member?")}},
+		{desc: "union", input: `(union #{1 2} #{2 3} #{4})`, expected: setVariant(intVariant(1), intVariant(2), intVariant(3), intVariant(4))},
+		{desc: "intersection", input: `(intersection #{1 2 3} #{3 2 5})`, expected: setVariant(intVariant(2), intVariant(3))},
+		{desc: "roles intersect", input: `(= (set-size (intersection #{:admin :ops} #{:ops :dev})) 1)`, expected: Variant{VariantType: VAR_BOOL, VariantValue: true}},
+		{desc: "difference", input: `(difference #{1 2 3} #{2} #{3})`, expected: setVariant(intVariant(1))},
+		{desc: "subset?", input: `(subset? #{1 2} #{2 3 1})`, expected: Variant{VariantType: VAR_BOOL, VariantValue: true}},
+		{desc: "not subset?", input: `(subset? #{1 4} #{2 3 1})`, expected: Variant{VariantType: VAR_BOOL, VariantValue: false}},
+		{desc: "set-size", input: `(set-size #{1 2 2})`, expected: intVariant(2)},
+		{desc: "equality ignores order", input: `(= #{1 2} #{2 1})`, expected: Variant{VariantType: VAR_BOOL, VariantValue: true}},
+		{desc: "sets and vectors differ", input: `(= #{1} [1])`, expected: Variant{VariantType: VAR_BOOL, VariantValue: false}},
+		{desc: "set as map key", input: `(get {#{1 2} "a"} #{2 1})`, expected: stringVariant("a")},
+		{desc: "nested sets", input: `(member? #{#{1 2}} #{2 1})`, expected: Variant{VariantType: VAR_BOOL, VariantValue: true}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			sexpr, e := Parse(test.input)
+			assert.Nil(t, e, "parse error")
+
+			actual := sexpr.Eval(context)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
@@ -1,17 +1,527 @@
 package golisp
 
+import (
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// StringLibrary works on strings as sequences of runes, so lengths and indices count characters rather than bytes.
 type StringLibrary struct {
 }
 
-func (l *StringLibrary) concat(args []Variant) Variant {
-	return foldStrings(
+func (l *StringLibrary) Namespace() string {
+	return "str"
+}
+
+// concat joins its arguments as display strings, so floats are written in decimal notation rather than scientific.
+// It checks the length of the joined string against the limits before it joins the pieces.
+func (l *StringLibrary) concat(ctx *EvaluationContext, args []Variant) Variant {
+	functionName := "concat"
+	if e := ensureMinimimArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	pieces := make([]string, len(args))
+	length := int64(0)
+	for i, a := range args {
+		v, e := displayString(a)
+		if e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		pieces[i] = v
+		length += int64(len(v))
+	}
+
+	if e := ctx.ensureStringFits(length, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return stringVariantOf(strings.Join(pieces, ""))
+}
+
+func unaryOpString(args []Variant, unaryOp func(string) Variant, functionName string) Variant {
+	if e := ensureExactArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	s, e := ensureStringArg(args[0], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return unaryOp(s)
+}
+
+func binaryOpStrings(args []Variant, binaryOp func(string, string) Variant, functionName string) Variant {
+	if e := ensureExactArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureArgumentTypesMatch(args, []EnumVariantType{VAR_STRING}, []EnumVariantType{}, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	a, e := args[0].CoerceToString()
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	b, e := args[1].CoerceToString()
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return binaryOp(a, b)
+}
+
+func stringVariantOf(s string) Variant {
+	return Variant{VariantType: VAR_STRING, VariantValue: s}
+}
+
+func boolVariantOf(b bool) Variant {
+	return Variant{VariantType: VAR_BOOL, VariantValue: b}
+}
+
+func (l *StringLibrary) upper(args []Variant) Variant {
+	return unaryOpString(args, func(s string) Variant { return stringVariantOf(strings.ToUpper(s)) }, "upper")
+}
+
+func (l *StringLibrary) lower(args []Variant) Variant {
+	return unaryOpString(args, func(s string) Variant { return stringVariantOf(strings.ToLower(s)) }, "lower")
+}
+
+// title upper-cases the first letter of every word and lower-cases the rest.
+func (l *StringLibrary) title(args []Variant) Variant {
+	return unaryOpString(
+		args,
+		func(s string) Variant {
+			runes := []rune(s)
+			startOfWord := true
+			for i, r := range runes {
+				if startOfWord {
+					runes[i] = unicode.ToTitle(r)
+				} else {
+					runes[i] = unicode.ToLower(r)
+				}
+				startOfWord = !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+			}
+			return stringVariantOf(string(runes))
+		},
+		"title")
+}
+
+func (l *StringLibrary) trim(args []Variant) Variant {
+	return unaryOpString(args, func(s string) Variant { return stringVariantOf(strings.TrimSpace(s)) }, "trim")
+}
+
+func (l *StringLibrary) trimLeft(args []Variant) Variant {
+	return unaryOpString(args, func(s string) Variant { return stringVariantOf(strings.TrimLeftFunc(s, unicode.IsSpace)) }, "trim-left")
+}
+
+func (l *StringLibrary) trimRight(args []Variant) Variant {
+	return unaryOpString(args, func(s string) Variant { return stringVariantOf(strings.TrimRightFunc(s, unicode.IsSpace)) }, "trim-right")
+}
+
+func (l *StringLibrary) length(args []Variant) Variant {
+	return unaryOpString(args, func(s string) Variant { return Variant{VariantType: VAR_INT, VariantValue: int64(len([]rune(s)))} }, "string-length")
+}
+
+func (l *StringLibrary) reverse(args []Variant) Variant {
+	return unaryOpString(
 		args,
-		func(a string, b string) (string, error) { return a + b, nil },
-		"concat")
+		func(s string) Variant {
+			runes := []rune(s)
+			for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+				runes[i], runes[j] = runes[j], runes[i]
+			}
+			return stringVariantOf(string(runes))
+		},
+		"reverse")
+}
+
+// split returns a vector of the pieces of the string between separators. An empty separator splits it into characters.
+// The number of pieces is checked against the limits before the string is split.
+func (l *StringLibrary) split(ctx *EvaluationContext, args []Variant) Variant {
+	return binaryOpStrings(
+		args,
+		func(s string, sep string) Variant {
+			count := strings.Count(s, sep) + 1
+			if sep == "" {
+				count = utf8.RuneCountInString(s)
+			}
+			if e := ctx.ensureCollectionFits(int64(count), "split"); e != nil {
+				return Variant{VariantType: VAR_ERROR, VariantValue: e}
+			}
+
+			pieces := strings.Split(s, sep)
+			items := make([]Variant, len(pieces))
+			for i, p := range pieces {
+				items[i] = stringVariantOf(p)
+			}
+			return Variant{VariantType: VAR_VECTOR, VariantValue: items}
+		},
+		"split")
+}
+
+// join concatenates the items of a collection with the separator between them, coercing items as concat does.
+// The length of the joined string is checked against the limits before it is built.
+func (l *StringLibrary) join(ctx *EvaluationContext, args []Variant) Variant {
+	functionName := "join"
+	if e := ensureExactArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	sep, e := ensureStringArg(args[0], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	items, e := ensureSequenceArg(args[1], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	pieces := make([]string, len(items))
+	length := int64(0)
+	for i, item := range items {
+		if pieces[i], e = displayString(item); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		length += int64(len(pieces[i]))
+	}
+
+	if len(pieces) > 1 {
+		length = addedLength(length, repeatedLength(int64(len(sep)), int64(len(pieces)-1)))
+	}
+	if e := ctx.ensureStringFits(length, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return stringVariantOf(strings.Join(pieces, sep))
+}
+
+// substring returns the characters from start up to, but not including, end. The end defaults to the length of the string.
+func (l *StringLibrary) substring(args []Variant) Variant {
+	functionName := "substring"
+	if e := ensureMinimimArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureMaximumArity(args, 3, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	s, e := ensureStringArg(args[0], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	runes := []rune(s)
+
+	start, e := ensureIndexArg(args[1], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	end := int64(len(runes))
+	if len(args) == 3 {
+		if end, e = ensureIndexArg(args[2], functionName); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+	}
+
+	if start < 0 || start > int64(len(runes)) {
+		return Variant{VariantType: VAR_ERROR, VariantValue: buildIndexOutOfRangeError(start, len(runes), functionName)}
+	}
+
+	if end < start || end > int64(len(runes)) {
+		return Variant{VariantType: VAR_ERROR, VariantValue: buildIndexOutOfRangeError(end, len(runes), functionName)}
+	}
+
+	return stringVariantOf(string(runes[start:end]))
+}
+
+func (l *StringLibrary) charAt(args []Variant) Variant {
+	functionName := "char-at"
+	if e := ensureExactArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	s, e := ensureStringArg(args[0], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	runes := []rune(s)
+
+	i, e := ensureIndexArg(args[1], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if i < 0 || i >= int64(len(runes)) {
+		return Variant{VariantType: VAR_ERROR, VariantValue: buildIndexOutOfRangeError(i, len(runes), functionName)}
+	}
+	return stringVariantOf(string(runes[i]))
+}
+
+// indexOf returns the character index of the first occurrence of the substring, or -1 if there is none.
+func (l *StringLibrary) indexOf(args []Variant) Variant {
+	return binaryOpStrings(
+		args,
+		func(s string, sub string) Variant {
+			i := strings.Index(s, sub)
+			if i > 0 {
+				i = len([]rune(s[:i]))
+			}
+			return Variant{VariantType: VAR_INT, VariantValue: int64(i)}
+		},
+		"index-of")
+}
+
+func (l *StringLibrary) contains(args []Variant) Variant {
+	return binaryOpStrings(args, func(s string, sub string) Variant { return boolVariantOf(strings.Contains(s, sub)) }, "contains?")
+}
+
+func (l *StringLibrary) startsWith(args []Variant) Variant {
+	return binaryOpStrings(args, func(s string, prefix string) Variant { return boolVariantOf(strings.HasPrefix(s, prefix)) }, "starts-with?")
+}
+
+func (l *StringLibrary) endsWith(args []Variant) Variant {
+	return binaryOpStrings(args, func(s string, suffix string) Variant { return boolVariantOf(strings.HasSuffix(s, suffix)) }, "ends-with?")
+}
+
+// replaceStrings replaces the first n occurrences of old, or all of them if n is negative, once it has checked the
+// length of the result against the limits. An empty old string occurs before every character and at the end.
+func replaceStrings(ctx *EvaluationContext, args []Variant, n int, functionName string) Variant {
+	if e := ensureExactArity(args, 3, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureArgumentTypesMatch(args, []EnumVariantType{VAR_STRING}, []EnumVariantType{}, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	strs := make([]string, len(args))
+	for i, a := range args {
+		s, e := a.CoerceToString()
+		if e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		strs[i] = s
+	}
+
+	count := int64(strings.Count(strs[0], strs[1]))
+	if n >= 0 && count > int64(n) {
+		count = int64(n)
+	}
+	length := addedLength(int64(len(strs[0]))-count*int64(len(strs[1])), repeatedLength(int64(len(strs[2])), count))
+	if e := ctx.ensureStringFits(length, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return stringVariantOf(strings.Replace(strs[0], strs[1], strs[2], n))
+}
+
+func (l *StringLibrary) replace(ctx *EvaluationContext, args []Variant) Variant {
+	return replaceStrings(ctx, args, 1, "replace")
+}
+
+func (l *StringLibrary) replaceAll(ctx *EvaluationContext, args []Variant) Variant {
+	return replaceStrings(ctx, args, -1, "replace-all")
+}
+
+// repeat checks the length of the repeated string against the limits before it builds it.
+func (l *StringLibrary) repeat(ctx *EvaluationContext, args []Variant) Variant {
+	functionName := "repeat"
+	if e := ensureExactArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	s, e := ensureStringArg(args[0], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	n, e := ensureIndexArg(args[1], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if n < 0 {
+		return Variant{VariantType: VAR_ERROR, VariantValue: buildNegativeArgumentError(n, functionName)}
+	}
+
+	if e := ctx.ensureStringFits(repeatedLength(int64(len(s)), n), functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return stringVariantOf(strings.Repeat(s, int(n)))
+}
+
+// repeatedLength is the length of count copies of a piece of the length, clamped to the largest int64 rather than
+// overflowing.
+func repeatedLength(length int64, count int64) int64 {
+	if length > 0 && count > math.MaxInt64/length {
+		return math.MaxInt64
+	}
+	return length * count
+}
+
+// addedLength is the sum of two lengths, clamped to the largest int64 rather than overflowing.
+func addedLength(a int64, b int64) int64 {
+	if a > math.MaxInt64-b {
+		return math.MaxInt64
+	}
+	return a + b
+}
+
+// pad grows the string to the width in characters with copies of the padding, which defaults to a space.
+// Strings that are already wide enough are returned unchanged, and the length of the others is checked against the
+// limits before they are built.
+func pad(ctx *EvaluationContext, args []Variant, left bool, functionName string) Variant {
+	if e := ensureMinimimArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureMaximumArity(args, 3, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	s, e := ensureStringArg(args[0], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	width, e := ensureIndexArg(args[1], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	padding := " "
+	if len(args) == 3 {
+		if padding, e = ensureStringArg(args[2], functionName); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+		if padding == "" {
+			return Variant{VariantType: VAR_ERROR, VariantValue: buildEmptyStringArgumentError(functionName)}
+		}
+	}
+
+	missing := width - int64(utf8.RuneCountInString(s))
+	if missing <= 0 {
+		return stringVariantOf(s)
+	}
+
+	padRunes := []rune(padding)
+	copies, rest := missing/int64(len(padRunes)), missing%int64(len(padRunes))
+	length := repeatedLength(int64(len(padding)), copies)
+	if length < math.MaxInt64-int64(len(s))-int64(len(padding)) {
+		length += int64(len(s)) + int64(len(string(padRunes[:rest])))
+	}
+	if e := ctx.ensureStringFits(length, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	fill := make([]rune, missing)
+	for i := range fill {
+		fill[i] = padRunes[i%len(padRunes)]
+	}
+
+	if left {
+		return stringVariantOf(string(fill) + s)
+	}
+	return stringVariantOf(s + string(fill))
+}
+
+func (l *StringLibrary) padLeft(ctx *EvaluationContext, args []Variant) Variant {
+	return pad(ctx, args, true, "pad-left")
+}
+
+func (l *StringLibrary) padRight(ctx *EvaluationContext, args []Variant) Variant {
+	return pad(ctx, args, false, "pad-right")
 }
 
 func (l *StringLibrary) InjectFunctions(functions FunctionTable) FunctionTable {
+	functions["upper"] = l.upper
+	functions["lower"] = l.lower
+	functions["title"] = l.title
+	functions["trim"] = l.trim
+	functions["trim-left"] = l.trimLeft
+	functions["trim-right"] = l.trimRight
+	functions["substring"] = l.substring
+	functions["string-length"] = l.length
+	functions["index-of"] = l.indexOf
+	functions["contains?"] = l.contains
+	functions["starts-with?"] = l.startsWith
+	functions["ends-with?"] = l.endsWith
+	functions["reverse"] = l.reverse
+	functions["char-at"] = l.charAt
+	return functions
+}
+
+func (l *StringLibrary) InjectContextFunctions(functions ContextFunctionTable) ContextFunctionTable {
 	functions["concat"] = l.concat
 	functions["++"] = l.concat
+	functions["split"] = l.split
+	functions["join"] = l.join
+	functions["replace"] = l.replace
+	functions["replace-all"] = l.replaceAll
+	functions["repeat"] = l.repeat
+	functions["pad-left"] = l.padLeft
+	functions["pad-right"] = l.padRight
 	return functions
 }
+
+func (l *StringLibrary) Documentation() map[string]string {
+	return map[string]string{
+		"concat":        "(concat a b ...) joins the arguments into one string.",
+		"++":            "(++ a b ...) joins the arguments into one string.",
+		"upper":         "(upper s) upper-cases the string.",
+		"lower":         "(lower s) lower-cases the string.",
+		"title":         "(title s) upper-cases the first letter of every word and lower-cases the rest.",
+		"trim":          "(trim s) removes the white space from both ends of the string.",
+		"trim-left":     "(trim-left s) removes the white space from the start of the string.",
+		"trim-right":    "(trim-right s) removes the white space from the end of the string.",
+		"split":         "(split s sep) is a vector of the pieces of s between separators, or of its characters if sep is empty.",
+		"join":          "(join sep coll) joins the items of the collection into a string, with the separator between them.",
+		"substring":     "(substring s start [end]) is the characters of s from start up to, but not including, end.",
+		"string-length": "(string-length s) counts the characters of the string.",
+		"index-of":      "(index-of s sub) is the index of the first sub in s, or -1 if there is none.",
+		"contains?":     "(contains? s sub) is true if sub is in s.",
+		"starts-with?":  "(starts-with? s prefix) is true if s starts with the prefix.",
+		"ends-with?":    "(ends-with? s suffix) is true if s ends with the suffix.",
+		"replace":       "(replace s old new) replaces the first old in s with new.",
+		"replace-all":   "(replace-all s old new) replaces every old in s with new.",
+		"repeat":        "(repeat s n) joins n copies of the string.",
+		"pad-left":      "(pad-left s width [padding]) pads the start of the string to the width, with spaces by default.",
+		"pad-right":     "(pad-right s width [padding]) pads the end of the string to the width, with spaces by default.",
+		"reverse":       "(reverse s) reverses the characters of the string.",
+		"char-at":       "(char-at s i) is the character at index i of the string.",
+	}
+}
+
+func (l *StringLibrary) Signatures() map[string]Signature {
+	text := exactly(1, stringType)
+	twoStrings := exactly(2, stringType, stringType)
+	threeStrings := exactly(3, stringType, stringType, stringType)
+	return map[string]Signature{
+		"concat":        atLeast(1),
+		"++":            atLeast(1),
+		"upper":         text,
+		"lower":         text,
+		"title":         text,
+		"trim":          text,
+		"trim-left":     text,
+		"trim-right":    text,
+		"split":         twoStrings,
+		"join":          exactly(2, stringType, sequenceArgTypes),
+		"substring":     between(2, 3, stringType, intType, intType),
+		"string-length": text,
+		"index-of":      twoStrings,
+		"contains?":     twoStrings,
+		"starts-with?":  twoStrings,
+		"ends-with?":    twoStrings,
+		"replace":       threeStrings,
+		"replace-all":   threeStrings,
+		"repeat":        exactly(2, stringType, intType),
+		"pad-left":      between(2, 3, stringType, intType, stringType),
+		"pad-right":     between(2, 3, stringType, intType, stringType),
+		"reverse":       text,
+		"char-at":       exactly(2, stringType, intType),
+	}
+}
@@ -42,8 +42,69 @@ func TestConcat(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.desc, func(t *testing.T) {
-			actual := str.concat(test.input)
+			actual := str.concat(nil, test.input)
 			assert.Equal(t, test.expected, actual, "computation error")
 		})
 	}
 }
+
+func TestStringFunctions(t *testing.T) {
+	context := NewEvaluationContext(nil)
+
+	tests := [...]struct {
+		desc     string
+		input    string
+		expected Variant
+	}{
+		{desc: "upper", input: `(upper "héllo")`, expected: stringVariant("HÉLLO")},
+		{desc: "lower", input: `(lower "ÀB")`, expected: stringVariant("àb")},
+		{desc: "title", input: `(title "o'neil mcDONALD-smith")`, expected: stringVariant("O'neil Mcdonald-Smith")},
+		{desc: "upper of a number", input: `(upper 1)`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnacceptableTypeError(VAR_INT, "upper")}},
+		{desc: "trim", input: `(trim "  a b  ")`, expected: stringVariant("a b")},
+		{desc: "trim-left", input: `(trim-left "  a ")`, expected: stringVariant("a ")},
+		{desc: "trim-right", input: `(trim-right "  a ")`, expected: stringVariant("  a")},
+		{desc: "split", input: `(split "a,b,,c" ",")`, expected: vectorVariant(stringVariant("a"), stringVariant("b"), stringVariant(""), stringVariant("c"))},
+		{desc: "split into characters", input: `(split "hé" "")`, expected: vectorVariant(stringVariant("h"), stringVariant("é"))},
+		{desc: "join", input: `(join ", " ["a" 1 "c"])`, expected: stringVariant("a, 1, c")},
+		{desc: "join floats", input: `(join ", " [(/ 3 2) 0.25])`, expected: stringVariant("1.5, 0.25")},
+		{desc: "join nothing", input: `(join ", " [])`, expected: stringVariant("")},
+		{desc: "join a string", input: `(join ", " "abc")`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnacceptableTypeError(VAR_STRING, "join")}},
+		{desc: "substring", input: `(substring "héllo" 1 3)`, expected: stringVariant("él")},
+		{desc: "substring to end", input: `(substring "héllo" 2)`, expected: stringVariant("llo")},
+		{desc: "substring out of range", input: `(substring "héllo" 2 6)`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildIndexOutOfRangeError(6, 5, "substring")}},
+		{desc: "string-length", input: `(string-length "héllo")`, expected: intVariant(5)},
+		{desc: "index-of", input: `(index-of "héllo" "l")`, expected: intVariant(2)},
+		{desc: "index-of missing", input: `(index-of "héllo" "z")`, expected: intVariant(-1)},
+		{desc: "str/contains?", input: `(str/contains? "héllo" "él")`, expected: boolVariant(true)},
+		{desc: "contains? on a string", input: `(contains? "héllo" "él")`, expected: boolVariant(true)},
+		{desc: "contains? on a string without the substring", input: `(contains? "héllo" "le")`, expected: boolVariant(false)},
+		{desc: "starts-with?", input: `(starts-with? "ORD-1" "ORD-")`, expected: boolVariant(true)},
+		{desc: "ends-with?", input: `(ends-with? "ORD-1" "2")`, expected: boolVariant(false)},
+		{desc: "replace", input: `(replace "a-b-c" "-" "+")`, expected: stringVariant("a+b-c")},
+		{desc: "replace-all", input: `(replace-all "a-b-c" "-" "+")`, expected: stringVariant("a+b+c")},
+		{desc: "repeat", input: `(repeat "ab" 3)`, expected: stringVariant("ababab")},
+		{desc: "repeat negative", input: `(repeat "ab" -1)`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildNegativeArgumentError(-1, "repeat")}},
+		{desc: "repeat too long to build", input: `(repeat "ab" 4_611_686_018_427_387_904)`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildValueTooLargeError(9_223_372_036_854_775_807, "repeat")}},
+		{desc: "pad-left", input: `(pad-left "7" 3 "0")`, expected: stringVariant("007")},
+		{desc: "pad-left with a long padding", input: `(pad-left "7" 4 "ab")`, expected: stringVariant("aba7")},
+		{desc: "pad-right", input: `(pad-right "é" 3)`, expected: stringVariant("é  ")},
+		{desc: "pad too long to build", input: `(pad-left "7" 9_000_000_000 "é")`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildValueTooLargeError(17_999_999_999, "pad-left")}},
+		{desc: "pad already wide", input: `(pad-right "abc" 2)`, expected: stringVariant("abc")},
+		{desc: "pad with nothing", input: `(pad-right "a" 2 "")`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildEmptyStringArgumentError("pad-right")}},
+		{desc: "reverse", input: `(reverse "héllo")`, expected: stringVariant("olléh")},
+		{desc: "char-at", input: `(char-at "héllo" 1)`, expected: stringVariant("é")},
+		{desc: "char-at out of range", input: `(char-at "" 0)`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildIndexOutOfRangeError(0, 0, "char-at")}},
+		{desc: "concat a float", input: `(concat "x" (/ 3 2))`, expected: stringVariant("x1.5")},
+		{desc: "concat with no arguments", input: `(concat)`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildMinimumArityError(1, "concat")}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			sexpr, e := Parse(test.input)
+			assert.Nil(t, e, "parse error")
+
+			actual := sexpr.Eval(context)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package golisp
+
+// VectorLibrary works with vectors, which are immutable slices with O(1) indexed access. They are not persistent:
+// nothing is shared between a vector and a changed copy of it, so vec-set copies every item.
+type VectorLibrary struct {
+}
+
+func (l *VectorLibrary) Namespace() string {
+	return "vec"
+}
+
+var vectorType = []EnumVariantType{VAR_VECTOR}
+
+func ensureVectorArg(args []Variant, functionName string) ([]Variant, error) {
+	if e := ensureArgumentTypesMatch(args[:1], vectorType, []EnumVariantType{}, functionName); e != nil {
+		return nil, e
+	}
+	return args[0].GetVectorValue()
+}
+
+func ensureIndexArg(arg Variant, functionName string) (int64, error) {
+	if e := ensureArgumentTypesMatch([]Variant{arg}, []EnumVariantType{VAR_INT}, []EnumVariantType{}, functionName); e != nil {
+		return 0, e
+	}
+	return arg.CoerceToInt()
+}
+
+func (l *VectorLibrary) vector(args []Variant) Variant {
+	if e := ensureValidArgs(args); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return Variant{VariantType: VAR_VECTOR, VariantValue: append([]Variant{}, args...)}
+}
+
+func (l *VectorLibrary) ref(args []Variant) Variant {
+	functionName := "vec-ref"
+	if e := ensureExactArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	items, e := ensureVectorArg(args, functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	i, e := ensureIndexArg(args[1], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if i < 0 || i >= int64(len(items)) {
+		return Variant{VariantType: VAR_ERROR, VariantValue: buildIndexOutOfRangeError(i, len(items), functionName)}
+	}
+	return items[i]
+}
+
+// set returns a copy of the vector with the item at the index replaced, leaving the original untouched. The copy is
+// O(n) in the length of the vector.
+func (l *VectorLibrary) set(args []Variant) Variant {
+	functionName := "vec-set"
+	if e := ensureExactArity(args, 3, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	items, e := ensureVectorArg(args, functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	i, e := ensureIndexArg(args[1], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureTypeIsNotInvalid(args[2]); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if i < 0 || i >= int64(len(items)) {
+		return Variant{VariantType: VAR_ERROR, VariantValue: buildIndexOutOfRangeError(i, len(items), functionName)}
+	}
+
+	result := append([]Variant{}, items...)
+	result[i] = args[2]
+	return Variant{VariantType: VAR_VECTOR, VariantValue: result}
+}
+
+// subvec returns the items from start up to, but not including, end. The end defaults to the length of the vector.
+func (l *VectorLibrary) subvec(args []Variant) Variant {
+	functionName := "subvec"
+	if e := ensureMinimimArity(args, 2, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	if e := ensureMaximumArity(args, 3, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	items, e := ensureVectorArg(args, functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	start, e := ensureIndexArg(args[1], functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	end := int64(len(items))
+	if len(args) == 3 {
+		if end, e = ensureIndexArg(args[2], functionName); e != nil {
+			return Variant{VariantType: VAR_ERROR, VariantValue: e}
+		}
+	}
+
+	if start < 0 || start > int64(len(items)) {
+		return Variant{VariantType: VAR_ERROR, VariantValue: buildIndexOutOfRangeError(start, len(items), functionName)}
+	}
+
+	if end < start || end > int64(len(items)) {
+		return Variant{VariantType: VAR_ERROR, VariantValue: buildIndexOutOfRangeError(end, len(items), functionName)}
+	}
+
+	// the capacity is capped so that appending to the result can never write into the original vector
+	return Variant{VariantType: VAR_VECTOR, VariantValue: items[start:end:end]}
+}
+
+func (l *VectorLibrary) length(args []Variant) Variant {
+	functionName := "vec-length"
+	if e := ensureExactArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	items, e := ensureVectorArg(args, functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return Variant{VariantType: VAR_INT, VariantValue: int64(len(items))}
+}
+
+func (l *VectorLibrary) toList(args []Variant) Variant {
+	functionName := "vec->list"
+	if e := ensureExactArity(args, 1, functionName); e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+
+	items, e := ensureVectorArg(args, functionName)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR, VariantValue: e}
+	}
+	return Variant{VariantType: VAR_LIST, VariantValue: items}
+}
+
+func (l *VectorLibrary) InjectFunctions(functions FunctionTable) FunctionTable {
+	functions["vector"] = l.vector
+	functions["vec-ref"] = l.ref
+	functions["vec-set"] = l.set
+	functions["subvec"] = l.subvec
+	functions["vec-length"] = l.length
+	functions["vec->list"] = l.toList
+	return functions
+}
+
+func (l *VectorLibrary) Documentation() map[string]string {
+	return map[string]string{
+		"vector":     "(vector x ...) makes a vector of the arguments.",
+		"vec-ref":    "(vec-ref v i) is the item at index i of the vector.",
+		"vec-set":    "(vec-set v i x) is a copy of the vector with the item at index i replaced by x, which copies every item.",
+		"subvec":     "(subvec v start [end]) is the items of the vector from start up to, but not including, end.",
+		"vec-length": "(vec-length v) counts the items of the vector.",
+		"vec->list":  "(vec->list v) is a list of the items of the vector.",
+	}
+}
+
+func (l *VectorLibrary) Signatures() map[string]Signature {
+	return map[string]Signature{
+		"vector":     atLeast(0),
+		"vec-ref":    exactly(2, vectorType, intType),
+		"vec-set":    exactly(3, vectorType, intType),
+		"subvec":     between(2, 3, vectorType, intType, intType),
+		"vec-length": exactly(1, vectorType),
+		"vec->list":  exactly(1, vectorType),
+	}
+}
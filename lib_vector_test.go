@@ -0,0 +1,72 @@
+package golisp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var vectors = &(VectorLibrary{})
+
+func vectorVariant(items ...Variant) Variant {
+	return Variant{VariantType: VAR_VECTOR, VariantValue: append([]Variant{}, items...)}
+}
+
+func TestVecSet(t *testing.T) {
+	input := vectorVariant(intVariant(1), intVariant(2), intVariant(3))
+
+	actual := vectors.set([]Variant{input, intVariant(1), stringVariant("b")})
+	assert.Equal(t, vectorVariant(intVariant(1), stringVariant("b"), intVariant(3)), actual)
+	assert.Equal(t, vectorVariant(intVariant(1), intVariant(2), intVariant(3)), input, "input must not be modified")
+}
+
+func TestSubvecDoesNotShareCapacity(t *testing.T) {
+	input := vectorVariant(intVariant(1), intVariant(2), intVariant(3))
+
+	actual := vectors.subvec([]Variant{input, intVariant(0), intVariant(1)})
+	items, e := actual.GetVectorValue()
+	assert.Nil(t, e)
+
+	_ = append(items, intVariant(9))
+	assert.Equal(t, vectorVariant(intVariant(1), intVariant(2), intVariant(3)), input, "input must not be modified")
+}
+
+func TestVector(t *testing.T) {
+	context := NewEvaluationContext(nil)
+
+	tests := [...]struct {
+		desc     string
+		input    string
+		expected Variant
+	}{
+		{desc: "literal", input: `[1 "a" (+ 1 1)]`, expected: vectorVariant(intVariant(1), stringVariant("a"), intVariant(2))},
+		{desc: "empty literal", input: `[]`, expected: vectorVariant()},
+		{desc: "nested literal", input: `[[1] {"a" [2]}]`, expected: vectorVariant(vectorVariant(intVariant(1)), mapVariant(stringVariant("a"), vectorVariant(intVariant(2))))},
+		{desc: "literal with error", input: `[1 nope]`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnresolvedIdentifierError("nope")}},
+		{desc: "vector", input: `(vector 1 2)`, expected: vectorVariant(intVariant(1), intVariant(2))},
+		{desc: "vec-ref", input: `(vec-ref [1 2 3] 2)`, expected: intVariant(3)},
+		{desc: "vec-ref out of range", input: `(vec-ref [1 2 3] 3)`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildIndexOutOfRangeError(3, 3, "vec-ref")}},
+		{desc: "vec-ref of a list", input: `(vec-ref (vec->list [1]) 0)`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildUnacceptableTypeError(VAR_LIST, "vec-ref")}},
+		{desc: "vec-set", input: `(vec-set [1 2] 0 "a")`, expected: vectorVariant(stringVariant("a"), intVariant(2))},
+		{desc: "subvec", input: `(subvec [1 2 3 4] 1 3)`, expected: vectorVariant(intVariant(2), intVariant(3))},
+		{desc: "subvec to end", input: `(subvec [1 2 3 4] 2)`, expected: vectorVariant(intVariant(3), intVariant(4))},
+		{desc: "subvec reversed", input: `(subvec [1 2 3 4] 3 1)`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildIndexOutOfRangeError(1, 4, "subvec")}},
+		{desc: "vec-length", input: `(vec-length [1 2 3])`, expected: intVariant(3)},
+		{desc: "vec->list", input: `(vec->list [1 2])`, expected: Variant{VariantType: VAR_LIST, VariantValue: []Variant{intVariant(1), intVariant(2)}}},
+		{desc: "vectors and lists differ", input: `(= [1 2] (vec->list [1 2]))`, expected: Variant{VariantType: VAR_BOOL, VariantValue: false}},
+		{desc: "get by index", input: `(get ["a" "b"] 1)`, expected: stringVariant("b")},
+		{desc: "get-in json array", input: `(get-in (json-parse "[[1, 2], [3]]") [1 0])`, expected: intVariant(3)},
+		{desc: "vector as map key", input: `(get {[1 2] "a"} [1 2])`, expected: stringVariant("a")},
+		{desc: "debug string", input: `(concat "v: " [1 "a"])`, expected: stringVariant(`v: [1 "a"]`)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			sexpr, e := Parse(test.input)
+			assert.Nil(t, e, "parse error")
+
+			actual := sexpr.Eval(context)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package golisp
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"unsafe"
+)
+
+// Limits caps the work that evaluations in a context may do, so that the cost of untrusted rules is bounded.
+// A zero field means that there is no limit.
+//
+// Strings and collections are measured when a function returns them or a literal builds them. The library functions
+// that build a value whose size the script chooses, such as repeat, range, join and replace-all, check that it is within
+// the limits before they build it, as do interpolated strings.
+type Limits struct {
+	MaxSteps          int64 // function calls, including calls made by libraries to functions passed to them
+	MaxDepth          int   // nesting of function calls
+	MaxStringLength   int   // bytes in any string produced
+	MaxCollectionSize int   // items in any list, vector or set, or entries in any map produced
+	MaxAllocatedBytes int64 // estimate of the memory used by all the values produced
+}
+
+// Usage counts the work done by evaluations in a context since it was created or its usage was last reset.
+type Usage struct {
+	Steps          int64
+	MaxDepth       int
+	AllocatedBytes int64
+}
+
+// resourceMeter counts the depth of the calls made by one goroutine, against totals shared by a root context and its
+// children, including the children evaluating futures in other goroutines.
+type resourceMeter struct {
+	totals *meterTotals
+	depth  int
+}
+
+type meterTotals struct {
+	mutex    sync.Mutex
+	limits   Limits
+	usage    Usage
+	exceeded error
+}
+
+func newResourceMeter(limits Limits) *resourceMeter {
+	return &resourceMeter{totals: &meterTotals{limits: limits}}
+}
+
+// fork returns a meter for another goroutine, which starts at the current depth and shares the totals.
+func (m *resourceMeter) fork() *resourceMeter {
+	return &resourceMeter{totals: m.totals, depth: m.depth}
+}
+
+var errLimitExceeded = errors.New("limit exceeded")
+
+// maxBuiltLength bounds the strings and collections that library functions build whatever the limits, so that a
+// script asking for an absurd size gets an error rather than a panic from the runtime.
+const maxBuiltLength = math.MaxInt32
+
+var variantSize = int64(unsafe.Sizeof(Variant{}))
+
+// WithLimits caps the work done by evaluations in the context. A child context given limits counts its work
+// separately from its parent.
+func WithLimits(limits Limits) ContextOption {
+	return func(ctx *EvaluationContext) {
+		ctx.meter = newResourceMeter(limits)
+	}
+}
+
+// Usage reports the work done by evaluations in the context and its children.
+func (ctx *EvaluationContext) Usage() Usage {
+	t := ctx.meter.totals
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.usage
+}
+
+// ResetUsage clears the counters, and a limit that has tripped, so the context can be reused for another evaluation.
+func (ctx *EvaluationContext) ResetUsage() {
+	t := ctx.meter.totals
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.usage = Usage{}
+	t.exceeded = nil
+}
+
+// IsLimitExceeded reports whether the variant is the error of an evaluation that went over one of its Limits.
+func IsLimitExceeded(v Variant) bool {
+	if v.VariantType != VAR_ERROR {
+		return false
+	}
+
+	e, ok := v.VariantValue.(error)
+	return ok && errors.Is(e, errLimitExceeded)
+}
+
+// step counts a function call. Once a limit has tripped, every later call fails with the same error.
+func (m *resourceMeter) step() error {
+	t := m.totals
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.exceeded != nil {
+		return t.exceeded
+	}
+
+	t.usage.Steps++
+	if t.limits.MaxSteps > 0 && t.usage.Steps > t.limits.MaxSteps {
+		return t.trip("MaxSteps", t.usage.Steps, t.limits.MaxSteps)
+	}
+	return nil
+}
+
+// descend counts a level of nesting, which must be matched by a call to ascend whether or not it fails.
+func (m *resourceMeter) descend() error {
+	m.depth++
+	return m.totals.checkDepth(m.depth)
+}
+
+func (m *resourceMeter) ascend() {
+	m.depth--
+}
+
+func (t *meterTotals) checkDepth(depth int) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if depth > t.usage.MaxDepth {
+		t.usage.MaxDepth = depth
+	}
+
+	if t.exceeded != nil {
+		return t.exceeded
+	}
+
+	if t.limits.MaxDepth > 0 && depth > t.limits.MaxDepth {
+		return t.trip("MaxDepth", int64(depth), int64(t.limits.MaxDepth))
+	}
+	return nil
+}
+
+// account measures a value that has just been produced.
+// Nested strings and collections were measured when they were produced, so only the top level is counted.
+func (m *resourceMeter) account(v Variant) error {
+	length := 0
+	size := variantSize
+	switch v.VariantType {
+	case VAR_STRING:
+		s, _ := v.VariantValue.(string)
+		length = len(s)
+		size += int64(length)
+
+	case VAR_LIST, VAR_VECTOR:
+		items, _ := v.VariantValue.([]Variant)
+		length = len(items)
+		size += int64(length) * variantSize
+
+	case VAR_SET:
+		if s, ok := v.VariantValue.(*VariantSet); ok {
+			length = s.Len()
+			size += int64(length) * variantSize
+		}
+
+	case VAR_MAP:
+		if mv, ok := v.VariantValue.(*VariantMap); ok {
+			length = mv.Len()
+			size += int64(length) * 2 * variantSize
+		}
+	}
+
+	t := m.totals
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.exceeded != nil {
+		return t.exceeded
+	}
+
+	if v.VariantType == VAR_STRING && t.limits.MaxStringLength > 0 && length > t.limits.MaxStringLength {
+		return t.trip("MaxStringLength", int64(length), int64(t.limits.MaxStringLength))
+	}
+
+	if v.VariantType != VAR_STRING && t.limits.MaxCollectionSize > 0 && length > t.limits.MaxCollectionSize {
+		return t.trip("MaxCollectionSize", int64(length), int64(t.limits.MaxCollectionSize))
+	}
+
+	t.usage.AllocatedBytes += size
+	if t.limits.MaxAllocatedBytes > 0 && t.usage.AllocatedBytes > t.limits.MaxAllocatedBytes {
+		return t.trip("MaxAllocatedBytes", t.usage.AllocatedBytes, t.limits.MaxAllocatedBytes)
+	}
+	return nil
+}
+
+// reserve checks, before a function builds a string or collection of the length and estimated size, that it would be
+// within the limits. The value is accounted for when the function returns it, like any other.
+func (m *resourceMeter) reserve(isString bool, length int64, size int64) error {
+	t := m.totals
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.exceeded != nil {
+		return t.exceeded
+	}
+
+	if isString && t.limits.MaxStringLength > 0 && length > int64(t.limits.MaxStringLength) {
+		return t.trip("MaxStringLength", length, int64(t.limits.MaxStringLength))
+	}
+
+	if !isString && t.limits.MaxCollectionSize > 0 && length > int64(t.limits.MaxCollectionSize) {
+		return t.trip("MaxCollectionSize", length, int64(t.limits.MaxCollectionSize))
+	}
+
+	if t.limits.MaxAllocatedBytes > 0 && t.usage.AllocatedBytes+size > t.limits.MaxAllocatedBytes {
+		return t.trip("MaxAllocatedBytes", t.usage.AllocatedBytes+size, t.limits.MaxAllocatedBytes)
+	}
+	return nil
+}
+
+// ensureStringFits checks, before a function builds a string of the length in bytes, that the evaluation calling it
+// may build it.
+func (ctx *EvaluationContext) ensureStringFits(length int64, functionName string) error {
+	if length > maxBuiltLength {
+		return buildValueTooLargeError(length, functionName)
+	}
+	if ctx == nil {
+		return nil
+	}
+	return ctx.meter.reserve(true, length, variantSize+length)
+}
+
+// ensureCollectionFits checks, before a function builds a collection of the length, that the evaluation calling it
+// may build it.
+func (ctx *EvaluationContext) ensureCollectionFits(length int64, functionName string) error {
+	if length > maxBuiltLength {
+		return buildValueTooLargeError(length, functionName)
+	}
+	if ctx == nil {
+		return nil
+	}
+	return ctx.meter.reserve(false, length, variantSize+length*variantSize)
+}
+
+// trip records the limit that was exceeded. The mutex must be held.
+func (t *meterTotals) trip(limit string, value int64, max int64) error {
+	t.exceeded = buildLimitExceededError(limit, value, max)
+	return t.exceeded
+}
@@ -0,0 +1,77 @@
+package golisp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimits(t *testing.T) {
+	tests := [...]struct {
+		desc     string
+		limits   Limits
+		input    string
+		expected Variant
+	}{
+		{desc: "within every limit", limits: Limits{MaxSteps: 10, MaxDepth: 10, MaxStringLength: 10, MaxCollectionSize: 10, MaxAllocatedBytes: 1024}, input: "(+ 1 (* 2 3))", expected: intVariant(7)},
+		{desc: "steps", limits: Limits{MaxSteps: 3}, input: "(+ 1 (+ 2 (+ 3 (+ 4 5))))", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildLimitExceededError("MaxSteps", 4, 3)}},
+		{desc: "steps include callbacks", limits: Limits{MaxSteps: 3}, input: "(map abs [1 -2 3])", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildLimitExceededError("MaxSteps", 4, 3)}},
+		{desc: "depth", limits: Limits{MaxDepth: 2}, input: "(+ 1 (+ 2 (+ 3 4)))", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildLimitExceededError("MaxDepth", 3, 2)}},
+		{desc: "string length", limits: Limits{MaxStringLength: 5}, input: `(concat "abc" "def")`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildLimitExceededError("MaxStringLength", 6, 5)}},
+		{desc: "string length of a function passed to another", limits: Limits{MaxStringLength: 5}, input: `(apply concat ["ab" "cd" "ef"])`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildLimitExceededError("MaxStringLength", 6, 5)}},
+		{desc: "pieces of a split", limits: Limits{MaxCollectionSize: 3}, input: `(split "a,b,c,d" ",")`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildLimitExceededError("MaxCollectionSize", 4, 3)}},
+		{desc: "characters of a split", limits: Limits{MaxCollectionSize: 3}, input: `(split "héllo" "")`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildLimitExceededError("MaxCollectionSize", 5, 3)}},
+		{desc: "allocated bytes of a string before it is built", limits: Limits{MaxAllocatedBytes: variantSize + 5}, input: `(concat "abc" "def")`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildLimitExceededError("MaxAllocatedBytes", variantSize+6, variantSize+5)}},
+		{desc: "string length of a repeat before it is built", limits: Limits{MaxStringLength: 5}, input: `(repeat "ab" 3)`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildLimitExceededError("MaxStringLength", 6, 5)}},
+		{desc: "string length of a pad before it is built", limits: Limits{MaxStringLength: 5}, input: `(pad-right "é" 5 "ab")`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildLimitExceededError("MaxStringLength", 6, 5)}},
+		{desc: "string length of a join before it is built", limits: Limits{MaxStringLength: 5}, input: `(join "---" ["a" "b" "c"])`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildLimitExceededError("MaxStringLength", 9, 5)}},
+		{desc: "string length of a replace-all before it is built", limits: Limits{MaxStringLength: 5}, input: `(replace-all "abc" "" "-")`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildLimitExceededError("MaxStringLength", 7, 5)}},
+		{desc: "string length of a re-replace before it is built", limits: Limits{MaxStringLength: 5}, input: `(re-replace "b" "abc" "<<$0>>")`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildLimitExceededError("MaxStringLength", 7, 5)}},
+		{desc: "string length of a format-number before it is built", limits: Limits{MaxStringLength: 5}, input: "(format-number 1 300000000)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildLimitExceededError("MaxStringLength", 300000002, 5)}},
+		{desc: "string length of a sprintf before it is built", limits: Limits{MaxStringLength: 100}, input: `(sprintf "%999999d" 1)`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildLimitExceededError("MaxStringLength", 8+999999+65, 100)}},
+		{desc: "string length of a sprintf with a width from an argument", limits: Limits{MaxStringLength: 100}, input: `(sprintf "%*s" 200 "a")`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildLimitExceededError("MaxStringLength", 3+200+1, 100)}},
+		{desc: "string length of an interpolated string", limits: Limits{MaxStringLength: 5}, input: `$"abc{(+ 100 200)}"`, expected: Variant{VariantType: VAR_ERROR, VariantValue: buildLimitExceededError("MaxStringLength", 6, 5)}},
+		{desc: "collection size of a vector literal", limits: Limits{MaxCollectionSize: 2}, input: "[1 2 3]", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildLimitExceededError("MaxCollectionSize", 3, 2)}},
+		{desc: "collection size of a map literal", limits: Limits{MaxCollectionSize: 1}, input: "{:a 1 :b 2}", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildLimitExceededError("MaxCollectionSize", 2, 1)}},
+		{desc: "collection size of a set literal", limits: Limits{MaxCollectionSize: 1}, input: "#{1 2}", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildLimitExceededError("MaxCollectionSize", 2, 1)}},
+		{desc: "collection size", limits: Limits{MaxCollectionSize: 10}, input: "(range 0 11)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildLimitExceededError("MaxCollectionSize", 11, 10)}},
+		{desc: "collection size before it is built", limits: Limits{MaxCollectionSize: 10, MaxAllocatedBytes: 1 << 20}, input: "(range 300_000_000)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildLimitExceededError("MaxCollectionSize", 300_000_000, 10)}},
+		{desc: "capacity of a channel", limits: Limits{MaxCollectionSize: 10}, input: "(chan 11)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildLimitExceededError("MaxCollectionSize", 11, 10)}},
+		{desc: "allocated bytes", limits: Limits{MaxAllocatedBytes: 100 * variantSize}, input: "(range 0 100)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildLimitExceededError("MaxAllocatedBytes", 101*variantSize, 100*variantSize)}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			sexpr, e := Parse(test.input)
+			assert.Nil(t, e, "parse error")
+
+			actual := sexpr.Eval(NewEvaluationContext(nil, WithLimits(test.limits)))
+			assert.Equal(t, test.expected, actual)
+			assert.Equal(t, test.expected.VariantType == VAR_ERROR, IsLimitExceeded(actual))
+		})
+	}
+}
+
+func TestUsage(t *testing.T) {
+	ctx := NewEvaluationContext(nil, WithLimits(Limits{MaxSteps: 5}))
+
+	sexpr, _ := Parse("(map abs [1 -2 3])")
+	assert.Equal(t, vectorVariant(intVariant(1), intVariant(2), intVariant(3)), sexpr.Eval(ctx))
+	assert.Equal(t, int64(4), ctx.Usage().Steps)
+	assert.Equal(t, 2, ctx.Usage().MaxDepth)
+	// the vector literal, the three results of abs and the vector that map returns
+	assert.Equal(t, 11*variantSize, ctx.Usage().AllocatedBytes)
+
+	sexpr, _ = Parse("(+ 1 (+ 2 3))")
+	assert.True(t, IsLimitExceeded(sexpr.Eval(ctx)))
+
+	sexpr, _ = Parse("(+ 1 2)")
+	assert.True(t, IsLimitExceeded(sexpr.Eval(ctx)), "a tripped limit stays tripped")
+
+	ctx.ResetUsage()
+	assert.Equal(t, intVariant(3), sexpr.Eval(ctx))
+	assert.Equal(t, Usage{Steps: 1, MaxDepth: 1, AllocatedBytes: variantSize}, ctx.Usage())
+
+	sexpr, _ = Parse("(/ 1 0)")
+	assert.False(t, IsLimitExceeded(sexpr.Eval(ctx)))
+}
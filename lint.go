@@ -0,0 +1,431 @@
+package golisp
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Severity ranks diagnostics by how likely they are to be mistakes.
+type Severity uint8
+
+const (
+	SeverityError   Severity = iota // the script fails when it reaches this
+	SeverityWarning                 // this is probably not what was meant
+	SeverityInfo                    // this may not be what was meant
+)
+
+func (s Severity) String() string {
+	names := [...]string{"error", "warning", "info"}
+	if int(s) >= len(names) {
+		return "unknown"
+	}
+	return names[s]
+}
+
+// The rules that the linter checks. They identify its diagnostics, and name them in suppressions.
+const (
+	RuleUnknownFunction = "unknown-function" // a call to a function that isn't defined
+	RuleArity           = "arity"            // a call to a library function with the wrong number of arguments
+	RuleTypeMismatch    = "type-mismatch"    // a literal argument of a type that the function never accepts
+	RuleUnusedSymbol    = "unused-symbol"    // a symbol bound in the context that the script doesn't use
+	RuleShadowedBuiltin = "shadowed-builtin" // a symbol that hides the function of the same name
+	RuleUnreachable     = "unreachable"      // arguments that can't change the result of and, or and the like
+	RuleSuspiciousDate  = "suspicious-date"  // a literal read as a date that may have been meant as something else
+)
+
+// Diagnostic describes a likely mistake in a script. Diagnostics about the context rather than the script, such as an
+// unused symbol, have no position.
+type Diagnostic struct {
+	Rule     string
+	Severity Severity
+	Position Position
+	End      Position
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	if !d.Position.IsValid() {
+		return fmt.Sprintf("%s: %s (%s)", d.Severity, d.Message, d.Rule)
+	}
+	return fmt.Sprintf("%s: %s: %s (%s)", d.Position, d.Severity, d.Message, d.Rule)
+}
+
+// Lint checks an expression against the default libraries. See EvaluationContext.Lint.
+func Lint(expr SExpr) []Diagnostic {
+	return NewEvaluationContext(nil).Lint(expr)
+}
+
+// LintSource parses and checks a script against the default libraries. See EvaluationContext.LintSource.
+func LintSource(src string) ([]Diagnostic, error) {
+	return NewEvaluationContext(nil).LintSource(src)
+}
+
+// Lint checks an expression for mistakes that would otherwise only show up as errors when it is evaluated, using the
+// functions and symbols of the context. Nothing is evaluated or called: calls to library functions are checked against
+// the signatures their libraries declare, and calls to other functions, such as go functions, only by name.
+func (ctx *EvaluationContext) Lint(expr SExpr) []Diagnostic {
+	return ctx.lintForms([]SExpr{expr})
+}
+
+// LintSource parses and checks every form of a script. A comment (* lint:ignore rule ... *) suppresses the rules it
+// names, or every rule if it names none, in the form that follows it, or on its own line if it follows a form on that
+// line. A comment (* lint:ignore-file rule ... *) suppresses them in the whole script.
+func (ctx *EvaluationContext) LintSource(src string) ([]Diagnostic, error) {
+	return ctx.checkSource(src, ctx.lintForms)
+}
+
+// checkSource parses a script and checks its forms, dropping the diagnostics that its comments suppress.
+func (ctx *EvaluationContext) checkSource(src string, check func([]SExpr) []Diagnostic) ([]Diagnostic, error) {
+	forms, e := ParseAll(src)
+	if e != nil {
+		return nil, e
+	}
+
+	nodes, e := parseSyntax(src)
+	if e != nil {
+		return nil, e
+	}
+
+	suppressions := findSuppressions(nodes)
+	diagnostics := []Diagnostic{}
+	for _, d := range check(forms) {
+		if !suppressions.suppress(d) {
+			diagnostics = append(diagnostics, d)
+		}
+	}
+	return diagnostics, nil
+}
+
+type linter struct {
+	ctx         *EvaluationContext
+	diagnostics []Diagnostic
+	referenced  map[string]bool
+}
+
+func newLinter(ctx *EvaluationContext) *linter {
+	return &linter{ctx: ctx, diagnostics: []Diagnostic{}, referenced: map[string]bool{}}
+}
+
+func (ctx *EvaluationContext) lintForms(forms []SExpr) []Diagnostic {
+	l := newLinter(ctx)
+	for _, form := range forms {
+		l.lintExpr(form)
+	}
+
+	for name := range ctx.SymbolTable {
+		if !l.referenced[name] {
+			l.report(RuleUnusedSymbol, SeverityInfo, span{}, "symbol %q is bound but never used", name)
+		}
+	}
+
+	sortDiagnostics(l.diagnostics)
+	return l.diagnostics
+}
+
+// sortDiagnostics orders diagnostics by where they are in the script, followed by those without a position.
+func sortDiagnostics(diagnostics []Diagnostic) {
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		a, b := diagnostics[i], diagnostics[j]
+		if a.Position.IsValid() != b.Position.IsValid() {
+			return a.Position.IsValid()
+		}
+		if a.Position.Offset != b.Position.Offset {
+			return a.Position.Offset < b.Position.Offset
+		}
+		return a.Message < b.Message
+	})
+}
+
+func (l *linter) report(rule string, severity Severity, at span, format string, args ...interface{}) {
+	l.diagnostics = append(l.diagnostics, Diagnostic{
+		Rule:     rule,
+		Severity: severity,
+		Position: at.start,
+		End:      at.end,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (l *linter) lintExpr(expr SExpr) {
+	var children []SExpr
+	switch p := expr.(type) {
+	case *atom:
+		l.lintAtom(p)
+	case *list:
+		children = l.lintCall(p)
+	case *mapLiteral:
+		children = p.children
+	case *vectorLiteral:
+		children = p.children
+	case *setLiteral:
+		children = p.children
+	case *interpolatedString:
+		children = p.parts
+	}
+
+	for _, c := range children {
+		l.lintExpr(c)
+	}
+}
+
+func (l *linter) lintAtom(a *atom) {
+	switch a.typedValue.VariantType {
+	case VAR_IDENT:
+		name, _ := a.typedValue.GetIdentifierValue()
+		l.reference(name)
+		if l.ctx.isSymbol(name) && l.ctx.isFunction(name) {
+			l.report(RuleShadowedBuiltin, SeverityWarning, a.span, "%q refers to the symbol of that name, which hides the function", name)
+		}
+
+	case VAR_DATE:
+		l.lintDate(a)
+	}
+}
+
+// reference records that the script uses the symbol, including through a property path such as "order.total".
+func (l *linter) reference(name string) {
+	l.referenced[name] = true
+	if i := strings.Index(name, propertySeparator); i > 0 {
+		l.referenced[name[:i]] = true
+	}
+}
+
+// lintCall checks the call the list makes, returning the expressions in it that remain to be checked.
+func (l *linter) lintCall(p *list) []SExpr {
+	if len(p.children) == 0 {
+		return nil
+	}
+
+	head, ok := p.children[0].(*atom)
+	if !ok || head.typedValue.VariantType != VAR_IDENT {
+		return p.children
+	}
+
+	args := p.children[1:]
+	name, _ := head.typedValue.GetIdentifierValue()
+	l.reference(name)
+
+	switch {
+	case l.ctx.isSymbol(name):
+		if l.ctx.isFunction(name) {
+			l.report(RuleShadowedBuiltin, SeverityWarning, head.span, "%q refers to the symbol of that name, which hides the function", name)
+		}
+		return args
+
+	case !l.ctx.isFunction(name):
+		if i := strings.Index(name, propertySeparator); i > 0 && l.ctx.isSymbol(name[:i]) {
+			return args
+		}
+		l.report(RuleUnknownFunction, SeverityError, head.span, "unknown function %q", name)
+		return args
+	}
+
+	l.lintUnreachable(name, args)
+
+	signature, found := l.signature(name)
+	if !found {
+		return args
+	}
+
+	if !signature.AcceptsArity(len(args)) {
+		l.report(RuleArity, SeverityError, p.span, "%q takes %s, not %d", name, signature.DescribeArity(), len(args))
+		return args
+	}
+
+	for i, arg := range args {
+		t, literal := literalType(arg)
+		if !literal {
+			continue
+		}
+
+		accepted := signature.AcceptedTypes(i)
+		if len(accepted) > 0 && !containsType(accepted, t) {
+			l.report(RuleTypeMismatch, SeverityWarning, spanOf(arg), "%q doesn't accept %s as argument %d, only %s", name, t, i+1, joinTypes(accepted))
+		}
+	}
+	return args
+}
+
+// signature returns the signature of the library function, which the functions of the host don't have.
+func (l *linter) signature(name string) (Signature, bool) {
+	if l.ctx.registry == nil {
+		return Signature{}, false
+	}
+	return l.ctx.registry.Signature(name)
+}
+
+// decidingValues are the values that decide the result of the logical functions on their own.
+var decidingValues = map[string]bool{"and": false, "&&": false, "nand": false, "or": true, "||": true, "nor": true}
+
+func (l *linter) lintUnreachable(name string, args []SExpr) {
+	if len(args) < 2 || l.ctx.capabilityOf(name) != (&LogicalLibrary{}).Namespace() {
+		return
+	}
+
+	baseName := name[strings.Index(name, namespaceSeparator)+1:]
+	deciding, found := decidingValues[baseName]
+	if !found {
+		return
+	}
+
+	for i, arg := range args[:len(args)-1] {
+		a, ok := arg.(*atom)
+		if !ok || a.typedValue.VariantType != VAR_BOOL || a.typedValue.VariantValue != deciding {
+			continue
+		}
+
+		unreachable := span{start: spanOf(args[i+1]).start, end: spanOf(args[len(args)-1]).end}
+		l.report(RuleUnreachable, SeverityWarning, unreachable, "the arguments after %t can't change the result of %q", deciding, name)
+		return
+	}
+}
+
+var ambiguousDate = regexp.MustCompile(`^(\d{1,2})/(\d{1,2})/\d+`)
+
+// lintDate warns of numbers that dateparse reads as dates, such as 1000 and 20210101, and of dates whose day and month
+// could be read either way round.
+func (l *linter) lintDate(a *atom) {
+	date, _ := a.typedValue.GetDateValue()
+
+	if strings.Trim(a.rawValue, "0123456789.") == "" {
+		// a digit separator or a trailing zero keeps the literal a number
+		number := a.rawValue[:1] + "_" + a.rawValue[1:]
+		if strings.Contains(a.rawValue, ".") {
+			number = a.rawValue + "0"
+		}
+		l.report(RuleSuspiciousDate, SeverityWarning, a.span, "%s is read as the date %s; write %s if it is a number", a.rawValue, date.Format(time.RFC3339), number)
+		return
+	}
+
+	if m := ambiguousDate.FindStringSubmatch(a.rawValue); m != nil && m[1] != m[2] {
+		if month, day := atoiOrZero(m[1]), atoiOrZero(m[2]); month <= 12 && day <= 12 {
+			l.report(RuleSuspiciousDate, SeverityInfo, a.span, "%s is read as month/day, as %s; write dates as yyyy-mm-dd to avoid ambiguity", a.rawValue, date.Format("2006-01-02"))
+		}
+	}
+}
+
+func atoiOrZero(s string) int {
+	n := 0
+	for _, r := range s {
+		n = n*10 + int(r-'0')
+	}
+	return n
+}
+
+// literalType reports the type of an argument that is written as a literal.
+func literalType(expr SExpr) (EnumVariantType, bool) {
+	switch p := expr.(type) {
+	case *atom:
+		switch t := p.typedValue.VariantType; t {
+		case VAR_IDENT:
+			return VAR_UNKNOWN, false
+		default:
+			return t, true
+		}
+	case *interpolatedString:
+		return VAR_STRING, true
+	case *vectorLiteral:
+		return VAR_VECTOR, true
+	case *mapLiteral:
+		return VAR_MAP, true
+	case *setLiteral:
+		return VAR_SET, true
+	}
+	return VAR_UNKNOWN, false
+}
+
+func containsType(types []EnumVariantType, t EnumVariantType) bool {
+	for _, u := range types {
+		if u == t {
+			return true
+		}
+	}
+	return false
+}
+
+// suppressions are the rules that lint:ignore comments suppress, by where they apply.
+type suppressions []suppression
+
+type suppression struct {
+	rules map[string]bool // nil for every rule
+	file  bool
+	line  int
+	start int
+	end   int
+}
+
+func (s suppressions) suppress(d Diagnostic) bool {
+	for _, sup := range s {
+		if sup.rules != nil && !sup.rules[d.Rule] {
+			continue
+		}
+
+		offset := d.Position.Offset
+		switch {
+		case sup.file:
+			return true
+		case sup.line > 0 && d.Position.Line == sup.line:
+			return true
+		case sup.line == 0 && d.Position.IsValid() && offset >= sup.start && offset < sup.end:
+			return true
+		}
+	}
+	return false
+}
+
+// findSuppressions reads the lint:ignore comments of a script.
+func findSuppressions(nodes []*syntaxNode) suppressions {
+	result := suppressions{}
+	for i, node := range nodes {
+		if node.isCollection() {
+			result = append(result, findSuppressions(node.children)...)
+			continue
+		}
+		if node.kind != syntaxComment {
+			continue
+		}
+
+		fields := strings.Fields(strings.TrimSuffix(strings.TrimPrefix(node.text, "(*"), "*)"))
+		if len(fields) == 0 || (fields[0] != "lint:ignore" && fields[0] != "lint:ignore-file") {
+			continue
+		}
+
+		sup := suppression{file: fields[0] == "lint:ignore-file"}
+		for _, field := range fields[1:] {
+			for _, rule := range strings.Split(field, ",") {
+				if rule != "" {
+					if sup.rules == nil {
+						sup.rules = map[string]bool{}
+					}
+					sup.rules[rule] = true
+				}
+			}
+		}
+
+		switch {
+		case sup.file:
+		case i > 0 && node.linesBefore == 0:
+			sup.line = node.start.Line
+		default:
+			next := nextForm(nodes[i+1:])
+			if next == nil {
+				continue
+			}
+			sup.start, sup.end = next.start.Offset, next.end.Offset
+		}
+		result = append(result, sup)
+	}
+	return result
+}
+
+func nextForm(nodes []*syntaxNode) *syntaxNode {
+	for _, node := range nodes {
+		if node.kind != syntaxComment {
+			return node
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package golisp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLintSource(t *testing.T) {
+	tests := [...]struct {
+		desc     string
+		input    string
+		expected []string
+	}{
+		{desc: "clean script", input: "(and (> amount 100) (= country \"NZ\"))", expected: []string{}},
+		{desc: "unknown function", input: "(+ 1 (frobnicate 2))", expected: []string{`1:7: error: unknown function "frobnicate" (unknown-function)`}},
+		{desc: "property paths of symbols aren't functions", input: "(order.quantity 1)", expected: []string{}},
+		{desc: "too many arguments", input: "(abs 1 2)", expected: []string{`1:1: error: "abs" takes exactly 1 argument, not 2 (arity)`}},
+		{desc: "too few arguments", input: "(upper)", expected: []string{`1:1: error: "upper" takes exactly 1 argument, not 0 (arity)`}},
+		{desc: "sum of nothing", input: "(+)", expected: []string{`1:1: error: "+" takes at least 1 argument, not 0 (arity)`}},
+		{desc: "optional arguments", input: "(range 0 10 1 2)", expected: []string{`1:1: error: "range" takes 1 to 3 arguments, not 4 (arity)`}},
+		{desc: "even arguments", input: "(hash-map :a 1 :b)", expected: []string{`1:1: error: "hash-map" takes an even number of arguments, not 3 (arity)`}},
+		{desc: "qualified names", input: "(str/upper 1 2)", expected: []string{`1:1: error: "str/upper" takes exactly 1 argument, not 2 (arity)`}},
+		{desc: "literal of the wrong type", input: "(or 3.1415 x)", expected: []string{`1:5: warning: "or" doesn't accept VAR_FLOAT as argument 1, only VAR_BOOL or VAR_INT (type-mismatch)`}},
+		{desc: "collection literal of the wrong type", input: "(upper [1])", expected: []string{`1:8: warning: "upper" doesn't accept VAR_VECTOR as argument 1, only VAR_STRING (type-mismatch)`}},
+		{desc: "arguments of several types", input: "(substring \"abc\" 1 2) (get {:a 1} :a) (map abs [1])", expected: []string{}},
+		{desc: "unreachable arguments", input: "(and x false y z)", expected: []string{`1:14: warning: the arguments after false can't change the result of "and" (unreachable)`}},
+		{desc: "deciding value last", input: "(or x y true)", expected: []string{}},
+		{
+			desc:  "numbers read as dates",
+			input: "(max 1000 1.5)",
+			expected: []string{
+				`1:6: warning: "max" doesn't accept VAR_DATE as argument 1, only VAR_BOOL, VAR_FLOAT or VAR_INT (type-mismatch)`,
+				`1:6: warning: 1000 is read as the date 1000-01-01T00:00:00Z; write 1_000 if it is a number (suspicious-date)`,
+				`1:11: warning: "max" doesn't accept VAR_DATE as argument 2, only VAR_BOOL, VAR_FLOAT or VAR_INT (type-mismatch)`,
+				`1:11: warning: 1.5 is read as the date 0000-01-05T00:00:00Z; write 1.50 if it is a number (suspicious-date)`,
+			},
+		},
+		{desc: "ambiguous dates", input: "[11/12/2021 13/12/2021 12/12/2021]", expected: []string{`1:2: info: 11/12/2021 is read as month/day, as 2021-11-12; write dates as yyyy-mm-dd to avoid ambiguity (suspicious-date)`}},
+		{desc: "interpolated expressions", input: "$\"total {(abs 1 2)}\"", expected: []string{`1:10: error: "abs" takes exactly 1 argument, not 2 (arity)`}},
+		{desc: "suppressed in the next form", input: "(* lint:ignore arity *)\n(abs 1 2)\n(abs 1 2)", expected: []string{`3:1: error: "abs" takes exactly 1 argument, not 2 (arity)`}},
+		{desc: "suppressing other rules", input: "(* lint:ignore type-mismatch,unreachable *)\n(abs 1 2)", expected: []string{`2:1: error: "abs" takes exactly 1 argument, not 2 (arity)`}},
+		{desc: "suppressed on the line", input: "(upper 1) (* lint:ignore *)\n(upper 2)", expected: []string{`2:8: warning: "upper" doesn't accept VAR_INT as argument 1, only VAR_STRING (type-mismatch)`}},
+		{desc: "suppressed within a form", input: "(concat\n  (* lint:ignore arity *)\n  (upper 1 2)\n  (lower 1 2))", expected: []string{`4:3: error: "lower" takes exactly 1 argument, not 2 (arity)`}},
+		{desc: "suppressed in the file", input: "(abs 1 2)\n(* lint:ignore-file arity *)\n(upper 1 2)", expected: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := NewEvaluationContext(nil)
+			ctx.SymbolTable["x"] = boolVariant(true)
+			ctx.SymbolTable["y"] = boolVariant(true)
+			ctx.SymbolTable["z"] = boolVariant(true)
+			ctx.SymbolTable["order"] = Variant{VariantType: VAR_OBJECT, VariantValue: &testLineItem{Quantity: 1}}
+
+			diagnostics, e := ctx.LintSource(test.input)
+			assert.Nil(t, e)
+
+			actual := []string{}
+			for _, d := range diagnostics {
+				if d.Rule != RuleUnusedSymbol {
+					actual = append(actual, d.String())
+				}
+			}
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestLintContext(t *testing.T) {
+	ctx := NewEvaluationContext(nil)
+	ctx.SymbolTable["abs"] = intVariant(-1)
+	ctx.SymbolTable["unused"] = intVariant(1)
+	calls := 0
+	_ = ctx.RegisterGoFunc("discount", func(x float64) float64 { calls++; return x * 0.9 })
+
+	sexpr, _ := Parse("(discount (map abs [1]) 2)")
+	assert.Equal(
+		t,
+		[]Diagnostic{
+			{Rule: RuleShadowedBuiltin, Severity: SeverityWarning, Position: Position{Offset: 15, Line: 1, Column: 16}, End: Position{Offset: 18, Line: 1, Column: 19}, Message: `"abs" refers to the symbol of that name, which hides the function`},
+			{Rule: RuleUnusedSymbol, Severity: SeverityInfo, Message: `symbol "unused" is bound but never used`},
+		},
+		ctx.Lint(sexpr),
+		"host functions have no signature, so their arity isn't checked")
+	assert.Equal(t, 0, calls, "the linter never calls a function")
+}
+
+func TestLint(t *testing.T) {
+	sexpr, _ := Parse("(+ 1 (abs))")
+	assert.Equal(t, []Diagnostic{{
+		Rule:     RuleArity,
+		Severity: SeverityError,
+		Position: Position{Offset: 5, Line: 1, Column: 6},
+		End:      Position{Offset: 10, Line: 1, Column: 11},
+		Message:  `"abs" takes exactly 1 argument, not 0`,
+	}}, Lint(sexpr))
+
+	_, e := LintSource("(+ 1")
+	assert.Equal(t, buildUnexpectedEndOfStringError(), e)
+}
@@ -7,77 +7,298 @@ import (
 	"github.com/araddon/dateparse"
 )
 
+// parseSymbol reads the value of a token that isn't a bracket or a string: a keyword, number, date, bool or identifier.
+func parseSymbol(rawValue string) (Variant, error) {
+	if len(rawValue) > len(keywordPrefix) && strings.HasPrefix(rawValue, keywordPrefix) {
+		if !isKeywordName(strings.TrimPrefix(rawValue, keywordPrefix)) {
+			return Variant{}, buildInvalidKeywordLiteralError(rawValue)
+		}
+		return Variant{VariantType: VAR_KEYWORD, VariantValue: rawValue}, nil
+	}
+
+	// numbers with a base prefix or digit separators, which dateparse would otherwise misread
+	if isDecoratedNumber(rawValue) {
+		return parseDecoratedNumber(rawValue)
+	}
+
+	// date in any format - dd/mm and mm/dd are both parsed as mm/dd because USA! :)
+	if d, e := dateparse.ParseAny(rawValue); e == nil {
+		return Variant{VariantType: VAR_DATE, VariantValue: d}, nil
+	} else if i, e := strconv.ParseInt(rawValue, 0, 64); e == nil {
+		// int64
+		return Variant{VariantType: VAR_INT, VariantValue: i}, nil
+	} else if f, e := strconv.ParseFloat(rawValue, 64); e == nil {
+		// float64
+		return Variant{VariantType: VAR_FLOAT, VariantValue: f}, nil
+	} else if b, e := strconv.ParseBool(rawValue); e == nil {
+		// bool
+		return Variant{VariantType: VAR_BOOL, VariantValue: b}, nil
+	}
+
+	// identifier
+	return Variant{VariantType: VAR_IDENT, VariantValue: rawValue}, nil
+}
+
 func parseSExpr(tokenizer *tokenizerContext, tok *token, into *list) (SExpr, error) {
 	if tok == nil {
 		into.children = append(into.children, &null{})
 		return into, nil
 	}
 
+	tokenSpan := tokenizer.spanOf(tok)
+	start := tokenSpan.start
+
 	switch tok.tokenType {
 	case TOK_COMMENT:
 		break
 
 	case TOK_QUOTEDSTRING:
-		a := &atom{rawValue: tok.rawValue(tokenizer)}
+		a := &atom{span: tokenSpan, rawValue: tok.rawValue(tokenizer)}
 		a.typedValue = Variant{VariantType: VAR_STRING, VariantValue: strings.Trim(a.rawValue, "\"")}
 		into.children = append(into.children, a)
 
-	case TOK_SYMBOL:
-		a := &atom{rawValue: tok.rawValue(tokenizer)}
-
-		// date in any format - dd/mm and mm/dd are both parsed as mm/dd because USA! :)
-		if d, e := dateparse.ParseAny(a.rawValue); e == nil {
-			a.typedValue = Variant{VariantType: VAR_DATE, VariantValue: d}
-		} else if i, e := strconv.ParseInt(a.rawValue, 0, 64); e == nil {
-			// int64
-			a.typedValue = Variant{VariantType: VAR_INT, VariantValue: i}
-		} else if f, e := strconv.ParseFloat(a.rawValue, 64); e == nil {
-			// float64
-			a.typedValue = Variant{VariantType: VAR_FLOAT, VariantValue: f}
-		} else if b, e := strconv.ParseBool(a.rawValue); e == nil {
-			// bool
-			a.typedValue = Variant{VariantType: VAR_BOOL, VariantValue: b}
-		} else {
-			// identifier
-			a.typedValue = Variant{VariantType: VAR_IDENT, VariantValue: a.rawValue}
+	case TOK_INTERPOLATEDSTRING:
+		s, e := parseInterpolatedString(tok.rawValue(tokenizer), tokenSpan)
+		if e != nil {
+			return into, e
 		}
+		into.children = append(into.children, s)
 
+	case TOK_SYMBOL:
+		a := &atom{span: tokenSpan, rawValue: tok.rawValue(tokenizer)}
+		v, e := parseSymbol(a.rawValue)
+		if e != nil {
+			return into, e
+		}
+		a.typedValue = v
 		into.children = append(into.children, a)
 
 	case TOK_LPAREN:
 		child := &list{children: []SExpr{}}
-		var t *token = tokenizer.NextToken()
-
-		for t != nil && t.tokenType != TOK_RPAREN {
-			parseSExpr(tokenizer, t, child)
-			t = tokenizer.NextToken()
-		}
-
-		if t == nil {
-			return into, buildUnexpectedEndOfStringError()
+		end, e := parseChildren(tokenizer, child, TOK_RPAREN)
+		if e != nil {
+			return into, e
 		}
 
+		child.span = span{start: start, end: end}
 		into.children = append(into.children, child)
 
 	case TOK_RPAREN:
 		return into, buildUnexpectedCloseParenError()
+
+	case TOK_LBRACE:
+		children := &list{children: []SExpr{}}
+		end, e := parseChildren(tokenizer, children, TOK_RBRACE)
+		if e != nil {
+			return into, e
+		}
+
+		if len(children.children)%2 != 0 {
+			return into, buildOddMapLiteralError()
+		}
+
+		into.children = append(into.children, &mapLiteral{span: span{start: start, end: end}, children: children.children})
+
+	case TOK_RBRACE:
+		return into, buildUnexpectedCloseBraceError()
+
+	case TOK_LSET:
+		children := &list{children: []SExpr{}}
+		end, e := parseChildren(tokenizer, children, TOK_RBRACE)
+		if e != nil {
+			return into, e
+		}
+
+		into.children = append(into.children, &setLiteral{span: span{start: start, end: end}, children: children.children})
+
+	case TOK_LBRACKET:
+		children := &list{children: []SExpr{}}
+		end, e := parseChildren(tokenizer, children, TOK_RBRACKET)
+		if e != nil {
+			return into, e
+		}
+
+		into.children = append(into.children, &vectorLiteral{span: span{start: start, end: end}, children: children.children})
+
+	case TOK_RBRACKET:
+		return into, buildUnexpectedCloseBracketError()
 	}
 
 	return into, nil
 }
 
+// isDecoratedNumber reports whether a symbol is a number written with a base prefix (0b, 0o or 0x) or with
+// underscores separating its digits (1_000).
+func isDecoratedNumber(s string) bool {
+	s = strings.TrimLeft(s, "+-")
+	if s == "" || s[0] < '0' || s[0] > '9' {
+		return false
+	}
+
+	if len(s) > 1 && s[0] == '0' && strings.IndexByte("bBoOxX", s[1]) >= 0 {
+		return true
+	}
+	return strings.Contains(s, "_")
+}
+
+// parseDecoratedNumber parses a number with go's literal syntax, as an int where it fits and a float otherwise.
+func parseDecoratedNumber(s string) (Variant, error) {
+	if i, e := strconv.ParseInt(s, 0, 64); e == nil {
+		return Variant{VariantType: VAR_INT, VariantValue: i}, nil
+	}
+
+	if f, e := strconv.ParseFloat(s, 64); e == nil {
+		return Variant{VariantType: VAR_FLOAT, VariantValue: f}, nil
+	}
+	return Variant{}, buildInvalidNumberLiteralError(s)
+}
+
+// parseChildren parses forms into the list until the closing token is found, returning the position after it.
+func parseChildren(tokenizer *tokenizerContext, into *list, closingTokenType enumTokenType) (Position, error) {
+	var t *token = tokenizer.NextToken()
+
+	for t != nil && t.tokenType != closingTokenType {
+		if _, e := parseSExpr(tokenizer, t, into); e != nil {
+			return Position{}, e
+		}
+		t = tokenizer.NextToken()
+	}
+
+	if t == nil {
+		return Position{}, buildUnexpectedEndOfStringError()
+	}
+
+	return tokenizer.position(t.finish), nil
+}
+
+// parseInterpolatedString splits $"text {expr} text" into its literal text and the expressions to evaluate.
+// Literal braces are written doubled, as {{ and }}.
+func parseInterpolatedString(rawValue string, stringSpan span) (SExpr, error) {
+	body := strings.TrimSuffix(strings.TrimPrefix(rawValue, "$\""), "\"")
+	bodyStart := stringSpan.start.advance("$\"")
+	result := &interpolatedString{span: stringSpan, rawValue: rawValue, parts: []SExpr{}}
+	literal := strings.Builder{}
+	literalStart := 0
+
+	flushLiteral := func(end int) {
+		if literal.Len() > 0 {
+			text := literal.String()
+			textSpan := span{start: bodyStart.advance(body[:literalStart]), end: bodyStart.advance(body[:end])}
+			result.parts = append(result.parts, &atom{span: textSpan, rawValue: text, typedValue: Variant{VariantType: VAR_STRING, VariantValue: text}})
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(body); i++ {
+		switch {
+		case strings.HasPrefix(body[i:], "{{"), strings.HasPrefix(body[i:], "}}"):
+			literal.WriteByte(body[i])
+			i++
+
+		case body[i] == '{':
+			end, depth := i+1, 1
+			for ; end < len(body) && depth > 0; end++ {
+				switch body[end] {
+				case '{':
+					depth++
+				case '}':
+					depth--
+				}
+			}
+			if depth > 0 {
+				return &null{}, buildInvalidInterpolationError(rawValue)
+			}
+
+			expr, e := Parse(body[i+1 : end-1])
+			if e != nil {
+				return &null{}, e
+			}
+			if _, empty := expr.(*null); empty {
+				return &null{}, buildInvalidInterpolationError(rawValue)
+			}
+
+			flushLiteral(i)
+			rebaseExpr(expr, bodyStart.advance(body[:i+1]))
+			result.parts = append(result.parts, expr)
+			i, literalStart = end-1, end
+
+		case body[i] == '}':
+			return &null{}, buildInvalidInterpolationError(rawValue)
+
+		default:
+			literal.WriteByte(body[i])
+		}
+	}
+
+	flushLiteral(len(body))
+	return result, nil
+}
+
+// rebaseExpr moves the positions of an expression parsed from a fragment of a script to the script itself.
+func rebaseExpr(expr SExpr, base Position) {
+	walk(expr, func(node SExpr) {
+		if p, ok := node.(positioned); ok {
+			s := p.sourceSpan()
+			s.start, s.end = s.start.rebase(base), s.end.rebase(base)
+		}
+	})
+}
+
 func Parse(s string) (SExpr, error) {
 	tokenizer := newTokenizerContext(s)
 	token := tokenizer.NextToken()
 
 	r, e := parseSExpr(tokenizer, token, &list{children: []SExpr{}})
 
+	if e != nil {
+		return &null{}, e
+	}
+
 	if tokenizer.hasMoreText() {
 		return &null{}, buildUnexpectedTrailingTextError()
 	}
 
-	if e == nil {
-		return r.(*list).children[0], e
+	return r.(*list).children[0], nil
+}
+
+// ParseAll parses every form in a script, such as a file of definitions, in the order they are written.
+func ParseAll(s string) ([]SExpr, error) {
+	tokenizer := newTokenizerContext(s)
+	forms := &list{children: []SExpr{}}
+
+	for t := tokenizer.NextToken(); t != nil; t = tokenizer.NextToken() {
+		if _, e := parseSExpr(tokenizer, t, forms); e != nil {
+			return nil, e
+		}
+	}
+
+	return forms.children, nil
+}
+
+// IsIncomplete reports whether the text ends inside a form, with brackets left open or a string left unterminated,
+// so that more input is needed before it can be parsed.
+func IsIncomplete(s string) bool {
+	tokenizer := newTokenizerContext(s)
+	depth := 0
+
+	for t := tokenizer.NextToken(); t != nil; t = tokenizer.NextToken() {
+		switch t.tokenType {
+		case TOK_LPAREN, TOK_LBRACE, TOK_LBRACKET, TOK_LSET:
+			depth++
+		case TOK_RPAREN, TOK_RBRACE, TOK_RBRACKET:
+			depth--
+		case TOK_SYMBOL:
+			if isUnterminatedString(t.rawValue(tokenizer)) {
+				return true
+			}
+		}
 	}
-	return &null{}, e
+
+	return depth > 0
+}
+
+// isUnterminatedString reports whether the text of a symbol token starts a string that is never closed, which the
+// tokenizer reads as a symbol.
+func isUnterminatedString(raw string) bool {
+	return strings.HasPrefix(raw, "\"") || strings.HasPrefix(raw, "$\"")
 }
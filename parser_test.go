@@ -17,7 +17,12 @@ func TestParser(t *testing.T) {
 		{desc: "whitespace string", input: " ", success: "NIL"},
 		{desc: "numeric literal", input: "1", success: "1"},
 		{desc: "identifier literal", input: "a", success: "a"},
+		{desc: "keyword literal", input: ":approved", success: ":approved"},
+		{desc: "doubled keyword prefix", input: "::approved", success: "NIL", failure: buildInvalidKeywordLiteralError("::approved")},
 		{desc: "date literal", input: "11/11/1974", success: "11/11/1974"},
+		{desc: "binary literal", input: "0b1010_1010", success: "0b1010_1010"},
+		{desc: "invalid binary literal", input: "0b102", success: "NIL", failure: buildInvalidNumberLiteralError("0b102")},
+		{desc: "invalid separated literal", input: "1__000", success: "NIL", failure: buildInvalidNumberLiteralError("1__000")},
 		{desc: "quoted raw string", input: `"Now is the time"`, success: `"Now is the time"`},
 		{desc: "quoted string", input: "\"Now is the time\"", success: "\"Now is the time\""},
 		{desc: "valid list", input: "(+ 1 2)", success: "(+ 1 2)"},
@@ -27,7 +32,26 @@ func TestParser(t *testing.T) {
 		{desc: "valid list", input: "(+ (1) (+ 2 3))", success: "(+ (1) (+ 2 3))"},
 		{desc: "valid list", input: "(+ (1) (+ 2 3) 4)", success: "(+ (1) (+ 2 3) 4)"},
 		{desc: "valid list", input: "(+ (1) (+ 2 3) a)", success: "(+ (1) (+ 2 3) a)"},
+		{desc: "map literal", input: "{a 1 b (+ 1 2)}", success: "{a 1 b (+ 1 2)}"},
+		{desc: "map literal in list", input: "(f {a {}})", success: "(f {a {}})"},
 		{desc: "parse error", input: "(", success: "NIL", failure: buildUnexpectedEndOfStringError()},
+		{desc: "unterminated map", input: "{a 1", success: "NIL", failure: buildUnexpectedEndOfStringError()},
+		{desc: "odd map", input: "(f {a 1 b})", success: "NIL", failure: buildOddMapLiteralError()},
+		{desc: "mismatched brace", input: "(f {a 1)}", success: "NIL", failure: buildUnexpectedCloseParenError()},
+		{desc: "unexpected rbrace", input: "}", success: "NIL", failure: buildUnexpectedCloseBraceError()},
+		{desc: "vector literal", input: "[a 1 (+ 1 2)]", success: "[a 1 (+ 1 2)]"},
+		{desc: "vector literal in list", input: "(f [a []] {b [1]})", success: "(f [a []] {b [1]})"},
+		{desc: "unterminated vector", input: "[a 1", success: "NIL", failure: buildUnexpectedEndOfStringError()},
+		{desc: "mismatched bracket", input: "(f [a 1)]", success: "NIL", failure: buildUnexpectedCloseParenError()},
+		{desc: "unexpected rbracket", input: "]", success: "NIL", failure: buildUnexpectedCloseBracketError()},
+		{desc: "set literal", input: "#{a 1 (+ 1 2)}", success: "#{a 1 (+ 1 2)}"},
+		{desc: "set literal in list", input: "(f #{} {a #{b}})", success: "(f #{} {a #{b}})"},
+		{desc: "unterminated set", input: "#{a 1", success: "NIL", failure: buildUnexpectedEndOfStringError()},
+		{desc: "interpolated string", input: `(f $"a {(+ 1 2)} b")`, success: `(f $"a {(+ 1 2)} b")`},
+		{desc: "unclosed interpolation", input: `$"a {b"`, success: "NIL", failure: buildInvalidInterpolationError(`$"a {b"`)},
+		{desc: "stray interpolation brace", input: `$"a } b"`, success: "NIL", failure: buildInvalidInterpolationError(`$"a } b"`)},
+		{desc: "empty interpolation", input: `$"a {} b"`, success: "NIL", failure: buildInvalidInterpolationError(`$"a {} b"`)},
+		{desc: "invalid interpolated expression", input: `$"a {(b} c"`, success: "NIL", failure: buildUnexpectedEndOfStringError()},
 		{desc: "parse error", input: "(+ (* a b)", success: "NIL", failure: buildUnexpectedEndOfStringError()},
 		{desc: "parse error", input: "(+ (1) (+ 2 3)", success: "NIL", failure: buildUnexpectedEndOfStringError()},
 		{desc: "unexpected rparen", input: ")", success: "NIL", failure: buildUnexpectedCloseParenError()},
@@ -48,3 +72,78 @@ func TestParser(t *testing.T) {
 		})
 	}
 }
+
+func TestParseAll(t *testing.T) {
+	cases := [...]struct {
+		desc     string
+		input    string
+		expected []string
+		failure  error
+	}{
+		{desc: "empty string", input: "", expected: []string{}},
+		{desc: "single form", input: "(+ 1 2)", expected: []string{"(+ 1 2)"}},
+		{desc: "several forms", input: "(f 1)\n  a [b c]\n{d 1}", expected: []string{"(f 1)", "a", "[b c]", "{d 1}"}},
+		{desc: "comments are skipped", input: "(* first *) (f 1) (* last *)", expected: []string{"(f 1)"}},
+		{desc: "unterminated form", input: "(f 1) (g", failure: buildUnexpectedEndOfStringError()},
+		{desc: "unexpected rparen", input: "(f 1))", failure: buildUnexpectedCloseParenError()},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			forms, e := ParseAll(c.input)
+			if c.failure != nil {
+				assert.EqualError(t, e, c.failure.Error())
+				return
+			}
+
+			assert.Nil(t, e, "parse error")
+			actual := []string{}
+			for _, form := range forms {
+				actual = append(actual, form.String())
+			}
+			assert.Equal(t, c.expected, actual)
+		})
+	}
+}
+
+func TestIsIncomplete(t *testing.T) {
+	cases := [...]struct {
+		input    string
+		expected bool
+	}{
+		{input: "", expected: false},
+		{input: "(+ 1 2)", expected: false},
+		{input: "(+ 1", expected: true},
+		{input: "(f [a {b", expected: true},
+		{input: "#{1 2", expected: true},
+		{input: "(f 1))", expected: false},
+		{input: `(concat "a b`, expected: true},
+		{input: `$"a {b}`, expected: true},
+		{input: `(concat "a (b")`, expected: false},
+		{input: "(* open comment", expected: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.input, func(t *testing.T) {
+			assert.Equal(t, c.expected, IsIncomplete(c.input))
+		})
+	}
+}
+
+func TestParserPositions(t *testing.T) {
+	sexpr, e := Parse("\n  (f [a]\n     $\"x {(g b)}\")")
+	assert.Nil(t, e)
+
+	f := sexpr.(*list)
+	assert.Equal(t, span{start: Position{Offset: 3, Line: 2, Column: 3}, end: Position{Offset: 28, Line: 3, Column: 19}}, f.span)
+	assert.Equal(t, span{start: Position{Offset: 4, Line: 2, Column: 4}, end: Position{Offset: 5, Line: 2, Column: 5}}, spanOf(f.children[0]))
+	assert.Equal(t, span{start: Position{Offset: 6, Line: 2, Column: 6}, end: Position{Offset: 9, Line: 2, Column: 9}}, spanOf(f.children[1]))
+
+	interpolated := f.children[2].(*interpolatedString)
+	assert.Equal(t, Position{Offset: 15, Line: 3, Column: 6}, interpolated.start)
+	assert.Equal(t, span{start: Position{Offset: 17, Line: 3, Column: 8}, end: Position{Offset: 19, Line: 3, Column: 10}}, spanOf(interpolated.parts[0]))
+
+	g := interpolated.parts[1].(*list)
+	assert.Equal(t, span{start: Position{Offset: 20, Line: 3, Column: 11}, end: Position{Offset: 25, Line: 3, Column: 16}}, g.span)
+	assert.Equal(t, Position{Offset: 23, Line: 3, Column: 14}, spanOf(g.children[1]).start)
+}
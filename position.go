@@ -0,0 +1,87 @@
+package golisp
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Position is a place in the source of a script. Lines and columns count from 1, and columns count bytes, so a
+// Position with a zero Line is in no source at all.
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// IsValid reports whether the position is in a source.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// advance returns the position after the text, which starts at p.
+func (p Position) advance(text string) Position {
+	for i := 0; i < len(text); i++ {
+		p.Offset++
+		if text[i] == '\n' {
+			p.Line, p.Column = p.Line+1, 1
+		} else {
+			p.Column++
+		}
+	}
+	return p
+}
+
+// rebase moves a position in a fragment of a script, such as an expression interpolated into a string, to the script
+// itself, given where the fragment starts in it.
+func (p Position) rebase(base Position) Position {
+	if p.Line == 1 {
+		p.Column += base.Column - 1
+	}
+	p.Line += base.Line - 1
+	p.Offset += base.Offset
+	return p
+}
+
+// span is the extent of an expression in the source it was parsed from.
+type span struct {
+	start Position
+	end   Position
+}
+
+func (s *span) sourceSpan() *span {
+	return s
+}
+
+// positioned is implemented by the expressions that know where they were written.
+type positioned interface {
+	sourceSpan() *span
+}
+
+// spanOf returns where the expression was written, or an empty span for one that wasn't parsed from a source.
+func spanOf(expr SExpr) span {
+	if p, ok := expr.(positioned); ok {
+		return *p.sourceSpan()
+	}
+	return span{}
+}
+
+// lineStarts returns the offsets at which the lines of the text start.
+func lineStarts(text string) []int {
+	starts := []int{0}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// positionAt converts an offset into the text whose lines start at the offsets given into a position.
+func positionAt(starts []int, offset int) Position {
+	line := sort.Search(len(starts), func(i int) bool { return starts[i] > offset }) - 1
+	return Position{Offset: offset, Line: line + 1, Column: offset - starts[line] + 1}
+}
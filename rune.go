@@ -10,8 +10,24 @@ func isRParen(r rune) bool {
 	return r == ')'
 }
 
+func isLBrace(r rune) bool {
+	return r == '{'
+}
+
+func isRBrace(r rune) bool {
+	return r == '}'
+}
+
+func isLBracket(r rune) bool {
+	return r == '['
+}
+
+func isRBracket(r rune) bool {
+	return r == ']'
+}
+
 func isSeparator(r rune) bool {
-	return unicode.IsSpace(r) || isLParen(r) || isRParen(r)
+	return unicode.IsSpace(r) || isLParen(r) || isRParen(r) || isLBrace(r) || isRBrace(r) || isLBracket(r) || isRBracket(r)
 }
 
 func isQuote(r rune) bool {
@@ -0,0 +1,227 @@
+package golisp
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	// CapabilityHost is the capability of the go functions bound with RegisterGoFunc, which belong to no library.
+	CapabilityHost = "host"
+
+	// CapabilityAll grants every capability.
+	CapabilityAll = "*"
+)
+
+// Sandbox restricts the functions that scripts may call. The namespaces of the libraries act as capabilities, so a
+// sandbox with the "str" capability permits every function of the string library.
+// Allow and Deny adjust that by function: a qualified name ("str/upper") names one function, and a plain name ("upper")
+// names the function of that name in every namespace. Deny takes precedence over Allow.
+type Sandbox struct {
+	Name         string
+	Capabilities []string
+	Allow        []string
+	Deny         []string
+}
+
+var pureCapabilities = []string{"core", "math", "logic", "str", "obj", "map", "vec", "set", "seq", "fmt"}
+
+// PureSandbox permits the functions that compute only with the values given to them. They include get, get-in and
+// has?, which read maps, lists and vectors as well as the host objects bound for the script, as dotted identifiers do.
+func PureSandbox() Sandbox {
+	return Sandbox{Name: "pure", Capabilities: append([]string{}, pureCapabilities...)}
+}
+
+// StandardSandbox adds JSON and regular expressions to the pure functions.
+func StandardSandbox() Sandbox {
+	return Sandbox{Name: "standard", Capabilities: append([]string{"json", "re"}, pureCapabilities...)}
+}
+
+// TrustedSandbox permits every function, including the go functions bound by the host.
+func TrustedSandbox() Sandbox {
+	return Sandbox{Name: "trusted", Capabilities: []string{CapabilityAll}}
+}
+
+// WithSandbox restricts the functions of the context, and of its children, to those the sandbox permits.
+// It applies to the libraries however they are given, so the order of the options doesn't matter.
+func WithSandbox(sandbox Sandbox) ContextOption {
+	return func(ctx *EvaluationContext) {
+		ctx.sandbox = &sandbox
+	}
+}
+
+// Requirements describes the functions that a script refers to.
+type Requirements struct {
+	Functions    []string // functions referred to, in the order they are first written
+	Capabilities []string // capabilities those functions need, sorted
+}
+
+// Requirements reports the functions the script refers to and the capabilities it needs, whether or not the sandbox of
+// the context permits them.
+func (ctx *EvaluationContext) Requirements(expr SExpr) Requirements {
+	result := Requirements{Functions: []string{}, Capabilities: []string{}}
+	capabilities := map[string]bool{}
+
+	for _, name := range ctx.functionReferences(expr) {
+		result.Functions = append(result.Functions, name)
+
+		capability := ctx.capabilityOf(name)
+		if !capabilities[capability] {
+			capabilities[capability] = true
+			result.Capabilities = append(result.Capabilities, capability)
+		}
+	}
+
+	sort.Strings(result.Capabilities)
+	return result
+}
+
+// Check rejects a script that refers to a function the sandbox of the context doesn't permit, before it is run.
+func (ctx *EvaluationContext) Check(expr SExpr) error {
+	sandbox := ctx.sandbox
+	if sandbox == nil {
+		return nil
+	}
+
+	for _, name := range ctx.functionReferences(expr) {
+		if !sandbox.permits(name, ctx.capabilityOf(name)) {
+			return buildFunctionNotPermittedError(name, sandbox.Name)
+		}
+	}
+	return nil
+}
+
+// Parse parses a script and checks it against the sandbox of the context.
+func (ctx *EvaluationContext) Parse(s string) (SExpr, error) {
+	expr, e := Parse(s)
+	if e != nil {
+		return expr, e
+	}
+
+	if e := ctx.Check(expr); e != nil {
+		return &null{}, e
+	}
+	return expr, nil
+}
+
+// functionReferences finds the identifiers in the script that name functions, including functions the sandbox has
+// removed, but not identifiers bound to symbols.
+func (ctx *EvaluationContext) functionReferences(expr SExpr) []string {
+	names := []string{}
+	seen := map[string]bool{}
+
+	walk(expr, func(node SExpr) {
+		a, ok := node.(*atom)
+		if !ok || a.typedValue.VariantType != VAR_IDENT {
+			return
+		}
+
+		name, e := a.typedValue.GetIdentifierValue()
+		if e != nil || seen[name] || ctx.isSymbol(name) || !ctx.isFunction(name) {
+			return
+		}
+
+		seen[name] = true
+		names = append(names, name)
+	})
+	return names
+}
+
+func (ctx *EvaluationContext) isSymbol(name string) bool {
+	for c := ctx; c != nil; c = c.Parent {
+		if _, found := c.SymbolTable[name]; found {
+			return true
+		}
+	}
+	return false
+}
+
+func (ctx *EvaluationContext) isFunction(name string) bool {
+	if ctx.registry != nil {
+		if _, found := ctx.registry.Owner(name); found {
+			return true
+		}
+	}
+
+	for c := ctx; c != nil; c = c.Parent {
+		if _, found := c.FunctionTable[name]; found {
+			return true
+		}
+	}
+	return false
+}
+
+// capabilityOf is the namespace of the library that provides the function, or CapabilityHost for a go function.
+func (ctx *EvaluationContext) capabilityOf(name string) string {
+	if ctx.registry != nil {
+		if namespace, found := ctx.registry.Owner(name); found {
+			return namespace
+		}
+	}
+	return CapabilityHost
+}
+
+// applySandbox removes the functions that the sandbox doesn't permit from the context.
+func (ctx *EvaluationContext) applySandbox() {
+	if ctx.sandbox == nil {
+		return
+	}
+
+	for name := range ctx.FunctionTable {
+		if !ctx.sandbox.permits(name, ctx.capabilityOf(name)) {
+			delete(ctx.FunctionTable, name)
+		}
+	}
+}
+
+func (s *Sandbox) permits(functionName string, capability string) bool {
+	if s.names(s.Deny, functionName, capability) {
+		return false
+	}
+
+	if s.names(s.Allow, functionName, capability) {
+		return true
+	}
+
+	return s.grants(capability)
+}
+
+// grants reports whether the sandbox has the capability, which permits every function that needs it.
+func (s *Sandbox) grants(capability string) bool {
+	for _, c := range s.Capabilities {
+		if c == CapabilityAll || c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFunctionProperty refuses a function read from a property, unless the sandbox of the context has CapabilityHost.
+// Properties of host objects may hold go functions of the host, which are as powerful as those bound with
+// RegisterGoFunc.
+func (ctx *EvaluationContext) checkFunctionProperty(property string, v Variant) error {
+	if v.VariantType != VAR_FUNCTION || ctx == nil || ctx.sandbox == nil || ctx.sandbox.grants(CapabilityHost) {
+		return nil
+	}
+	return buildFunctionPropertyNotPermittedError(property, ctx.sandbox.Name)
+}
+
+// names reports whether any of the entries names the function, which may be given by its plain or qualified name.
+func (s *Sandbox) names(entries []string, functionName string, capability string) bool {
+	qualifiedName, baseName := functionName, functionName
+	if i := strings.Index(functionName, namespaceSeparator); i > 0 {
+		baseName = functionName[i+1:]
+	} else if capability != CapabilityHost {
+		qualifiedName = QualifiedName(capability, functionName)
+	}
+
+	for _, entry := range entries {
+		if entry == functionName || entry == qualifiedName {
+			return true
+		}
+		if entry == baseName && !strings.Contains(entry, namespaceSeparator) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package golisp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSandbox(t *testing.T) {
+	tests := [...]struct {
+		desc     string
+		sandbox  Sandbox
+		input    string
+		expected error
+	}{
+		{desc: "pure permits arithmetic", sandbox: PureSandbox(), input: "(+ 1 (abs -2))"},
+		{desc: "pure permits qualified names", sandbox: PureSandbox(), input: "(str/upper \"a\")"},
+		{desc: "pure reads maps", sandbox: PureSandbox(), input: "(+ (get {:a 1} :a) (get-in {:b [2]} :b 0))"},
+		{desc: "pure rejects json", sandbox: PureSandbox(), input: "(get (json-parse \"{}\") \"a\")", expected: buildFunctionNotPermittedError("json-parse", "pure")},
+		{desc: "pure rejects functions passed as values", sandbox: PureSandbox(), input: "(map json-stringify [1])", expected: buildFunctionNotPermittedError("json-stringify", "pure")},
+		{desc: "standard permits json", sandbox: StandardSandbox(), input: "(get (json-parse \"{}\") \"a\")"},
+		{desc: "trusted permits everything", sandbox: TrustedSandbox(), input: "(re-find \"a\" \"abc\")"},
+		{desc: "deny by plain name", sandbox: Sandbox{Name: "no-upper", Capabilities: []string{"str"}, Deny: []string{"upper"}}, input: "(str/upper \"a\")", expected: buildFunctionNotPermittedError("str/upper", "no-upper")},
+		{desc: "deny by qualified name", sandbox: Sandbox{Name: "no-upper", Capabilities: []string{"str"}, Deny: []string{"str/upper"}}, input: "(upper \"a\")", expected: buildFunctionNotPermittedError("upper", "no-upper")},
+		{desc: "deny division", sandbox: Sandbox{Name: "no-division", Capabilities: []string{"math"}, Deny: []string{"/"}}, input: "(/ 1 2)", expected: buildFunctionNotPermittedError("/", "no-division")},
+		{desc: "allow a single function", sandbox: Sandbox{Name: "sums", Allow: []string{"+"}}, input: "(+ 1 2)"},
+		{desc: "allow doesn't grant the namespace", sandbox: Sandbox{Name: "sums", Allow: []string{"+"}}, input: "(+ 1 (- 3 2))", expected: buildFunctionNotPermittedError("-", "sums")},
+		{desc: "symbols are not functions", sandbox: Sandbox{Name: "nothing"}, input: "abs"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := NewEvaluationContext(nil, WithSandbox(test.sandbox))
+			ctx.SymbolTable["abs"] = intVariant(1)
+
+			sexpr, e := ctx.Parse(test.input)
+			assert.Equal(t, test.expected, e)
+
+			if e != nil {
+				// the functions are removed from the context as well, in case the script isn't checked
+				sexpr, _ = Parse(test.input)
+				assert.Equal(t, VAR_ERROR, sexpr.Eval(ctx).VariantType)
+			}
+		})
+	}
+}
+
+func TestSandboxedHostFunctions(t *testing.T) {
+	ctx := NewEvaluationContext(nil, WithSandbox(PureSandbox()))
+	assert.Equal(t, buildFunctionNotPermittedError("answer", "pure"), ctx.RegisterGoFunc("answer", func() int64 { return 42 }))
+
+	sandbox := PureSandbox()
+	sandbox.Allow = []string{"answer"}
+	ctx = NewEvaluationContext(nil, WithSandbox(sandbox))
+	assert.Nil(t, ctx.RegisterGoFunc("answer", func() int64 { return 42 }))
+
+	sexpr, e := ctx.Parse("(+ (answer) 1)")
+	assert.Nil(t, e)
+	assert.Equal(t, intVariant(43), sexpr.Eval(ctx))
+}
+
+func TestChildSandbox(t *testing.T) {
+	parent := NewEvaluationContext(nil)
+	child := NewEvaluationContext(parent, WithSandbox(Sandbox{Name: "math-only", Capabilities: []string{"math"}}))
+
+	sexpr, _ := Parse("(upper \"a\")")
+	notPermitted := buildFunctionNotPermittedError("upper", "math-only")
+	assert.Equal(t, Variant{VariantType: VAR_ERROR, VariantValue: buildFunctionNameNotFoundError(notPermitted.Error())}, sexpr.Eval(child))
+	assert.Equal(t, stringVariant("A"), sexpr.Eval(parent))
+}
+
+func TestRequirements(t *testing.T) {
+	ctx := NewEvaluationContext(nil)
+	_ = ctx.RegisterGoFunc("discount", func(x float64) float64 { return x * 0.9 })
+	ctx.SymbolTable["total"] = intVariant(100)
+
+	sexpr, _ := Parse("(if-missing (json-path (json-parse data) \"$.a\") (discount total) (map abs [total]))")
+	assert.Equal(
+		t,
+		Requirements{
+			Functions:    []string{"json-path", "json-parse", "discount", "map", "abs"},
+			Capabilities: []string{"host", "json", "math", "seq"},
+		},
+		ctx.Requirements(sexpr))
+}
+
+type testCancellable struct {
+	Cancel func()
+}
+
+func TestSandboxedHostObjectFunctions(t *testing.T) {
+	cancelled := false
+	order := Variant{VariantType: VAR_OBJECT, VariantValue: &testCancellable{Cancel: func() { cancelled = true }}}
+	notPermitted := func(property string) Variant {
+		return Variant{VariantType: VAR_ERROR, VariantValue: buildFunctionPropertyNotPermittedError(property, "standard")}
+	}
+
+	tests := [...]struct {
+		desc     string
+		input    string
+		expected Variant
+	}{
+		{desc: "dotted property", input: "(order.Cancel)", expected: Variant{VariantType: VAR_ERROR, VariantValue: buildFunctionNameNotFoundError(notPermitted("order.Cancel").VariantValue.(error).Error())}},
+		{desc: "get", input: "(get order \"Cancel\")", expected: notPermitted("Cancel")},
+		{desc: "get-in", input: "(get-in order :Cancel)", expected: notPermitted(":Cancel")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := NewEvaluationContext(nil, WithSandbox(StandardSandbox()))
+			ctx.SymbolTable["order"] = order
+
+			sexpr, e := ctx.Parse(test.input)
+			assert.Nil(t, e)
+			assert.Equal(t, test.expected, sexpr.Eval(ctx))
+		})
+	}
+	assert.False(t, cancelled)
+
+	sandbox := StandardSandbox()
+	sandbox.Capabilities = append(sandbox.Capabilities, CapabilityHost)
+	ctx := NewEvaluationContext(nil, WithSandbox(sandbox))
+	ctx.SymbolTable["order"] = order
+
+	sexpr, _ := ctx.Parse("(order.Cancel)")
+	sexpr.Eval(ctx)
+	assert.True(t, cancelled)
+}
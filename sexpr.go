@@ -8,9 +8,15 @@ import (
 // SExpr ::= null | atom | list
 // atom ::= bool | int | float | "string"
 // list ::= ( SExpr+ )
+// map ::= { (SExpr SExpr)* }
+// vector ::= [ SExpr* ]
+// set ::= #{ SExpr* }
+// interpolated ::= $"(text | {SExpr})*"
 
+// SExpr is a parsed expression. Eval evaluates it in the context and returns its value, leaving the context's
+// EvaluatedValue alone; use EvalContext to evaluate an expression that may be cancelled.
 type SExpr interface {
-	Eval(*EvaluationContext) *EvaluationContext
+	Eval(*EvaluationContext) Variant
 	String() string
 }
 
@@ -24,6 +30,7 @@ func (p *null) String() string {
 
 /* atom */
 type atom struct {
+	span
 	rawValue   string
 	typedValue Variant
 }
@@ -34,6 +41,7 @@ func (p *atom) String() string {
 
 /* list */
 type list struct {
+	span
 	children []SExpr
 }
 
@@ -45,3 +53,85 @@ func (p *list) String() string {
 
 	return fmt.Sprintf("(%s)", strings.TrimSpace(builder.String()))
 }
+
+/* map */
+type mapLiteral struct {
+	span
+	children []SExpr
+}
+
+func (p *mapLiteral) String() string {
+	builder := strings.Builder{}
+	for _, c := range p.children {
+		builder.WriteString(fmt.Sprintf("%s ", c.String()))
+	}
+
+	return fmt.Sprintf("{%s}", strings.TrimSpace(builder.String()))
+}
+
+/* vector */
+type vectorLiteral struct {
+	span
+	children []SExpr
+}
+
+func (p *vectorLiteral) String() string {
+	builder := strings.Builder{}
+	for _, c := range p.children {
+		builder.WriteString(fmt.Sprintf("%s ", c.String()))
+	}
+
+	return fmt.Sprintf("[%s]", strings.TrimSpace(builder.String()))
+}
+
+/* set */
+type setLiteral struct {
+	span
+	children []SExpr
+}
+
+func (p *setLiteral) String() string {
+	builder := strings.Builder{}
+	for _, c := range p.children {
+		builder.WriteString(fmt.Sprintf("%s ", c.String()))
+	}
+
+	return fmt.Sprintf("#{%s}", strings.TrimSpace(builder.String()))
+}
+
+/* interpolated string */
+type interpolatedString struct {
+	span
+	rawValue string
+	parts    []SExpr
+}
+
+func (p *interpolatedString) String() string {
+	return p.rawValue
+}
+
+// walk visits the expression and then every expression nested in it, in the order they are written.
+func walk(expr SExpr, visit func(SExpr)) {
+	visit(expr)
+	for _, c := range childrenOf(expr) {
+		walk(c, visit)
+	}
+}
+
+// childrenOf returns the expressions directly within an expression: the items of a list or collection literal, or the
+// parts of an interpolated string.
+func childrenOf(expr SExpr) []SExpr {
+	switch p := expr.(type) {
+	case *list:
+		return p.children
+	case *mapLiteral:
+		return p.children
+	case *vectorLiteral:
+		return p.children
+	case *setLiteral:
+		return p.children
+	case *interpolatedString:
+		return p.parts
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package golisp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// VariadicArity is the MaxArity of a function that takes any number of arguments.
+const VariadicArity = -1
+
+// Signature describes the arguments that a library function accepts.
+type Signature struct {
+	MinArity int
+	MaxArity int // or VariadicArity
+
+	// ArityStep is the difference between the numbers of arguments accepted, from MinArity up, so that a function
+	// taking key/value pairs has a step of 2. Zero means 1.
+	ArityStep int
+
+	// ArgumentTypes are the types accepted in each position, nil meaning any type. The positions that aren't listed
+	// accept any type, except in a variadic function, where the last types listed apply to the rest of the arguments.
+	ArgumentTypes [][]EnumVariantType
+}
+
+// The types of arguments that the library functions share.
+var (
+	anyType      []EnumVariantType
+	numberTypes  = []EnumVariantType{VAR_BOOL, VAR_INT, VAR_FLOAT}
+	booleanTypes = []EnumVariantType{VAR_BOOL, VAR_INT}
+	intType      = []EnumVariantType{VAR_INT}
+	stringType   = []EnumVariantType{VAR_STRING}
+	functionType = []EnumVariantType{VAR_FUNCTION}
+)
+
+func exactly(arity int, types ...[]EnumVariantType) Signature {
+	return Signature{MinArity: arity, MaxArity: arity, ArgumentTypes: types}
+}
+
+func between(minArity int, maxArity int, types ...[]EnumVariantType) Signature {
+	return Signature{MinArity: minArity, MaxArity: maxArity, ArgumentTypes: types}
+}
+
+func atLeast(arity int, types ...[]EnumVariantType) Signature {
+	return Signature{MinArity: arity, MaxArity: VariadicArity, ArgumentTypes: types}
+}
+
+// inPairs is a signature of key/value pairs following the arguments, such as (assoc m k v ...).
+func inPairs(arity int, types ...[]EnumVariantType) Signature {
+	return Signature{MinArity: arity + 2, MaxArity: VariadicArity, ArityStep: 2, ArgumentTypes: types}
+}
+
+// AcceptsArity reports whether the function accepts the number of arguments.
+func (s Signature) AcceptsArity(n int) bool {
+	step := s.ArityStep
+	if step == 0 {
+		step = 1
+	}
+	return n >= s.MinArity && (s.MaxArity == VariadicArity || n <= s.MaxArity) && (n-s.MinArity)%step == 0
+}
+
+// AcceptedTypes returns the types of argument accepted in the position, or nil if any type is.
+func (s Signature) AcceptedTypes(position int) []EnumVariantType {
+	switch {
+	case position < len(s.ArgumentTypes):
+		return s.ArgumentTypes[position]
+	case s.MaxArity == VariadicArity && len(s.ArgumentTypes) > 0:
+		return s.ArgumentTypes[len(s.ArgumentTypes)-1]
+	}
+	return nil
+}
+
+// DescribeArity describes the numbers of arguments accepted, such as "exactly 1 argument" or "1 to 3 arguments".
+func (s Signature) DescribeArity() string {
+	plural := func(n int) string {
+		if n == 1 {
+			return "1 argument"
+		}
+		return fmt.Sprintf("%d arguments", n)
+	}
+
+	switch {
+	case s.ArityStep == 2 && s.MinArity == 0:
+		return "an even number of arguments"
+	case s.ArityStep == 2 && s.MinArity%2 == 0:
+		return fmt.Sprintf("an even number of arguments, at least %d", s.MinArity)
+	case s.ArityStep == 2:
+		return fmt.Sprintf("an odd number of arguments, at least %d", s.MinArity)
+	case s.MaxArity == VariadicArity:
+		return "at least " + plural(s.MinArity)
+	case s.MinArity == s.MaxArity:
+		return "exactly " + plural(s.MinArity)
+	case s.MinArity == 0:
+		return "at most " + plural(s.MaxArity)
+	}
+	return fmt.Sprintf("%d to %d arguments", s.MinArity, s.MaxArity)
+}
+
+// joinTypes lists the types in the order they are declared, as "VAR_BOOL, VAR_FLOAT or VAR_INT".
+func joinTypes(types []EnumVariantType) string {
+	sorted := append([]EnumVariantType{}, types...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	names := make([]string, len(sorted))
+	for i, t := range sorted {
+		names[i] = t.String()
+	}
+	if len(names) == 1 {
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
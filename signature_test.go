@@ -0,0 +1,52 @@
+package golisp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignature(t *testing.T) {
+	tests := [...]struct {
+		desc      string
+		signature Signature
+		accepted  []int
+		rejected  []int
+		expected  string
+	}{
+		{desc: "exactly one", signature: exactly(1), accepted: []int{1}, rejected: []int{0, 2}, expected: "exactly 1 argument"},
+		{desc: "exactly two", signature: exactly(2), accepted: []int{2}, rejected: []int{1, 3}, expected: "exactly 2 arguments"},
+		{desc: "optional", signature: between(0, 1), accepted: []int{0, 1}, rejected: []int{2}, expected: "at most 1 argument"},
+		{desc: "range", signature: between(1, 3), accepted: []int{1, 2, 3}, rejected: []int{0, 4}, expected: "1 to 3 arguments"},
+		{desc: "variadic", signature: atLeast(2), accepted: []int{2, 3, 100}, rejected: []int{0, 1}, expected: "at least 2 arguments"},
+		{desc: "pairs", signature: Signature{MaxArity: VariadicArity, ArityStep: 2}, accepted: []int{0, 2, 4}, rejected: []int{1, 3}, expected: "an even number of arguments"},
+		{desc: "pairs following one", signature: inPairs(1), accepted: []int{3, 5}, rejected: []int{1, 2, 4}, expected: "an odd number of arguments, at least 3"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			for _, n := range test.accepted {
+				assert.True(t, test.signature.AcceptsArity(n), n)
+			}
+			for _, n := range test.rejected {
+				assert.False(t, test.signature.AcceptsArity(n), n)
+			}
+			assert.Equal(t, test.expected, test.signature.DescribeArity())
+		})
+	}
+}
+
+func TestSignature_AcceptedTypes(t *testing.T) {
+	fixed := exactly(3, stringType, intType)
+	assert.Equal(t, stringType, fixed.AcceptedTypes(0))
+	assert.Equal(t, intType, fixed.AcceptedTypes(1))
+	assert.Nil(t, fixed.AcceptedTypes(2), "positions that aren't listed accept any type")
+
+	variadic := atLeast(1, functionType, numberTypes)
+	assert.Equal(t, numberTypes, variadic.AcceptedTypes(5), "the last types listed apply to the rest of the arguments")
+}
+
+func TestJoinTypes(t *testing.T) {
+	assert.Equal(t, "VAR_INT", joinTypes(intType))
+	assert.Equal(t, "VAR_BOOL, VAR_FLOAT or VAR_INT", joinTypes(numberTypes))
+}
@@ -0,0 +1,114 @@
+package golisp
+
+import "strings"
+
+type syntaxKind uint8
+
+const (
+	syntaxAtom syntaxKind = iota
+	syntaxComment
+	syntaxList
+	syntaxVector
+	syntaxMap
+	syntaxSet
+)
+
+// syntaxNode is a node of the concrete syntax tree of a script. Unlike the SExpr that Parse returns, it keeps the
+// comments and the layout of the source, so that the script can be written back out.
+type syntaxNode struct {
+	kind     syntaxKind
+	text     string        // the source of an atom or comment
+	children []*syntaxNode // the forms and comments between the brackets of a list, vector, map or set
+
+	// span is where the node is in the source, and linesBefore counts the line breaks between the node and the token
+	// before it.
+	span
+	linesBefore int
+}
+
+func (n *syntaxNode) brackets() (string, string) {
+	switch n.kind {
+	case syntaxList:
+		return "(", ")"
+	case syntaxVector:
+		return "[", "]"
+	case syntaxMap:
+		return "{", "}"
+	case syntaxSet:
+		return "#{", "}"
+	default:
+		return "", ""
+	}
+}
+
+func (n *syntaxNode) isCollection() bool {
+	return n.kind >= syntaxList
+}
+
+// parseSyntax reads the concrete syntax tree of a script, returning its top-level forms and comments.
+func parseSyntax(src string) ([]*syntaxNode, error) {
+	tokenizer := newTokenizerContext(src)
+	root := &syntaxNode{kind: syntaxList}
+	open := []*syntaxNode{root}
+	closing := []enumTokenType{TOK_END}
+	previous := 0
+
+	for t := tokenizer.NextToken(); t != nil; t = tokenizer.NextToken() {
+		parent := open[len(open)-1]
+		node := &syntaxNode{
+			span:        tokenizer.spanOf(t),
+			linesBefore: strings.Count(tokenizer.code[previous:t.start], "\n"),
+		}
+		previous = t.finish
+
+		switch t.tokenType {
+		case TOK_COMMENT:
+			node.kind, node.text = syntaxComment, t.rawValue(tokenizer)
+
+		case TOK_SYMBOL, TOK_QUOTEDSTRING, TOK_INTERPOLATEDSTRING:
+			node.kind, node.text = syntaxAtom, t.rawValue(tokenizer)
+
+		case TOK_LPAREN, TOK_LBRACKET, TOK_LBRACE, TOK_LSET:
+			node.kind, node.children = collectionKinds[t.tokenType], []*syntaxNode{}
+			parent.children = append(parent.children, node)
+			open = append(open, node)
+			closing = append(closing, closingTokens[t.tokenType])
+			continue
+
+		case TOK_RPAREN, TOK_RBRACKET, TOK_RBRACE:
+			if closing[len(closing)-1] != t.tokenType {
+				return nil, unexpectedCloseErrors[t.tokenType]()
+			}
+			parent.end = node.end
+			open, closing = open[:len(open)-1], closing[:len(closing)-1]
+			continue
+		}
+
+		parent.children = append(parent.children, node)
+	}
+
+	if len(open) > 1 {
+		return nil, buildUnexpectedEndOfStringError()
+	}
+	return root.children, nil
+}
+
+var collectionKinds = map[enumTokenType]syntaxKind{
+	TOK_LPAREN:   syntaxList,
+	TOK_LBRACKET: syntaxVector,
+	TOK_LBRACE:   syntaxMap,
+	TOK_LSET:     syntaxSet,
+}
+
+var closingTokens = map[enumTokenType]enumTokenType{
+	TOK_LPAREN:   TOK_RPAREN,
+	TOK_LBRACKET: TOK_RBRACKET,
+	TOK_LBRACE:   TOK_RBRACE,
+	TOK_LSET:     TOK_RBRACE,
+}
+
+var unexpectedCloseErrors = map[enumTokenType]func() error{
+	TOK_RPAREN:   buildUnexpectedCloseParenError,
+	TOK_RBRACKET: buildUnexpectedCloseBracketError,
+	TOK_RBRACE:   buildUnexpectedCloseBraceError,
+}
@@ -15,6 +15,12 @@ const (
 	TOK_COMMENT
 	TOK_QUOTEDSTRING
 	TOK_SYMBOL
+	TOK_LBRACE
+	TOK_RBRACE
+	TOK_LBRACKET
+	TOK_RBRACKET
+	TOK_LSET
+	TOK_INTERPOLATEDSTRING
 	TOK_END
 	// put new tokens between BEGIN and END, and ensure you implement `String()` correctly!
 	TOK_UNKNOWN
@@ -28,6 +34,12 @@ func (t enumTokenType) String() string {
 		"COMMENT",
 		"QUOTEDSTRING",
 		"SYMBOL",
+		"LBRACE",
+		"RBRACE",
+		"LBRACKET",
+		"RBRACKET",
+		"LSET",
+		"INTERPOLATEDSTRING",
 		"END",
 		"UNKNOWN",
 	}
@@ -48,11 +60,16 @@ type token struct {
 type tokenizerContext struct {
 	code string
 	idx  int
+
+	// offset is the length of the whitespace trimmed from the front of the source, and lines are the offsets at which
+	// the lines of the source start, so that tokens can be placed in the source.
+	offset int
+	lines  []int
 }
 
 func (t *token) rawValue(ctx *tokenizerContext) string {
 	switch t.tokenType {
-	case TOK_SYMBOL, TOK_QUOTEDSTRING, TOK_COMMENT:
+	case TOK_SYMBOL, TOK_QUOTEDSTRING, TOK_INTERPOLATEDSTRING, TOK_COMMENT:
 		return ctx.code[t.start:t.finish]
 	default:
 		return ""
@@ -60,8 +77,8 @@ func (t *token) rawValue(ctx *tokenizerContext) string {
 }
 
 func (ctx *tokenizerContext) hasMoreText() bool {
-	runecount := utf8.RuneCountInString(ctx.code)
-	return (ctx.idx < runecount)
+	length := len(ctx.code)
+	return (ctx.idx < length)
 }
 
 func (ctx *tokenizerContext) currentRune() (rune, int) {
@@ -69,13 +86,24 @@ func (ctx *tokenizerContext) currentRune() (rune, int) {
 }
 
 func newTokenizerContext(code string) *tokenizerContext {
-	return &tokenizerContext{code: strings.TrimSpace(code), idx: 0}
+	offset := len(code) - len(strings.TrimLeftFunc(code, unicode.IsSpace))
+	return &tokenizerContext{code: strings.TrimSpace(code), idx: 0, offset: offset, lines: lineStarts(code)}
+}
+
+// position places an index into the trimmed code in the source.
+func (ctx *tokenizerContext) position(idx int) Position {
+	return positionAt(ctx.lines, idx+ctx.offset)
+}
+
+// spanOf places a token in the source.
+func (ctx *tokenizerContext) spanOf(t *token) span {
+	return span{start: ctx.position(t.start), end: ctx.position(t.finish)}
 }
 
 func (ctx *tokenizerContext) skipWhitespace() {
-	runecount := utf8.RuneCountInString(ctx.code)
+	length := len(ctx.code)
 
-	for ctx.idx < runecount {
+	for ctx.idx < length {
 		r, width := ctx.currentRune()
 
 		if !unicode.IsSpace(r) {
@@ -108,6 +136,49 @@ func (ctx *tokenizerContext) read_RPARAM() *token {
 	return ctx.readChar(')', TOK_RPAREN)
 }
 
+func (ctx *tokenizerContext) read_LBRACE() *token {
+	return ctx.readChar('{', TOK_LBRACE)
+}
+
+func (ctx *tokenizerContext) read_RBRACE() *token {
+	return ctx.readChar('}', TOK_RBRACE)
+}
+
+func (ctx *tokenizerContext) read_LBRACKET() *token {
+	return ctx.readChar('[', TOK_LBRACKET)
+}
+
+func (ctx *tokenizerContext) read_RBRACKET() *token {
+	return ctx.readChar(']', TOK_RBRACKET)
+}
+
+// read_LSET reads the two character opening of a set literal, which is closed by an ordinary brace.
+func (ctx *tokenizerContext) read_LSET() *token {
+	if !(strings.HasPrefix(ctx.code[ctx.idx:], "#{")) {
+		return nil
+	}
+
+	start := ctx.idx
+	ctx.idx += len("#{")
+	return &token{start: start, finish: ctx.idx, tokenType: TOK_LSET}
+}
+
+// read_INTERPOLATEDSTRING reads a string prefixed with $, whose {expressions} are evaluated when the string is.
+func (ctx *tokenizerContext) read_INTERPOLATEDSTRING() *token {
+	if !(strings.HasPrefix(ctx.code[ctx.idx:], "$\"")) {
+		return nil
+	}
+
+	start := ctx.idx
+	ctx.idx += len("$")
+	if t := ctx.read_QUOTEDSTRING(); t != nil {
+		return &token{start: start, finish: t.finish, tokenType: TOK_INTERPOLATEDSTRING}
+	}
+
+	ctx.idx = start
+	return nil
+}
+
 func (ctx *tokenizerContext) read_QUOTEDSTRING() *token {
 	runeValue, width := ctx.currentRune()
 	if !isQuote(runeValue) {
@@ -117,8 +188,8 @@ func (ctx *tokenizerContext) read_QUOTEDSTRING() *token {
 	start := ctx.idx
 	ctx.idx += width
 
-	runecount := utf8.RuneCountInString(ctx.code)
-	for ctx.idx < runecount {
+	length := len(ctx.code)
+	for ctx.idx < length {
 		runeValue, width = ctx.currentRune()
 		ctx.idx += width
 
@@ -138,9 +209,9 @@ func (ctx *tokenizerContext) read_COMMENT() *token {
 	}
 
 	start := ctx.idx
-	runecount := utf8.RuneCountInString(ctx.code)
+	length := len(ctx.code)
 
-	for curr := start; curr <= runecount; curr++ {
+	for curr := start; curr <= length; curr++ {
 		if strings.HasSuffix(ctx.code[start:curr], "*)") {
 			ctx.idx = curr
 			return &token{start: start, finish: curr, tokenType: TOK_COMMENT}
@@ -152,9 +223,9 @@ func (ctx *tokenizerContext) read_COMMENT() *token {
 
 func (ctx *tokenizerContext) read_SYMBOL() *token {
 	start := ctx.idx
-	runecount := utf8.RuneCountInString(ctx.code)
+	length := len(ctx.code)
 
-	for ctx.idx < runecount {
+	for ctx.idx < length {
 		runeValue, width := ctx.currentRune()
 
 		if isSeparator(runeValue) {
@@ -176,14 +247,20 @@ func (ctx *tokenizerContext) NextToken() *token {
 		ctx.read_COMMENT,
 		ctx.read_LPARAM,
 		ctx.read_RPARAM,
+		ctx.read_LBRACE,
+		ctx.read_RBRACE,
+		ctx.read_LBRACKET,
+		ctx.read_RBRACKET,
+		ctx.read_LSET,
+		ctx.read_INTERPOLATEDSTRING,
 		ctx.read_QUOTEDSTRING,
 		ctx.read_SYMBOL,
 	}
 
 	ctx.skipWhitespace()
 
-	runecount := utf8.RuneCountInString(ctx.code)
-	if ctx.idx < runecount {
+	length := len(ctx.code)
+	if ctx.idx < length {
 		for _, tokenizerFunc := range tokenizerFuncs {
 			if token := tokenizerFunc(); token != nil {
 				return token
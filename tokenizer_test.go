@@ -29,6 +29,11 @@ func TestTokenizer(t *testing.T) {
 		{input: "(a a a a)", expected: []TokenizerTestResult{{tokenType: TOK_LPAREN}, {tokenType: TOK_SYMBOL, value: "a"}, {tokenType: TOK_SYMBOL, value: "a"}, {tokenType: TOK_SYMBOL, value: "a"}, {tokenType: TOK_SYMBOL, value: "a"}, {tokenType: TOK_RPAREN}}},
 		{input: "(() )", expected: []TokenizerTestResult{{tokenType: TOK_LPAREN}, {tokenType: TOK_LPAREN}, {tokenType: TOK_RPAREN}, {tokenType: TOK_RPAREN}}},
 		{input: "(\"this and that\")", expected: []TokenizerTestResult{{tokenType: TOK_LPAREN}, {tokenType: TOK_QUOTEDSTRING, value: "\"this and that\""}, {tokenType: TOK_RPAREN}}},
+		{input: "{a 1}", expected: []TokenizerTestResult{{tokenType: TOK_LBRACE}, {tokenType: TOK_SYMBOL, value: "a"}, {tokenType: TOK_SYMBOL, value: "1"}, {tokenType: TOK_RBRACE}}},
+		{input: "[a 1]", expected: []TokenizerTestResult{{tokenType: TOK_LBRACKET}, {tokenType: TOK_SYMBOL, value: "a"}, {tokenType: TOK_SYMBOL, value: "1"}, {tokenType: TOK_RBRACKET}}},
+		{input: `(f "héllo" ü)`, expected: []TokenizerTestResult{{tokenType: TOK_LPAREN}, {tokenType: TOK_SYMBOL, value: "f"}, {tokenType: TOK_QUOTEDSTRING, value: `"héllo"`}, {tokenType: TOK_SYMBOL, value: "ü"}, {tokenType: TOK_RPAREN}}},
+		{input: `$"a {b}"`, expected: []TokenizerTestResult{{tokenType: TOK_INTERPOLATEDSTRING, value: `$"a {b}"`}}},
+		{input: "#{a}", expected: []TokenizerTestResult{{tokenType: TOK_LSET}, {tokenType: TOK_SYMBOL, value: "a"}, {tokenType: TOK_RBRACE}}},
 		{input: "(* this is a comment *)", expected: []TokenizerTestResult{{tokenType: TOK_COMMENT, value: "(* this is a comment *)"}}},
 		{input: "((* this is a comment *))", expected: []TokenizerTestResult{{tokenType: TOK_LPAREN}, {tokenType: TOK_COMMENT, value: "(* this is a comment *)"}, {tokenType: TOK_RPAREN}}},
 	}
@@ -2,6 +2,9 @@ package golisp
 
 import (
 	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -18,6 +21,14 @@ const (
 	VAR_IDENT
 	VAR_ERROR
 	VAR_FUNCTION
+	VAR_LIST
+	VAR_MAP
+	VAR_OBJECT
+	VAR_KEYWORD
+	VAR_VECTOR
+	VAR_SET
+	VAR_FUTURE
+	VAR_CHANNEL
 	VAR_MAX
 )
 
@@ -33,6 +44,14 @@ func (t EnumVariantType) String() string {
 		"VAR_IDENT",
 		"VAR_ERROR",
 		"VAR_FUNCTION",
+		"VAR_LIST",
+		"VAR_MAP",
+		"VAR_OBJECT",
+		"VAR_KEYWORD",
+		"VAR_VECTOR",
+		"VAR_SET",
+		"VAR_FUTURE",
+		"VAR_CHANNEL",
 		"VAR_MAX",
 	}
 
@@ -43,6 +62,14 @@ func (t EnumVariantType) String() string {
 	return strings[t]
 }
 
+const keywordPrefix = ":"
+
+// isKeywordName is true if a keyword can be named name, which mustn't be empty, contain separators or start with
+// the keyword prefix itself, so that a keyword literal and the keyword function always agree on the name.
+func isKeywordName(name string) bool {
+	return name != "" && !strings.HasPrefix(name, keywordPrefix) && strings.IndexFunc(name, isSeparator) < 0
+}
+
 type Variant struct {
 	VariantType  EnumVariantType
 	VariantValue interface{}
@@ -225,6 +252,18 @@ func (b *Variant) GetTypeConsistentValue() (interface{}, error) {
 			return nil, buildInconsistentTypeError(b.VariantValue, b.VariantType)
 		}
 
+	case VAR_KEYWORD:
+		switch b.VariantValue.(type) {
+		case string:
+			if !strings.HasPrefix(b.VariantValue.(string), keywordPrefix) {
+				return nil, buildInconsistentTypeError(b.VariantValue, b.VariantType)
+			}
+			return b.VariantValue, nil
+
+		default:
+			return nil, buildInconsistentTypeError(b.VariantValue, b.VariantType)
+		}
+
 	case VAR_ERROR:
 		switch b.VariantValue.(type) {
 		case error:
@@ -233,6 +272,60 @@ func (b *Variant) GetTypeConsistentValue() (interface{}, error) {
 			return nil, buildInconsistentTypeError(b.VariantValue, b.VariantType)
 		}
 
+	case VAR_FUNCTION:
+		switch b.VariantValue.(type) {
+		case FunctionType:
+			return b.VariantValue, nil
+		default:
+			return nil, buildInconsistentTypeError(b.VariantValue, b.VariantType)
+		}
+
+	case VAR_LIST, VAR_VECTOR:
+		switch b.VariantValue.(type) {
+		case []Variant:
+			return b.VariantValue, nil
+		default:
+			return nil, buildInconsistentTypeError(b.VariantValue, b.VariantType)
+		}
+
+	case VAR_MAP:
+		switch b.VariantValue.(type) {
+		case *VariantMap:
+			return b.VariantValue, nil
+		default:
+			return nil, buildInconsistentTypeError(b.VariantValue, b.VariantType)
+		}
+
+	case VAR_SET:
+		switch b.VariantValue.(type) {
+		case *VariantSet:
+			return b.VariantValue, nil
+		default:
+			return nil, buildInconsistentTypeError(b.VariantValue, b.VariantType)
+		}
+
+	case VAR_OBJECT:
+		if b.VariantValue == nil {
+			return nil, buildInconsistentTypeError(b.VariantValue, b.VariantType)
+		}
+		return b.VariantValue, nil
+
+	case VAR_FUTURE:
+		switch b.VariantValue.(type) {
+		case *future:
+			return b.VariantValue, nil
+		default:
+			return nil, buildInconsistentTypeError(b.VariantValue, b.VariantType)
+		}
+
+	case VAR_CHANNEL:
+		switch b.VariantValue.(type) {
+		case *channel:
+			return b.VariantValue, nil
+		default:
+			return nil, buildInconsistentTypeError(b.VariantValue, b.VariantType)
+		}
+
 	default:
 		break
 	}
@@ -263,8 +356,26 @@ func (b *Variant) ToDebugString() string {
 		return v.(string)
 	case VAR_IDENT:
 		return v.(string)
+	case VAR_KEYWORD:
+		return v.(string)
 	case VAR_ERROR:
 		return v.(error).Error()
+	case VAR_FUNCTION:
+		return "FUNCTION"
+	case VAR_LIST:
+		return fmt.Sprintf("(%s)", joinDebugStrings(v.([]Variant)))
+	case VAR_VECTOR:
+		return fmt.Sprintf("[%s]", joinDebugStrings(v.([]Variant)))
+	case VAR_MAP:
+		return v.(*VariantMap).debugString()
+	case VAR_SET:
+		return v.(*VariantSet).debugString()
+	case VAR_OBJECT:
+		return fmt.Sprintf("%+v", v)
+	case VAR_FUTURE:
+		return "FUTURE"
+	case VAR_CHANNEL:
+		return "CHANNEL"
 	default:
 		break
 	}
@@ -272,6 +383,102 @@ func (b *Variant) ToDebugString() string {
 	panic("should never get here")
 }
 
+// Equals compares variants by type and value. Numbers of different types are never equal, which keeps equality
+// consistent with the way variants are used as map keys.
+func (b *Variant) Equals(other Variant) bool {
+	if b.VariantType != other.VariantType {
+		return false
+	}
+
+	switch b.VariantType {
+	case VAR_LIST, VAR_VECTOR:
+		l, e1 := b.GetTypeConsistentValue()
+		r, e2 := other.GetTypeConsistentValue()
+		if e1 != nil || e2 != nil {
+			return false
+		}
+		return variantsEqual(l.([]Variant), r.([]Variant))
+
+	case VAR_MAP:
+		l, e1 := b.GetMapValue()
+		r, e2 := other.GetMapValue()
+		if e1 != nil || e2 != nil || l.Len() != r.Len() {
+			return false
+		}
+		for i, k := range l.keys {
+			v, found := r.Get(k)
+			if !found || !l.values[i].Equals(v) {
+				return false
+			}
+		}
+		return true
+
+	case VAR_SET:
+		l, e1 := b.GetSetValue()
+		r, e2 := other.GetSetValue()
+		return e1 == nil && e2 == nil && l.Len() == r.Len() && l.IsSubset(r)
+
+	case VAR_OBJECT:
+		return reflect.DeepEqual(b.VariantValue, other.VariantValue)
+
+	case VAR_FUTURE, VAR_CHANNEL:
+		return b.VariantValue == other.VariantValue
+	}
+
+	l, e1 := b.hashKey()
+	r, e2 := other.hashKey()
+	return e1 == nil && e2 == nil && l == r
+}
+
+func variantsEqual(l []Variant, r []Variant) bool {
+	if len(l) != len(r) {
+		return false
+	}
+	for i := range l {
+		if !l[i].Equals(r[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetKeywordName returns the name of a keyword without its leading colon.
+func (b *Variant) GetKeywordName() (string, error) {
+	targetType := VAR_KEYWORD
+	errorValue := ""
+	acceptableTypes := map[EnumVariantType]bool{
+		VAR_KEYWORD: true,
+	}
+
+	if _, t := acceptableTypes[b.VariantType]; !t {
+		return errorValue, buildTypeError(b.VariantType, targetType)
+	}
+
+	if value, err := b.GetTypeConsistentValue(); err != nil {
+		return errorValue, err
+	} else {
+		return strings.TrimPrefix(value.(string), keywordPrefix), nil
+	}
+}
+
+// toElementDebugString quotes strings so that they remain distinguishable inside collections.
+func (b *Variant) toElementDebugString() string {
+	if b.VariantType == VAR_STRING {
+		if s, e := b.CoerceToString(); e == nil {
+			return strconv.Quote(s)
+		}
+	}
+	return b.ToDebugString()
+}
+
+func joinDebugStrings(items []Variant) string {
+	strs := make([]string, len(items))
+	for i, item := range items {
+		strs[i] = item.toElementDebugString()
+	}
+	return strings.Join(strs, " ")
+}
+
 func (b *Variant) GetDateValue() (time.Time, error) {
 	targetType := VAR_DATE
 	errorValue := time.Time{}
@@ -358,6 +565,15 @@ func (b *Variant) CoerceToString() (string, error) {
 	targetType := VAR_STRING
 	errorValue := ""
 
+	// collections share their go representation, so they can only be told apart by their variant type
+	switch b.VariantType {
+	case VAR_LIST, VAR_VECTOR, VAR_MAP, VAR_SET:
+		if _, err := b.GetTypeConsistentValue(); err != nil {
+			return errorValue, err
+		}
+		return b.ToDebugString(), nil
+	}
+
 	coerced := &Variant{VariantType: targetType, VariantValue: b.VariantValue}
 
 	if value, err := coerced.GetTypeConsistentValue(); err != nil {
@@ -402,3 +618,90 @@ func (b *Variant) GetErrorValue() (error, error) {
 		return value.(error), nil
 	}
 }
+
+func (b *Variant) GetListValue() ([]Variant, error) {
+	targetType := VAR_LIST
+	errorValue := []Variant{}
+	acceptableTypes := map[EnumVariantType]bool{
+		VAR_LIST: true,
+	}
+
+	if _, t := acceptableTypes[b.VariantType]; !t {
+		return errorValue, buildTypeError(b.VariantType, targetType)
+	}
+
+	if value, err := b.GetTypeConsistentValue(); err != nil {
+		return errorValue, err
+	} else {
+		return value.([]Variant), nil
+	}
+}
+
+func (b *Variant) GetMapValue() (*VariantMap, error) {
+	targetType := VAR_MAP
+	errorValue := NewVariantMap()
+	acceptableTypes := map[EnumVariantType]bool{
+		VAR_MAP: true,
+	}
+
+	if _, t := acceptableTypes[b.VariantType]; !t {
+		return errorValue, buildTypeError(b.VariantType, targetType)
+	}
+
+	if value, err := b.GetTypeConsistentValue(); err != nil {
+		return errorValue, err
+	} else {
+		return value.(*VariantMap), nil
+	}
+}
+
+func (b *Variant) GetVectorValue() ([]Variant, error) {
+	targetType := VAR_VECTOR
+	errorValue := []Variant{}
+	acceptableTypes := map[EnumVariantType]bool{
+		VAR_VECTOR: true,
+	}
+
+	if _, t := acceptableTypes[b.VariantType]; !t {
+		return errorValue, buildTypeError(b.VariantType, targetType)
+	}
+
+	if value, err := b.GetTypeConsistentValue(); err != nil {
+		return errorValue, err
+	} else {
+		return value.([]Variant), nil
+	}
+}
+
+func (b *Variant) GetSetValue() (*VariantSet, error) {
+	targetType := VAR_SET
+	errorValue := NewVariantSet()
+	acceptableTypes := map[EnumVariantType]bool{
+		VAR_SET: true,
+	}
+
+	if _, t := acceptableTypes[b.VariantType]; !t {
+		return errorValue, buildTypeError(b.VariantType, targetType)
+	}
+
+	if value, err := b.GetTypeConsistentValue(); err != nil {
+		return errorValue, err
+	} else {
+		return value.(*VariantSet), nil
+	}
+}
+
+// GetSequenceValue returns the items of a list, a vector or a set, in order.
+func (b *Variant) GetSequenceValue() ([]Variant, error) {
+	switch b.VariantType {
+	case VAR_LIST:
+		return b.GetListValue()
+	case VAR_SET:
+		s, e := b.GetSetValue()
+		if e != nil {
+			return []Variant{}, e
+		}
+		return s.Items(), nil
+	}
+	return b.GetVectorValue()
+}
@@ -0,0 +1,99 @@
+package golisp
+
+import (
+	"strings"
+	"time"
+)
+
+func isNumberType(t EnumVariantType) bool {
+	return t == VAR_INT || t == VAR_FLOAT
+}
+
+func compareInts(l int64, r int64) int {
+	switch {
+	case l < r:
+		return -1
+	case l > r:
+		return 1
+	}
+	return 0
+}
+
+func compareFloats(l float64, r float64) int {
+	switch {
+	case l < r:
+		return -1
+	case l > r:
+		return 1
+	}
+	return 0
+}
+
+// Compare orders two variants, returning a negative number, zero or a positive number as the receiver is less than,
+// equal to or greater than the other. Numbers of different types are ordered by value, even though they are never
+// equal; lists and vectors are ordered element by element. Any other pair of different types cannot be ordered.
+func (b *Variant) Compare(other Variant) (int, error) {
+	if isNumberType(b.VariantType) && isNumberType(other.VariantType) {
+		if b.VariantType == VAR_INT && other.VariantType == VAR_INT {
+			l, e1 := b.CoerceToInt()
+			r, e2 := other.CoerceToInt()
+			if e1 != nil || e2 != nil {
+				return 0, buildIncomparableTypesError(b.VariantType, other.VariantType)
+			}
+			return compareInts(l, r), nil
+		}
+
+		l, e1 := b.CoerceToFloat()
+		r, e2 := other.CoerceToFloat()
+		if e1 != nil || e2 != nil {
+			return 0, buildIncomparableTypesError(b.VariantType, other.VariantType)
+		}
+		return compareFloats(l, r), nil
+	}
+
+	if b.VariantType != other.VariantType {
+		return 0, buildIncomparableTypesError(b.VariantType, other.VariantType)
+	}
+
+	l, e := b.GetTypeConsistentValue()
+	if e != nil {
+		return 0, e
+	}
+
+	r, e := other.GetTypeConsistentValue()
+	if e != nil {
+		return 0, e
+	}
+
+	switch b.VariantType {
+	case VAR_NULL:
+		return 0, nil
+
+	case VAR_BOOL:
+		return compareInts(boolToInt(l.(bool)), boolToInt(r.(bool))), nil
+
+	case VAR_STRING, VAR_KEYWORD:
+		return strings.Compare(l.(string), r.(string)), nil
+
+	case VAR_DATE:
+		return compareInts(l.(time.Time).UnixNano(), r.(time.Time).UnixNano()), nil
+
+	case VAR_LIST, VAR_VECTOR:
+		ls, rs := l.([]Variant), r.([]Variant)
+		for i := 0; i < len(ls) && i < len(rs); i++ {
+			if c, e := ls[i].Compare(rs[i]); e != nil || c != 0 {
+				return c, e
+			}
+		}
+		return compareInts(int64(len(ls)), int64(len(rs))), nil
+	}
+
+	return 0, buildIncomparableTypesError(b.VariantType, other.VariantType)
+}
+
+func boolToInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
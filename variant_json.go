@@ -0,0 +1,369 @@
+package golisp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Variants that have no natural JSON representation are encoded as single-key objects tagged with one of these keys,
+// which only UnmarshalVariantJSON decodes back.
+const (
+	jsonTagDate     = "$date"
+	jsonTagError    = "$error"
+	jsonTagFunction = "$function"
+	jsonTagIdent    = "$ident"
+	jsonTagFloat    = "$float"
+	jsonTagMap      = "$map"
+	jsonTagSet      = "$set"
+)
+
+// MarshalJSON encodes the variant. Floats always carry a decimal point so that they decode back as floats.
+// Keywords are encoded as their names, so they decode back as strings.
+func (b Variant) MarshalJSON() ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	if e := b.writeJSON(buffer); e != nil {
+		return nil, e
+	}
+	return buffer.Bytes(), nil
+}
+
+// UnmarshalJSON decodes a JSON document into the variant as ParseJSON does, so that a host decoding data it has been
+// sent into a variant never gets the errors or identifiers that tagged objects stand for.
+func (b *Variant) UnmarshalJSON(data []byte) error {
+	v, e := ParseJSON(string(data))
+	if e != nil {
+		return e
+	}
+	*b = v
+	return nil
+}
+
+// ParseJSON decodes a JSON document as plain data. Integers become VAR_INT and all other numbers VAR_FLOAT.
+// Arrays become VAR_VECTOR, so that they can be indexed efficiently. Objects become maps with string keys, including
+// objects that look like the tagged objects written by MarshalJSON.
+func ParseJSON(s string) (Variant, error) {
+	return decodeJSON(s, false)
+}
+
+// UnmarshalVariantJSON decodes a document written by MarshalJSON, turning its tagged objects back into the dates,
+// errors, identifiers, floats, maps and sets they represent. As the tags can inject errors and identifiers into an
+// evaluation, it should only be given documents that the host wrote itself.
+func UnmarshalVariantJSON(s string) (Variant, error) {
+	return decodeJSON(s, true)
+}
+
+func decodeJSON(s string, tagged bool) (Variant, error) {
+	decoder := json.NewDecoder(strings.NewReader(s))
+	decoder.UseNumber()
+
+	v, e := decodeJSONValue(decoder, tagged)
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR}, e
+	}
+
+	if _, e := decoder.Token(); e != io.EOF {
+		return Variant{VariantType: VAR_ERROR}, buildJSONTrailingDataError()
+	}
+	return v, nil
+}
+
+func writeJSONTagged(buffer *bytes.Buffer, tag string, value interface{}) error {
+	encoded, e := json.Marshal(map[string]interface{}{tag: value})
+	if e != nil {
+		return e
+	}
+	buffer.Write(encoded)
+	return nil
+}
+
+func (b *Variant) writeJSON(buffer *bytes.Buffer) error {
+	v, e := b.GetTypeConsistentValue()
+	if e != nil {
+		return e
+	}
+
+	switch b.VariantType {
+	case VAR_UNKNOWN, VAR_NULL:
+		buffer.WriteString("null")
+
+	case VAR_BOOL:
+		buffer.WriteString(strconv.FormatBool(v.(bool)))
+
+	case VAR_INT:
+		buffer.WriteString(strconv.FormatInt(v.(int64), 10))
+
+	case VAR_FLOAT:
+		f := v.(float64)
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return writeJSONTagged(buffer, jsonTagFloat, strconv.FormatFloat(f, 'g', -1, 64))
+		}
+		s := strconv.FormatFloat(f, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eE") {
+			s += ".0"
+		}
+		buffer.WriteString(s)
+
+	case VAR_STRING:
+		encoded, e := json.Marshal(v.(string))
+		if e != nil {
+			return e
+		}
+		buffer.Write(encoded)
+
+	case VAR_KEYWORD:
+		name, e := b.GetKeywordName()
+		if e != nil {
+			return e
+		}
+		encoded, e := json.Marshal(name)
+		if e != nil {
+			return e
+		}
+		buffer.Write(encoded)
+
+	case VAR_DATE:
+		return writeJSONTagged(buffer, jsonTagDate, v.(time.Time).Format(time.RFC3339Nano))
+
+	case VAR_ERROR:
+		return writeJSONTagged(buffer, jsonTagError, v.(error).Error())
+
+	case VAR_IDENT:
+		return writeJSONTagged(buffer, jsonTagIdent, v.(string))
+
+	case VAR_FUNCTION:
+		return writeJSONTagged(buffer, jsonTagFunction, b.ToDebugString())
+
+	case VAR_LIST, VAR_VECTOR:
+		return writeJSONArray(buffer, v.([]Variant))
+
+	case VAR_MAP:
+		return writeJSONMap(buffer, v.(*VariantMap))
+
+	case VAR_SET:
+		buffer.WriteString("{\"" + jsonTagSet + "\":")
+		if e := writeJSONArray(buffer, v.(*VariantSet).Items()); e != nil {
+			return e
+		}
+		buffer.WriteByte('}')
+		return nil
+
+	case VAR_OBJECT:
+		copied, e := variantFromValue(reflect.ValueOf(v))
+		if e != nil {
+			return e
+		}
+		return copied.writeJSON(buffer)
+
+	default:
+		return buildUnhandledVariantTypeError()
+	}
+
+	return nil
+}
+
+func writeJSONArray(buffer *bytes.Buffer, items []Variant) error {
+	buffer.WriteByte('[')
+	for i, item := range items {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		if e := item.writeJSON(buffer); e != nil {
+			return e
+		}
+	}
+	buffer.WriteByte(']')
+	return nil
+}
+
+// writeJSONMap writes maps with string or keyword keys as objects, and any other map as a tagged array of key/value pairs.
+func writeJSONMap(buffer *bytes.Buffer, m *VariantMap) error {
+	names := map[string]bool{}
+	for _, k := range m.keys {
+		name, e := keyName(k)
+		if e != nil || names[name] {
+			pairs := make([]Variant, m.Len())
+			for i := range m.keys {
+				pairs[i] = Variant{VariantType: VAR_LIST, VariantValue: []Variant{m.keys[i], m.values[i]}}
+			}
+			buffer.WriteString("{\"" + jsonTagMap + "\":")
+			if e := writeJSONArray(buffer, pairs); e != nil {
+				return e
+			}
+			buffer.WriteByte('}')
+			return nil
+		}
+		names[name] = true
+	}
+
+	buffer.WriteByte('{')
+	for i := range m.keys {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		if e := m.keys[i].writeJSON(buffer); e != nil {
+			return e
+		}
+		buffer.WriteByte(':')
+		if e := m.values[i].writeJSON(buffer); e != nil {
+			return e
+		}
+	}
+	buffer.WriteByte('}')
+	return nil
+}
+
+func decodeJSONValue(decoder *json.Decoder, tagged bool) (Variant, error) {
+	token, e := decoder.Token()
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR}, e
+	}
+
+	switch t := token.(type) {
+	case nil:
+		return Variant{VariantType: VAR_NULL}, nil
+
+	case bool:
+		return Variant{VariantType: VAR_BOOL, VariantValue: t}, nil
+
+	case string:
+		return Variant{VariantType: VAR_STRING, VariantValue: t}, nil
+
+	case json.Number:
+		return decodeJSONNumber(t)
+
+	case json.Delim:
+		switch t {
+		case '[':
+			items := []Variant{}
+			for decoder.More() {
+				item, e := decodeJSONValue(decoder, tagged)
+				if e != nil {
+					return Variant{VariantType: VAR_ERROR}, e
+				}
+				items = append(items, item)
+			}
+			if _, e := decoder.Token(); e != nil {
+				return Variant{VariantType: VAR_ERROR}, e
+			}
+			return Variant{VariantType: VAR_VECTOR, VariantValue: items}, nil
+
+		case '{':
+			m := NewVariantMap()
+			for decoder.More() {
+				key, e := decoder.Token()
+				if e != nil {
+					return Variant{VariantType: VAR_ERROR}, e
+				}
+				value, e := decodeJSONValue(decoder, tagged)
+				if e != nil {
+					return Variant{VariantType: VAR_ERROR}, e
+				}
+				if e := m.put(Variant{VariantType: VAR_STRING, VariantValue: key.(string)}, value); e != nil {
+					return Variant{VariantType: VAR_ERROR}, e
+				}
+			}
+			if _, e := decoder.Token(); e != nil {
+				return Variant{VariantType: VAR_ERROR}, e
+			}
+			if !tagged {
+				return Variant{VariantType: VAR_MAP, VariantValue: m}, nil
+			}
+			return decodeJSONTagged(m)
+		}
+	}
+
+	return Variant{VariantType: VAR_ERROR}, buildUnhandledVariantTypeError()
+}
+
+func decodeJSONNumber(n json.Number) (Variant, error) {
+	if !strings.ContainsAny(n.String(), ".eE") {
+		if i, e := n.Int64(); e == nil {
+			return Variant{VariantType: VAR_INT, VariantValue: i}, nil
+		}
+	}
+
+	f, e := n.Float64()
+	if e != nil {
+		return Variant{VariantType: VAR_ERROR}, e
+	}
+	return Variant{VariantType: VAR_FLOAT, VariantValue: f}, nil
+}
+
+// decodeJSONTagged turns the tagged objects written by MarshalJSON back into the variants they represent.
+func decodeJSONTagged(m *VariantMap) (Variant, error) {
+	result := Variant{VariantType: VAR_MAP, VariantValue: m}
+	if m.Len() != 1 {
+		return result, nil
+	}
+
+	tag, _ := m.keys[0].CoerceToString()
+	value := m.values[0]
+	if value.VariantType != VAR_STRING && tag != jsonTagMap && tag != jsonTagSet {
+		return result, nil
+	}
+	s, _ := value.CoerceToString()
+
+	switch tag {
+	case jsonTagDate:
+		d, e := time.Parse(time.RFC3339Nano, s)
+		if e != nil {
+			return result, nil
+		}
+		return Variant{VariantType: VAR_DATE, VariantValue: d}, nil
+
+	case jsonTagError:
+		return Variant{VariantType: VAR_ERROR, VariantValue: errors.New(s)}, nil
+
+	case jsonTagIdent:
+		return Variant{VariantType: VAR_IDENT, VariantValue: s}, nil
+
+	case jsonTagFunction:
+		return Variant{VariantType: VAR_ERROR, VariantValue: buildJSONFunctionError()}, nil
+
+	case jsonTagFloat:
+		f, e := strconv.ParseFloat(s, 64)
+		if e != nil {
+			return result, nil
+		}
+		return Variant{VariantType: VAR_FLOAT, VariantValue: f}, nil
+
+	case jsonTagMap:
+		pairs, e := value.GetSequenceValue()
+		if e != nil {
+			return result, nil
+		}
+		decoded := NewVariantMap()
+		for _, pair := range pairs {
+			kv, e := pair.GetSequenceValue()
+			if e != nil || len(kv) != 2 {
+				return result, nil
+			}
+			if e := decoded.put(kv[0], kv[1]); e != nil {
+				return Variant{VariantType: VAR_ERROR}, e
+			}
+		}
+		return Variant{VariantType: VAR_MAP, VariantValue: decoded}, nil
+
+	case jsonTagSet:
+		items, e := value.GetSequenceValue()
+		if e != nil {
+			return result, nil
+		}
+		decoded := NewVariantSet()
+		for _, item := range items {
+			if e := decoded.put(item); e != nil {
+				return Variant{VariantType: VAR_ERROR}, e
+			}
+		}
+		return Variant{VariantType: VAR_SET, VariantValue: decoded}, nil
+	}
+
+	return result, nil
+}
@@ -0,0 +1,115 @@
+package golisp
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	placed := time.Date(2021, 5, 1, 10, 30, 0, 0, time.UTC)
+	m, _ := NewVariantMap().Set(stringVariant("b"), Variant{VariantType: VAR_INT, VariantValue: int64(1)})
+	m, _ = m.Set(stringVariant("a"), Variant{VariantType: VAR_LIST, VariantValue: []Variant{{VariantType: VAR_BOOL, VariantValue: true}, {VariantType: VAR_NULL}}})
+	intKeys, _ := NewVariantMap().Set(Variant{VariantType: VAR_INT, VariantValue: int64(1)}, stringVariant("one"))
+
+	tests := [...]struct {
+		desc     string
+		input    Variant
+		expected string
+	}{
+		{desc: "null", input: Variant{VariantType: VAR_NULL}, expected: `null`},
+		{desc: "int", input: Variant{VariantType: VAR_INT, VariantValue: int64(3)}, expected: `3`},
+		{desc: "integral float", input: Variant{VariantType: VAR_FLOAT, VariantValue: float64(3)}, expected: `3.0`},
+		{desc: "float", input: Variant{VariantType: VAR_FLOAT, VariantValue: 0.25}, expected: `0.25`},
+		{desc: "infinite float", input: Variant{VariantType: VAR_FLOAT, VariantValue: math.Inf(1)}, expected: `{"$float":"+Inf"}`},
+		{desc: "string", input: stringVariant("a \"b\""), expected: `"a \"b\""`},
+		{desc: "date", input: Variant{VariantType: VAR_DATE, VariantValue: placed}, expected: `{"$date":"2021-05-01T10:30:00Z"}`},
+		{desc: "error", input: Variant{VariantType: VAR_ERROR, VariantValue: errRandom}, expected: `{"$error":"a random error message"}`},
+		{desc: "function", input: Variant{VariantType: VAR_FUNCTION, VariantValue: FunctionType(arithmetic.add)}, expected: `{"$function":"FUNCTION"}`},
+		{desc: "map keeps insertion order", input: Variant{VariantType: VAR_MAP, VariantValue: m}, expected: `{"b":1,"a":[true,null]}`},
+		{desc: "map with non-string keys", input: Variant{VariantType: VAR_MAP, VariantValue: intKeys}, expected: `{"$map":[[1,"one"]]}`},
+		{desc: "object", input: Variant{VariantType: VAR_OBJECT, VariantValue: &testCustomer{Name: "Ada"}}, expected: `{"name":"Ada"}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			actual, e := json.Marshal(test.input)
+			assert.Nil(t, e)
+			assert.Equal(t, test.expected, string(actual))
+		})
+	}
+}
+
+func TestUnmarshalVariantJSON_RoundTrip(t *testing.T) {
+	inputs := [...]string{
+		`null`,
+		`42`,
+		`42.0`,
+		`"text"`,
+		`[1,2.5,"x",[true,false]]`,
+		`{"z":1,"a":{"$date":"2021-05-01T10:30:00Z"},"m":{"$map":[[1,"one"]]}}`,
+		`{"$error":"boom"}`,
+		`{"$float":"NaN"}`,
+		`{"$set":["a",1]}`,
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			v, e := UnmarshalVariantJSON(input)
+			assert.Nil(t, e)
+
+			output, e := json.Marshal(v)
+			assert.Nil(t, e)
+			assert.Equal(t, input, string(output))
+		})
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	tests := [...]struct {
+		desc     string
+		input    string
+		expected Variant
+	}{
+		{desc: "int", input: `7`, expected: Variant{VariantType: VAR_INT, VariantValue: int64(7)}},
+		{desc: "float", input: `7.5`, expected: Variant{VariantType: VAR_FLOAT, VariantValue: float64(7.5)}},
+		{desc: "exponent is a float", input: `1e3`, expected: Variant{VariantType: VAR_FLOAT, VariantValue: float64(1000)}},
+		{desc: "huge int", input: `18446744073709551616`, expected: Variant{VariantType: VAR_FLOAT, VariantValue: float64(18446744073709551616)}},
+		{desc: "error tag is a plain key", input: `{"$error":"x"}`, expected: func() Variant {
+			m, _ := NewVariantMap().Set(stringVariant("$error"), stringVariant("x"))
+			return Variant{VariantType: VAR_MAP, VariantValue: m}
+		}()},
+		{desc: "ident tag is a plain key", input: `{"who":{"$ident":"secret"}}`, expected: func() Variant {
+			inner, _ := NewVariantMap().Set(stringVariant("$ident"), stringVariant("secret"))
+			m, _ := NewVariantMap().Set(stringVariant("who"), Variant{VariantType: VAR_MAP, VariantValue: inner})
+			return Variant{VariantType: VAR_MAP, VariantValue: m}
+		}()},
+		{desc: "tag with non-string value stays a map", input: `{"$date":5}`, expected: func() Variant {
+			m, _ := NewVariantMap().Set(stringVariant("$date"), Variant{VariantType: VAR_INT, VariantValue: int64(5)})
+			return Variant{VariantType: VAR_MAP, VariantValue: m}
+		}()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			actual, e := ParseJSON(test.input)
+			assert.Nil(t, e)
+			assert.Equal(t, test.expected, actual)
+
+			// json.Unmarshal decodes plain data too
+			actual = Variant{}
+			e = json.Unmarshal([]byte(test.input), &actual)
+			assert.Nil(t, e)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+
+	_, e := ParseJSON(`{} {}`)
+	assert.Equal(t, buildJSONTrailingDataError(), e)
+
+	_, e = ParseJSON(`{"a":`)
+	assert.NotNil(t, e)
+}
@@ -0,0 +1,148 @@
+package golisp
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type variantKey struct {
+	variantType EnumVariantType
+	value       interface{}
+}
+
+func (b *Variant) hashKey() (variantKey, error) {
+	v, e := b.GetTypeConsistentValue()
+	if e != nil {
+		return variantKey{}, e
+	}
+
+	switch b.VariantType {
+	case VAR_NULL, VAR_BOOL, VAR_INT, VAR_FLOAT, VAR_STRING, VAR_IDENT, VAR_KEYWORD:
+		return variantKey{variantType: b.VariantType, value: v}, nil
+	case VAR_DATE:
+		return variantKey{variantType: b.VariantType, value: v.(time.Time).UnixNano()}, nil
+	case VAR_LIST, VAR_VECTOR, VAR_MAP:
+		return variantKey{variantType: b.VariantType, value: b.ToDebugString()}, nil
+	case VAR_SET:
+		return variantKey{variantType: b.VariantType, value: v.(*VariantSet).canonicalString()}, nil
+	default:
+		return variantKey{}, buildUnhashableTypeError(b.VariantType)
+	}
+}
+
+// VariantMap is an immutable associative array keyed by variants, which remembers insertion order.
+type VariantMap struct {
+	keys   []Variant
+	values []Variant
+	index  map[variantKey]int
+}
+
+func NewVariantMap() *VariantMap {
+	return &VariantMap{
+		keys:   []Variant{},
+		values: []Variant{},
+		index:  map[variantKey]int{},
+	}
+}
+
+func (m *VariantMap) Len() int {
+	return len(m.keys)
+}
+
+func (m *VariantMap) Keys() []Variant {
+	return append([]Variant{}, m.keys...)
+}
+
+func (m *VariantMap) Values() []Variant {
+	return append([]Variant{}, m.values...)
+}
+
+func (m *VariantMap) Get(key Variant) (Variant, bool) {
+	k, e := key.hashKey()
+	if e != nil {
+		return Variant{VariantType: VAR_NULL}, false
+	}
+
+	if i, found := m.index[k]; found {
+		return m.values[i], true
+	}
+	return Variant{VariantType: VAR_NULL}, false
+}
+
+// Set returns a new map with the key bound to the value, leaving the receiver untouched.
+func (m *VariantMap) Set(key Variant, value Variant) (*VariantMap, error) {
+	result := m.clone()
+	if e := result.put(key, value); e != nil {
+		return m, e
+	}
+	return result, nil
+}
+
+// Delete returns a new map without the key, leaving the receiver untouched.
+func (m *VariantMap) Delete(key Variant) (*VariantMap, error) {
+	k, e := key.hashKey()
+	if e != nil {
+		return m, e
+	}
+
+	if _, found := m.index[k]; !found {
+		return m, nil
+	}
+
+	result := NewVariantMap()
+	for i := range m.keys {
+		if kk, _ := m.keys[i].hashKey(); kk != k {
+			result.put(m.keys[i], m.values[i])
+		}
+	}
+	return result, nil
+}
+
+// Merge returns a new map with the entries of other added to the receiver's, with other winning on conflicts.
+func (m *VariantMap) Merge(other *VariantMap) *VariantMap {
+	result := m.clone()
+	for i := range other.keys {
+		result.put(other.keys[i], other.values[i])
+	}
+	return result
+}
+
+func (m *VariantMap) clone() *VariantMap {
+	result := &VariantMap{
+		keys:   append(make([]Variant, 0, len(m.keys)+1), m.keys...),
+		values: append(make([]Variant, 0, len(m.values)+1), m.values...),
+		index:  make(map[variantKey]int, len(m.index)+1),
+	}
+	for k, i := range m.index {
+		result.index[k] = i
+	}
+	return result
+}
+
+// put mutates the map in place, and must only be used while a new map is being built.
+func (m *VariantMap) put(key Variant, value Variant) error {
+	k, e := key.hashKey()
+	if e != nil {
+		return e
+	}
+
+	if i, found := m.index[k]; found {
+		m.values[i] = value
+		return nil
+	}
+
+	m.index[k] = len(m.keys)
+	m.keys = append(m.keys, key)
+	m.values = append(m.values, value)
+	return nil
+}
+
+func (m *VariantMap) debugString() string {
+	builder := strings.Builder{}
+	for i := range m.keys {
+		builder.WriteString(fmt.Sprintf("%s %s ", m.keys[i].toElementDebugString(), m.values[i].toElementDebugString()))
+	}
+
+	return fmt.Sprintf("{%s}", strings.TrimSpace(builder.String()))
+}
@@ -0,0 +1,174 @@
+package golisp
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// PropertyAccessor can be implemented by host objects bound as VAR_OBJECT to control which properties
+// scripts can see, or to compute them lazily. Objects that don't implement it expose their exported
+// struct fields (honouring `golisp:"name"` tags) or their string-keyed map entries.
+type PropertyAccessor interface {
+	GetProperty(name string) (interface{}, bool)
+}
+
+var propertyAccessorGoType = reflect.TypeOf((*PropertyAccessor)(nil)).Elem()
+
+func isHostObjectType(t reflect.Type) bool {
+	if t.Implements(propertyAccessorGoType) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return t.Elem().Kind() == reflect.Struct && t.Elem() != timeGoType
+	case reflect.Struct:
+		return t != timeGoType && t != variantGoType
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String
+	}
+	return false
+}
+
+// wrapHostValue converts a property value, keeping structs and maps as VAR_OBJECT so that they are not copied.
+func wrapHostValue(v reflect.Value) (Variant, error) {
+	if !v.IsValid() {
+		return Variant{VariantType: VAR_NULL}, nil
+	}
+
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return Variant{VariantType: VAR_NULL}, nil
+		}
+		v = v.Elem()
+	}
+
+	if isHostObjectType(v.Type()) {
+		if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Map) && v.IsNil() {
+			return Variant{VariantType: VAR_NULL}, nil
+		}
+		return Variant{VariantType: VAR_OBJECT, VariantValue: v.Interface()}, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem() == variantGoType || !isHostObjectType(v.Type().Elem()) && v.Type().Elem().Kind() != reflect.Interface {
+			break
+		}
+		items := make([]Variant, v.Len())
+		for i := range items {
+			item, e := wrapHostValue(v.Index(i))
+			if e != nil {
+				return Variant{VariantType: VAR_ERROR}, e
+			}
+			items[i] = item
+		}
+		return Variant{VariantType: VAR_VECTOR, VariantValue: items}, nil
+	}
+
+	return variantFromValue(v)
+}
+
+func lookupHostProperty(object interface{}, name string) (Variant, bool, error) {
+	if accessor, ok := object.(PropertyAccessor); ok {
+		value, found := accessor.GetProperty(name)
+		if !found {
+			return Variant{VariantType: VAR_NULL}, false, nil
+		}
+		v, e := wrapHostValue(reflect.ValueOf(value))
+		return v, true, e
+	}
+
+	v := reflect.ValueOf(object)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return Variant{VariantType: VAR_NULL}, false, nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if field, found := findStructField(v.Type(), name); found {
+			r, e := wrapHostValue(v.FieldByIndex(field.index))
+			return r, true, e
+		}
+
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			break
+		}
+		item := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+		if item.IsValid() {
+			r, e := wrapHostValue(item)
+			return r, true, e
+		}
+	}
+
+	return Variant{VariantType: VAR_NULL}, false, nil
+}
+
+// getProperty looks up a key in a map, a field of a host object, or an index in a list or vector.
+func getProperty(container Variant, key Variant) (Variant, bool, error) {
+	switch container.VariantType {
+	case VAR_MAP:
+		m, e := container.GetMapValue()
+		if e != nil {
+			return Variant{VariantType: VAR_ERROR}, false, e
+		}
+		v, found := m.Get(key)
+		return v, found, nil
+
+	case VAR_OBJECT:
+		name, e := keyName(key)
+		if e != nil {
+			return Variant{VariantType: VAR_ERROR}, false, e
+		}
+		return lookupHostProperty(container.VariantValue, name)
+
+	case VAR_LIST, VAR_VECTOR:
+		items, e := container.GetSequenceValue()
+		if e != nil {
+			return Variant{VariantType: VAR_ERROR}, false, e
+		}
+		i, e := key.CoerceToInt()
+		if e != nil || key.VariantType != VAR_INT {
+			return Variant{VariantType: VAR_ERROR}, false, buildTypeError(key.VariantType, VAR_INT)
+		}
+		if i < 0 || i >= int64(len(items)) {
+			return Variant{VariantType: VAR_NULL}, false, nil
+		}
+		return items[i], true, nil
+	}
+
+	return Variant{VariantType: VAR_ERROR}, false, buildTypeError(container.VariantType, VAR_OBJECT)
+}
+
+// getPathSegment interprets one segment of a dotted identifier, which is an index for lists and a name otherwise.
+// Map entries are found under either a string or a keyword key.
+func getPathSegment(container Variant, segment string) (Variant, bool, error) {
+	if container.VariantType == VAR_LIST || container.VariantType == VAR_VECTOR {
+		i, e := strconv.ParseInt(segment, 10, 64)
+		if e != nil {
+			return Variant{VariantType: VAR_NULL}, false, nil
+		}
+		return getProperty(container, Variant{VariantType: VAR_INT, VariantValue: i})
+	}
+
+	v, found, e := getProperty(container, Variant{VariantType: VAR_STRING, VariantValue: segment})
+	if !found && e == nil && container.VariantType == VAR_MAP {
+		return getProperty(container, Variant{VariantType: VAR_KEYWORD, VariantValue: keywordPrefix + segment})
+	}
+	return v, found, e
+}
+
+// keyName returns the name a string or keyword key refers to, for looking up fields and converting to go.
+func keyName(key Variant) (string, error) {
+	switch key.VariantType {
+	case VAR_KEYWORD:
+		return key.GetKeywordName()
+	case VAR_STRING:
+		return key.CoerceToString()
+	}
+	return "", buildTypeError(key.VariantType, VAR_STRING)
+}
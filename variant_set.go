@@ -0,0 +1,100 @@
+package golisp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// VariantSet is an immutable collection of distinct variants, which remembers insertion order.
+// Membership follows the same type-strict rules as map keys.
+type VariantSet struct {
+	items *VariantMap
+}
+
+func NewVariantSet() *VariantSet {
+	return &VariantSet{items: NewVariantMap()}
+}
+
+func (s *VariantSet) Len() int {
+	return s.items.Len()
+}
+
+func (s *VariantSet) Items() []Variant {
+	return s.items.Keys()
+}
+
+func (s *VariantSet) Contains(item Variant) bool {
+	_, found := s.items.Get(item)
+	return found
+}
+
+// Add returns a new set with the item included, leaving the receiver untouched.
+func (s *VariantSet) Add(item Variant) (*VariantSet, error) {
+	result := s.clone()
+	if e := result.put(item); e != nil {
+		return s, e
+	}
+	return result, nil
+}
+
+func (s *VariantSet) Union(other *VariantSet) *VariantSet {
+	result := s.clone()
+	for _, item := range other.items.keys {
+		result.put(item)
+	}
+	return result
+}
+
+func (s *VariantSet) Intersection(other *VariantSet) *VariantSet {
+	result := NewVariantSet()
+	for _, item := range s.items.keys {
+		if other.Contains(item) {
+			result.put(item)
+		}
+	}
+	return result
+}
+
+func (s *VariantSet) Difference(other *VariantSet) *VariantSet {
+	result := NewVariantSet()
+	for _, item := range s.items.keys {
+		if !other.Contains(item) {
+			result.put(item)
+		}
+	}
+	return result
+}
+
+func (s *VariantSet) IsSubset(other *VariantSet) bool {
+	for _, item := range s.items.keys {
+		if !other.Contains(item) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *VariantSet) clone() *VariantSet {
+	return &VariantSet{items: s.items.clone()}
+}
+
+// put mutates the set in place, and must only be used while a new set is being built.
+func (s *VariantSet) put(item Variant) error {
+	return s.items.put(item, Variant{VariantType: VAR_NULL})
+}
+
+func (s *VariantSet) debugString() string {
+	return fmt.Sprintf("#{%s}", joinDebugStrings(s.items.keys))
+}
+
+// canonicalString lists the items in sorted order, so that equal sets hash to the same key regardless of insertion order.
+func (s *VariantSet) canonicalString() string {
+	items := make([]string, len(s.items.keys))
+	for i := range s.items.keys {
+		items[i] = s.items.keys[i].toElementDebugString()
+	}
+	sort.Strings(items)
+
+	return fmt.Sprintf("#{%s}", strings.Join(items, " "))
+}
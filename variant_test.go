@@ -47,6 +47,11 @@ func TestToDebugString(t *testing.T) {
 		{desc: "VAR_STRING", input: Variant{VariantType: VAR_STRING, VariantValue: "Henlo!"}, expectedValue: "Henlo!"},
 		{desc: "VAR_IDENT", input: Variant{VariantType: VAR_IDENT, VariantValue: "w"}, expectedValue: "w"},
 		{desc: "VAR_ERROR", input: Variant{VariantType: VAR_ERROR, VariantValue: errRandom}, expectedValue: errRandom.Error()},
+		{desc: "VAR_LIST", input: Variant{VariantType: VAR_LIST, VariantValue: []Variant{{VariantType: VAR_INT, VariantValue: 1}, {VariantType: VAR_STRING, VariantValue: "a"}}}, expectedValue: `(1 "a")`},
+		{desc: "VAR_MAP", input: Variant{VariantType: VAR_MAP, VariantValue: NewVariantMap()}, expectedValue: "{}"},
+		{desc: "VAR_KEYWORD", input: Variant{VariantType: VAR_KEYWORD, VariantValue: ":ok"}, expectedValue: ":ok"},
+		{desc: "VAR_SET", input: Variant{VariantType: VAR_SET, VariantValue: NewVariantSet()}, expectedValue: "#{}"},
+		{desc: "inconsistent keyword", input: Variant{VariantType: VAR_KEYWORD, VariantValue: "ok"}, expectedValue: "type error: value [ok] is inconsistent with type \"VAR_KEYWORD\""},
 		{desc: "inconsistent", input: Variant{VariantType: VAR_DATE, VariantValue: "Some Random String"}, expectedValue: "type error: value [Some Random String] is inconsistent with type \"VAR_DATE\""},
 		// {desc: "VAR_MAX", input: Variant{VariantType: VAR_MAX, VariantValue: nil}, expectedValue: "UNKNOWN"}, should panic
 	}